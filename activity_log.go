@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -114,3 +115,23 @@ func (alr *ActivityLogRequest) GetList(ctx context.Context, activityLogListInput
 
 	return activityLogResult, nil
 }
+
+// All iterates over every activity log matching the input, fetching pages as needed.
+func (alr *ActivityLogRequest) All(ctx context.Context, activityLogListInput *ActivityLogListInput, opts ...IteratorOption) iter.Seq2[ActivityLog, error] {
+	input := ActivityLogListInput{}
+	if activityLogListInput != nil {
+		input = *activityLogListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]ActivityLog, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := alr.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.ActivityLogs, result.Meta, nil
+	}, opts)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -111,6 +112,26 @@ func (adr *AddOnRequest) GetList(ctx context.Context, addOnListInput *AddOnListI
 	return addOnResult, nil
 }
 
+// All iterates over every add on matching the input, fetching pages as needed.
+func (adr *AddOnRequest) All(ctx context.Context, addOnListInput *AddOnListInput, opts ...IteratorOption) iter.Seq2[AddOn, error] {
+	input := AddOnListInput{}
+	if addOnListInput != nil {
+		input = *addOnListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]AddOn, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := adr.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.AddOns, result.Meta, nil
+	}, opts)
+}
+
 func (adr *AddOnRequest) Create(ctx context.Context, addOnInput *AddOnInput) (*AddOn, *Error) {
 	addOnParams := &AddOnParams{
 		AddOn: addOnInput,
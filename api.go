@@ -0,0 +1,436 @@
+package subrow
+
+import (
+	"context"
+	"crypto/rsa"
+	"iter"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+//go:generate go run ./internal/cmd/mockgen -source api.go -destination api_mock.go
+
+// The XxxAPI interfaces are implemented by the matching XxxRequest types, so
+// code depending on them can be tested with the mocks in api_mock.go.
+
+type ActivityLogAPI interface {
+	Get(ctx context.Context, activityId string) (*ActivityLog, *Error)
+	GetList(ctx context.Context, activityLogListInput *ActivityLogListInput) (*ActivityLogResult, *Error)
+	All(ctx context.Context, activityLogListInput *ActivityLogListInput, opts ...IteratorOption) iter.Seq2[ActivityLog, error]
+}
+
+type AddOnAPI interface {
+	Get(ctx context.Context, addOnCode string) (*AddOn, *Error)
+	GetList(ctx context.Context, addOnListInput *AddOnListInput) (*AddOnResult, *Error)
+	All(ctx context.Context, addOnListInput *AddOnListInput, opts ...IteratorOption) iter.Seq2[AddOn, error]
+	Create(ctx context.Context, addOnInput *AddOnInput) (*AddOn, *Error)
+	Update(ctx context.Context, addOnInput *AddOnInput) (*AddOn, *Error)
+	Delete(ctx context.Context, addOnCode string) (*AddOn, *Error)
+}
+
+type AlertAPI interface {
+	Get(ctx context.Context, subscriptionExternalID, alertCode string) (*Alert, *Error)
+	GetList(ctx context.Context, subscriptionExternalID string) (*AlertResult, *Error)
+	Create(ctx context.Context, subscriptionExternalID string, alertInput *AlertInput) (*Alert, *Error)
+	Update(ctx context.Context, subscriptionExternalID, alertCode string, alertInput *AlertInput) (*Alert, *Error)
+	Delete(ctx context.Context, subscriptionExternalID, alertCode string) (*Alert, *Error)
+}
+
+type ApiLogAPI interface {
+	Get(ctx context.Context, requestId string) (*ApiLog, *Error)
+	GetList(ctx context.Context, apiLogListInput *ApiLogListInput) (*ApiLogResult, *Error)
+	All(ctx context.Context, apiLogListInput *ApiLogListInput, opts ...IteratorOption) iter.Seq2[ApiLog, error]
+}
+
+type AppliedCouponAPI interface {
+	GetList(ctx context.Context, appliedCouponListInput *AppliedCouponListInput) (*AppliedCouponResult, *Error)
+	All(ctx context.Context, appliedCouponListInput *AppliedCouponListInput, opts ...IteratorOption) iter.Seq2[AppliedCoupon, error]
+	AppliedCouponDelete(ctx context.Context, externalCustomerID string, appliedCouponID string) (*AppliedCoupon, *Error)
+}
+
+type BillableMetricAPI interface {
+	Get(ctx context.Context, billableMetricCode string) (*BillableMetric, *Error)
+	GetList(ctx context.Context, billableMetricListInput *BillableMetricListInput) (*BillableMetricResult, *Error)
+	All(ctx context.Context, billableMetricListInput *BillableMetricListInput, opts ...IteratorOption) iter.Seq2[BillableMetric, error]
+	Create(ctx context.Context, billableMetricInput *BillableMetricInput) (*BillableMetric, *Error)
+	Update(ctx context.Context, billableMetricInput *BillableMetricInput) (*BillableMetric, *Error)
+	Delete(ctx context.Context, billableMetricCode string) (*BillableMetric, *Error)
+	EvaluateExpression(ctx context.Context, evaluateExpressingInput *BillableMetricEvaluateExpressionInput) (*BillableMetricEvaluateExpressionResultValue, *Error)
+}
+
+type BillingEntityAPI interface {
+	Create(ctx context.Context, billingEntityInput *BillingEntityCreateInput) (*BillingEntity, *Error)
+	Get(ctx context.Context, billingEntityCode string) (*BillingEntity, *Error)
+	GetList(ctx context.Context) (*BillingEntityResult, *Error)
+	Update(ctx context.Context, billingEntityCode string, billingEntityInput *BillingEntityUpdateInput) (*BillingEntity, *Error)
+}
+
+type CouponAPI interface {
+	Get(ctx context.Context, couponCode string) (*Coupon, *Error)
+	GetList(ctx context.Context, couponListInput *CouponListInput) (*CouponResult, *Error)
+	All(ctx context.Context, couponListInput *CouponListInput, opts ...IteratorOption) iter.Seq2[Coupon, error]
+	Create(ctx context.Context, couponInput *CouponInput) (*Coupon, *Error)
+	Update(ctx context.Context, couponInput *CouponInput) (*Coupon, *Error)
+	Delete(ctx context.Context, couponCode string) (*Coupon, *Error)
+	ApplyToCustomer(ctx context.Context, applyCouponInput *ApplyCouponInput) (*AppliedCoupon, *Error)
+}
+
+type CreditNoteAPI interface {
+	Get(ctx context.Context, creditNoteID uuid.UUID) (*CreditNote, *Error)
+	Download(ctx context.Context, creditNoteID string) (*CreditNote, *Error)
+	GetList(ctx context.Context, creditNoteListInput *CreditListInput) (*CreditNoteResult, *Error)
+	All(ctx context.Context, creditNoteListInput *CreditListInput, opts ...IteratorOption) iter.Seq2[CreditNote, error]
+	Create(ctx context.Context, creditNoteInput *CreditNoteInput) (*CreditNote, *Error)
+	Update(ctx context.Context, creditNoteUpdateInput *CreditNoteUpdateInput) (*CreditNote, *Error)
+	Void(ctx context.Context, creditNoteID string) (*CreditNote, *Error)
+	Estimate(ctx context.Context, creditNoteEstimateInput *CreditNoteEstimateInput) (*CreditNoteEstimated, *Error)
+}
+
+type CustomerAPI interface {
+	Create(ctx context.Context, customerInput *CustomerInput) (*Customer, *Error)
+	Update(ctx context.Context, customerInput *CustomerInput) (*Customer, *Error)
+	CurrentUsage(ctx context.Context, externalCustomerID string, customerUsageInput *CustomerUsageInput) (*CustomerUsage, *Error)
+	PastUsage(ctx context.Context, externalCustomerID string, customerPastUsageInput *CustomerPastUsageInput) (*CustomerPastUsageResult, *Error)
+	PortalUrl(ctx context.Context, externalCustomerID string) (*CustomerPortalUrl, *Error)
+	CheckoutUrl(ctx context.Context, externalCustomerID string) (*CustomerCheckoutUrl, *Error)
+	Delete(ctx context.Context, externalCustomerID string) (*Customer, *Error)
+	Get(ctx context.Context, externalCustomerID string) (*Customer, *Error)
+	GetList(ctx context.Context, customerListInput *CustomerListInput) (*CustomerResult, *Error)
+	All(ctx context.Context, customerListInput *CustomerListInput, opts ...IteratorOption) iter.Seq2[Customer, error]
+}
+
+type EventAPI interface {
+	Create(ctx context.Context, eventInput *EventInput) (*Event, *Error)
+	EstimateFees(ctx context.Context, estimateInput EventEstimateFeesInput) (*FeeResult, *Error)
+	Get(ctx context.Context, eventID string) (*Event, *Error)
+	Batch(ctx context.Context, batchInput *[]EventInput) (*[]Event, *Error)
+}
+
+type FeeAPI interface {
+	Get(ctx context.Context, feeID string) (*Fee, *Error)
+	Update(ctx context.Context, feeInput *FeeUpdateInput) (*Fee, *Error)
+	GetList(ctx context.Context, feeListInput *FeeListInput) (*FeeResult, *Error)
+	All(ctx context.Context, feeListInput *FeeListInput, opts ...IteratorOption) iter.Seq2[Fee, error]
+	Delete(ctx context.Context, feeID string) (*Fee, *Error)
+}
+
+type GrossRevenueAPI interface {
+	GetList(ctx context.Context, grossRevenueListInput *GrossRevenueListInput) (*GrossRevenueResult, *Error)
+}
+
+type InvoiceAPI interface {
+	Get(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	GetList(ctx context.Context, invoiceListInput *InvoiceListInput) (*InvoiceResult, *Error)
+	All(ctx context.Context, invoiceListInput *InvoiceListInput, opts ...IteratorOption) iter.Seq2[Invoice, error]
+	Create(ctx context.Context, oneOffInput *InvoiceOneOffInput) (*Invoice, *Error)
+	Preview(ctx context.Context, invoicePreviewInput *InvoicePreviewInput) (*Invoice, *Error)
+	Update(ctx context.Context, invoiceInput *InvoiceInput) (*Invoice, *Error)
+	Download(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	Refresh(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	Retry(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	Finalize(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	Void(ctx context.Context, invoiceID string, opts *VoidInvoiceOptions) (*Invoice, *Error)
+	LoseDispute(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	RetryPayment(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	PaymentUrl(ctx context.Context, invoiceID string) (*InvoicePaymentUrl, *Error)
+}
+
+type InvoiceCollectionAPI interface {
+	GetList(ctx context.Context, invoiceCollectionListInput *InvoiceCollectionListInput) (*InvoiceCollectionResult, *Error)
+}
+
+type InvoicedUsageAPI interface {
+	GetList(ctx context.Context, invoicedUsageListInput *InvoicedUsageListInput) (*InvoicedUsageResult, *Error)
+}
+
+type MrrAPI interface {
+	GetList(ctx context.Context, mrrListInput *MrrListInput) (*MrrResult, *Error)
+}
+
+type OrganizationAPI interface {
+	Update(ctx context.Context, organizationInput *OrganizationInput) (*Organization, *Error)
+}
+
+type OverdueBalanceAPI interface {
+	GetList(ctx context.Context, overdueBalanceListInput *OverdueBalanceListInput) (*OverdueBalanceResult, *Error)
+}
+
+type PaymentAPI interface {
+	Get(ctx context.Context, paymentID string) (*Payment, *Error)
+	GetList(ctx context.Context, paymentListInput *PaymentListInput) (*PaymentResult, *Error)
+	All(ctx context.Context, paymentListInput *PaymentListInput, opts ...IteratorOption) iter.Seq2[Payment, error]
+	Create(ctx context.Context, paymentInput *PaymentInput) (*Payment, *Error)
+}
+
+type PaymentReceiptAPI interface {
+	Get(ctx context.Context, id string) (*PaymentReceipt, *Error)
+	GetList(ctx context.Context, paymentReceiptListInput *PaymentReceiptListInput) (*PaymentReceiptResult, *Error)
+	All(ctx context.Context, paymentReceiptListInput *PaymentReceiptListInput, opts ...IteratorOption) iter.Seq2[PaymentReceipt, error]
+}
+
+type PaymentRequestAPI interface {
+	GetList(ctx context.Context, paymentRequestListInput *PaymentRequestListInput) (*PaymentRequestResult, *Error)
+	All(ctx context.Context, paymentRequestListInput *PaymentRequestListInput, opts ...IteratorOption) iter.Seq2[PaymentRequest, error]
+	Create(ctx context.Context, paymentRequestInput *PaymentRequestInput) (*PaymentRequest, *Error)
+}
+
+type PlanAPI interface {
+	Get(ctx context.Context, planCode string) (*Plan, *Error)
+	GetList(ctx context.Context, planListInput *PlanListInput) (*PlanResult, *Error)
+	All(ctx context.Context, planListInput *PlanListInput, opts ...IteratorOption) iter.Seq2[Plan, error]
+	Create(ctx context.Context, planInput *PlanInput) (*Plan, *Error)
+	Update(ctx context.Context, planInput *PlanInput) (*Plan, *Error)
+	Delete(ctx context.Context, planCode string) (*Plan, *Error)
+}
+
+type SubscriptionAPI interface {
+	GetLifetimeUsage(ctx context.Context, externalSubscriptionID string) (*LifetimeUsage, *Error)
+	UpdateLifetimeUsage(ctx context.Context, lifetimeUsageInput *LifetimeUsageInput) (*LifetimeUsage, *Error)
+	Create(ctx context.Context, subscriptionInput *SubscriptionInput) (*Subscription, *Error)
+	Terminate(ctx context.Context, subscriptionTerminateInput SubscriptionTerminateInput) (*Subscription, *Error)
+	Get(ctx context.Context, subscriptionExternalId string) (*Subscription, *Error)
+	GetList(ctx context.Context, subscriptionListInput SubscriptionListInput) (*SubscriptionResult, *Error)
+	All(ctx context.Context, subscriptionListInput SubscriptionListInput, opts ...IteratorOption) iter.Seq2[Subscription, error]
+	Update(ctx context.Context, subscriptionInput *SubscriptionInput) (*Subscription, *Error)
+}
+
+type TaxAPI interface {
+	Get(ctx context.Context, taxCode string) (*Tax, *Error)
+	GetList(ctx context.Context, taxListInput *TaxListInput) (*TaxResult, *Error)
+	All(ctx context.Context, taxListInput *TaxListInput, opts ...IteratorOption) iter.Seq2[Tax, error]
+	Create(ctx context.Context, taxInput *TaxInput) (*Tax, *Error)
+	Update(ctx context.Context, taxInput *TaxInput) (*Tax, *Error)
+	Delete(ctx context.Context, taxCode string) (*Tax, *Error)
+}
+
+type WalletAPI interface {
+	Get(ctx context.Context, walletID string) (*Wallet, *Error)
+	GetList(ctx context.Context, walletListInput *WalletListInput) (*WalletResult, *Error)
+	All(ctx context.Context, walletListInput *WalletListInput, opts ...IteratorOption) iter.Seq2[Wallet, error]
+	Create(ctx context.Context, walletInput *WalletInput) (*Wallet, *Error)
+	Update(ctx context.Context, walletInput *WalletInput, walletID string) (*Wallet, *Error)
+	Delete(ctx context.Context, walletID string) (*Wallet, *Error)
+}
+
+type WalletTransactionAPI interface {
+	Create(ctx context.Context, walletTransactionInput *WalletTransactionInput) (*WalletTransactionResult, *Error)
+	GetList(ctx context.Context, walletTransactionListInput *WalletTransactionListInput) (*WalletTransactionResult, *Error)
+	All(ctx context.Context, walletTransactionListInput *WalletTransactionListInput, opts ...IteratorOption) iter.Seq2[WalletTransaction, error]
+	PaymentUrl(ctx context.Context, walletTransactionID string) (*WalletTransactionPaymentUrl, *Error)
+}
+
+type WebhookAPI interface {
+	GetPublicKey(ctx context.Context) (*rsa.PublicKey, *Error)
+	ValidateSignature(ctx context.Context, signature string) (bool, *Error)
+	ValidateBody(ctx context.Context, signature string, body string) (bool, *Error)
+	Handler(dispatcher *WebhookDispatcher, opts *WebhookHandlerOptions) http.Handler
+	ValidateHmacBody(ctx context.Context, signature string, body string) (bool, *Error)
+	ValidateBodyWithAlgo(ctx context.Context, signatureAlgo SignatureAlgo, signature string, body string) (bool, *Error)
+}
+
+type WebhookEndpointAPI interface {
+	Get(ctx context.Context, webhookEndpointID string) (*WebhookEndpoint, *Error)
+	GetList(ctx context.Context, webhookEndpointListInput *WebhookEndpointListInput) (*WebhookEndpointResult, *Error)
+	All(ctx context.Context, webhookEndpointListInput *WebhookEndpointListInput, opts ...IteratorOption) iter.Seq2[WebhookEndpoint, error]
+	Create(ctx context.Context, webhookEndpointInput *WebhookEndpointInput) (*WebhookEndpoint, *Error)
+	Update(ctx context.Context, webhookEndpointInput *WebhookEndpointInput, webhookEndpointID string) (*WebhookEndpoint, *Error)
+	Delete(ctx context.Context, webhookEndpointID string) (*WebhookEndpoint, *Error)
+}
+
+// API gives access to every resource of the Subrow API. Client.API returns
+// the implementation backed by a Client.
+type API interface {
+	ActivityLog() ActivityLogAPI
+	AddOn() AddOnAPI
+	Alert() AlertAPI
+	ApiLog() ApiLogAPI
+	AppliedCoupon() AppliedCouponAPI
+	BillableMetric() BillableMetricAPI
+	BillingEntity() BillingEntityAPI
+	Coupon() CouponAPI
+	CreditNote() CreditNoteAPI
+	Customer() CustomerAPI
+	Event() EventAPI
+	Fee() FeeAPI
+	GrossRevenue() GrossRevenueAPI
+	Invoice() InvoiceAPI
+	InvoiceCollection() InvoiceCollectionAPI
+	InvoicedUsage() InvoicedUsageAPI
+	Mrr() MrrAPI
+	Organization() OrganizationAPI
+	OverdueBalance() OverdueBalanceAPI
+	Payment() PaymentAPI
+	PaymentReceipt() PaymentReceiptAPI
+	PaymentRequest() PaymentRequestAPI
+	Plan() PlanAPI
+	Subscription() SubscriptionAPI
+	Tax() TaxAPI
+	Wallet() WalletAPI
+	WalletTransaction() WalletTransactionAPI
+	Webhook() WebhookAPI
+	WebhookEndpoint() WebhookEndpointAPI
+	HealthCheck(ctx context.Context) (*HealthCheckResponse, error)
+}
+
+var (
+	_ ActivityLogAPI       = (*ActivityLogRequest)(nil)
+	_ AddOnAPI             = (*AddOnRequest)(nil)
+	_ AlertAPI             = (*AlertRequest)(nil)
+	_ ApiLogAPI            = (*ApiLogRequest)(nil)
+	_ AppliedCouponAPI     = (*AppliedCouponRequest)(nil)
+	_ BillableMetricAPI    = (*BillableMetricRequest)(nil)
+	_ BillingEntityAPI     = (*BillingEntityRequest)(nil)
+	_ CouponAPI            = (*CouponRequest)(nil)
+	_ CreditNoteAPI        = (*CreditNoteRequest)(nil)
+	_ CustomerAPI          = (*CustomerRequest)(nil)
+	_ EventAPI             = (*EventRequest)(nil)
+	_ FeeAPI               = (*FeeRequest)(nil)
+	_ GrossRevenueAPI      = (*GrossRevenueRequest)(nil)
+	_ InvoiceAPI           = (*InvoiceRequest)(nil)
+	_ InvoiceCollectionAPI = (*InvoiceCollectionRequest)(nil)
+	_ InvoicedUsageAPI     = (*InvoicedUsageRequest)(nil)
+	_ MrrAPI               = (*MrrRequest)(nil)
+	_ OrganizationAPI      = (*OrganizationRequest)(nil)
+	_ OverdueBalanceAPI    = (*OverdueBalanceRequest)(nil)
+	_ PaymentAPI           = (*ManualPaymentRequest)(nil)
+	_ PaymentReceiptAPI    = (*PaymentReceiptRequest)(nil)
+	_ PaymentRequestAPI    = (*PaymentRequestRequest)(nil)
+	_ PlanAPI              = (*PlanRequest)(nil)
+	_ SubscriptionAPI      = (*SubscriptionRequest)(nil)
+	_ TaxAPI               = (*TaxRequest)(nil)
+	_ WalletAPI            = (*WalletRequest)(nil)
+	_ WalletTransactionAPI = (*WalletTransactionRequest)(nil)
+	_ WebhookAPI           = (*WebhookRequest)(nil)
+	_ WebhookEndpointAPI   = (*WebhookEndpointRequest)(nil)
+)
+
+type clientAPI struct {
+	client *Client
+}
+
+func (a clientAPI) ActivityLog() ActivityLogAPI {
+	return a.client.ActivityLog()
+}
+
+func (a clientAPI) AddOn() AddOnAPI {
+	return a.client.AddOn()
+}
+
+func (a clientAPI) Alert() AlertAPI {
+	return a.client.Alert()
+}
+
+func (a clientAPI) ApiLog() ApiLogAPI {
+	return a.client.ApiLog()
+}
+
+func (a clientAPI) AppliedCoupon() AppliedCouponAPI {
+	return a.client.AppliedCoupon()
+}
+
+func (a clientAPI) BillableMetric() BillableMetricAPI {
+	return a.client.BillableMetric()
+}
+
+func (a clientAPI) BillingEntity() BillingEntityAPI {
+	return a.client.BillingEntity()
+}
+
+func (a clientAPI) Coupon() CouponAPI {
+	return a.client.Coupon()
+}
+
+func (a clientAPI) CreditNote() CreditNoteAPI {
+	return a.client.CreditNote()
+}
+
+func (a clientAPI) Customer() CustomerAPI {
+	return a.client.Customer()
+}
+
+func (a clientAPI) Event() EventAPI {
+	return a.client.Event()
+}
+
+func (a clientAPI) Fee() FeeAPI {
+	return a.client.Fee()
+}
+
+func (a clientAPI) GrossRevenue() GrossRevenueAPI {
+	return a.client.GrossRevenue()
+}
+
+func (a clientAPI) Invoice() InvoiceAPI {
+	return a.client.Invoice()
+}
+
+func (a clientAPI) InvoiceCollection() InvoiceCollectionAPI {
+	return a.client.InvoiceCollection()
+}
+
+func (a clientAPI) InvoicedUsage() InvoicedUsageAPI {
+	return a.client.InvoicedUsage()
+}
+
+func (a clientAPI) Mrr() MrrAPI {
+	return a.client.Mrr()
+}
+
+func (a clientAPI) Organization() OrganizationAPI {
+	return a.client.Organization()
+}
+
+func (a clientAPI) OverdueBalance() OverdueBalanceAPI {
+	return a.client.OverdueBalance()
+}
+
+func (a clientAPI) Payment() PaymentAPI {
+	return a.client.Payment()
+}
+
+func (a clientAPI) PaymentReceipt() PaymentReceiptAPI {
+	return a.client.PaymentReceipt()
+}
+
+func (a clientAPI) PaymentRequest() PaymentRequestAPI {
+	return a.client.PaymentRequest()
+}
+
+func (a clientAPI) Plan() PlanAPI {
+	return a.client.Plan()
+}
+
+func (a clientAPI) Subscription() SubscriptionAPI {
+	return a.client.Subscription()
+}
+
+func (a clientAPI) Tax() TaxAPI {
+	return a.client.Tax()
+}
+
+func (a clientAPI) Wallet() WalletAPI {
+	return a.client.Wallet()
+}
+
+func (a clientAPI) WalletTransaction() WalletTransactionAPI {
+	return a.client.WalletTransaction()
+}
+
+func (a clientAPI) Webhook() WebhookAPI {
+	return a.client.Webhook()
+}
+
+func (a clientAPI) WebhookEndpoint() WebhookEndpointAPI {
+	return a.client.WebhookEndpoint()
+}
+
+func (a clientAPI) HealthCheck(ctx context.Context) (*HealthCheckResponse, error) {
+	return a.client.HealthCheck(ctx)
+}
+
+// API returns c as an API, whose accessors return interfaces instead of
+// concrete request types.
+func (c *Client) API() API {
+	return clientAPI{client: c}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -109,3 +110,23 @@ func (alr *ApiLogRequest) GetList(ctx context.Context, apiLogListInput *ApiLogLi
 
 	return apiLogResult, nil
 }
+
+// All iterates over every api log matching the input, fetching pages as needed.
+func (alr *ApiLogRequest) All(ctx context.Context, apiLogListInput *ApiLogListInput, opts ...IteratorOption) iter.Seq2[ApiLog, error] {
+	input := ApiLogListInput{}
+	if apiLogListInput != nil {
+		input = *apiLogListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]ApiLog, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := alr.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.ApiLogs, result.Meta, nil
+	}, opts)
+}
@@ -0,0 +1,2037 @@
+// Code generated by mockgen from api.go. DO NOT EDIT.
+
+package subrow
+
+import (
+	"context"
+	"crypto/rsa"
+	"iter"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// MockActivityLogAPI is a mock ActivityLogAPI whose methods call the matching Func field.
+type MockActivityLogAPI struct {
+	mockCalls
+
+	GetFunc     func(ctx context.Context, activityId string) (*ActivityLog, *Error)
+	GetListFunc func(ctx context.Context, activityLogListInput *ActivityLogListInput) (*ActivityLogResult, *Error)
+	AllFunc     func(ctx context.Context, activityLogListInput *ActivityLogListInput, opts ...IteratorOption) iter.Seq2[ActivityLog, error]
+}
+
+var _ ActivityLogAPI = (*MockActivityLogAPI)(nil)
+
+func (m *MockActivityLogAPI) Get(ctx context.Context, activityId string) (*ActivityLog, *Error) {
+	m.record("Get", ctx, activityId)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("ActivityLogAPI.Get")
+	}
+
+	return m.GetFunc(ctx, activityId)
+}
+
+func (m *MockActivityLogAPI) GetList(ctx context.Context, activityLogListInput *ActivityLogListInput) (*ActivityLogResult, *Error) {
+	m.record("GetList", ctx, activityLogListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("ActivityLogAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, activityLogListInput)
+}
+
+func (m *MockActivityLogAPI) All(ctx context.Context, activityLogListInput *ActivityLogListInput, opts ...IteratorOption) iter.Seq2[ActivityLog, error] {
+	m.record("All", ctx, activityLogListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(ActivityLog, error) bool) {
+			var zero ActivityLog
+			yield(zero, mockNotImplemented("ActivityLogAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, activityLogListInput, opts...)
+}
+
+// MockAddOnAPI is a mock AddOnAPI whose methods call the matching Func field.
+type MockAddOnAPI struct {
+	mockCalls
+
+	GetFunc     func(ctx context.Context, addOnCode string) (*AddOn, *Error)
+	GetListFunc func(ctx context.Context, addOnListInput *AddOnListInput) (*AddOnResult, *Error)
+	AllFunc     func(ctx context.Context, addOnListInput *AddOnListInput, opts ...IteratorOption) iter.Seq2[AddOn, error]
+	CreateFunc  func(ctx context.Context, addOnInput *AddOnInput) (*AddOn, *Error)
+	UpdateFunc  func(ctx context.Context, addOnInput *AddOnInput) (*AddOn, *Error)
+	DeleteFunc  func(ctx context.Context, addOnCode string) (*AddOn, *Error)
+}
+
+var _ AddOnAPI = (*MockAddOnAPI)(nil)
+
+func (m *MockAddOnAPI) Get(ctx context.Context, addOnCode string) (*AddOn, *Error) {
+	m.record("Get", ctx, addOnCode)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("AddOnAPI.Get")
+	}
+
+	return m.GetFunc(ctx, addOnCode)
+}
+
+func (m *MockAddOnAPI) GetList(ctx context.Context, addOnListInput *AddOnListInput) (*AddOnResult, *Error) {
+	m.record("GetList", ctx, addOnListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("AddOnAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, addOnListInput)
+}
+
+func (m *MockAddOnAPI) All(ctx context.Context, addOnListInput *AddOnListInput, opts ...IteratorOption) iter.Seq2[AddOn, error] {
+	m.record("All", ctx, addOnListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(AddOn, error) bool) {
+			var zero AddOn
+			yield(zero, mockNotImplemented("AddOnAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, addOnListInput, opts...)
+}
+
+func (m *MockAddOnAPI) Create(ctx context.Context, addOnInput *AddOnInput) (*AddOn, *Error) {
+	m.record("Create", ctx, addOnInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("AddOnAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, addOnInput)
+}
+
+func (m *MockAddOnAPI) Update(ctx context.Context, addOnInput *AddOnInput) (*AddOn, *Error) {
+	m.record("Update", ctx, addOnInput)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("AddOnAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, addOnInput)
+}
+
+func (m *MockAddOnAPI) Delete(ctx context.Context, addOnCode string) (*AddOn, *Error) {
+	m.record("Delete", ctx, addOnCode)
+	if m.DeleteFunc == nil {
+		return nil, mockNotImplemented("AddOnAPI.Delete")
+	}
+
+	return m.DeleteFunc(ctx, addOnCode)
+}
+
+// MockAlertAPI is a mock AlertAPI whose methods call the matching Func field.
+type MockAlertAPI struct {
+	mockCalls
+
+	GetFunc     func(ctx context.Context, subscriptionExternalID, alertCode string) (*Alert, *Error)
+	GetListFunc func(ctx context.Context, subscriptionExternalID string) (*AlertResult, *Error)
+	CreateFunc  func(ctx context.Context, subscriptionExternalID string, alertInput *AlertInput) (*Alert, *Error)
+	UpdateFunc  func(ctx context.Context, subscriptionExternalID, alertCode string, alertInput *AlertInput) (*Alert, *Error)
+	DeleteFunc  func(ctx context.Context, subscriptionExternalID, alertCode string) (*Alert, *Error)
+}
+
+var _ AlertAPI = (*MockAlertAPI)(nil)
+
+func (m *MockAlertAPI) Get(ctx context.Context, subscriptionExternalID, alertCode string) (*Alert, *Error) {
+	m.record("Get", ctx, subscriptionExternalID, alertCode)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("AlertAPI.Get")
+	}
+
+	return m.GetFunc(ctx, subscriptionExternalID, alertCode)
+}
+
+func (m *MockAlertAPI) GetList(ctx context.Context, subscriptionExternalID string) (*AlertResult, *Error) {
+	m.record("GetList", ctx, subscriptionExternalID)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("AlertAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, subscriptionExternalID)
+}
+
+func (m *MockAlertAPI) Create(ctx context.Context, subscriptionExternalID string, alertInput *AlertInput) (*Alert, *Error) {
+	m.record("Create", ctx, subscriptionExternalID, alertInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("AlertAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, subscriptionExternalID, alertInput)
+}
+
+func (m *MockAlertAPI) Update(ctx context.Context, subscriptionExternalID, alertCode string, alertInput *AlertInput) (*Alert, *Error) {
+	m.record("Update", ctx, subscriptionExternalID, alertCode, alertInput)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("AlertAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, subscriptionExternalID, alertCode, alertInput)
+}
+
+func (m *MockAlertAPI) Delete(ctx context.Context, subscriptionExternalID, alertCode string) (*Alert, *Error) {
+	m.record("Delete", ctx, subscriptionExternalID, alertCode)
+	if m.DeleteFunc == nil {
+		return nil, mockNotImplemented("AlertAPI.Delete")
+	}
+
+	return m.DeleteFunc(ctx, subscriptionExternalID, alertCode)
+}
+
+// MockApiLogAPI is a mock ApiLogAPI whose methods call the matching Func field.
+type MockApiLogAPI struct {
+	mockCalls
+
+	GetFunc     func(ctx context.Context, requestId string) (*ApiLog, *Error)
+	GetListFunc func(ctx context.Context, apiLogListInput *ApiLogListInput) (*ApiLogResult, *Error)
+	AllFunc     func(ctx context.Context, apiLogListInput *ApiLogListInput, opts ...IteratorOption) iter.Seq2[ApiLog, error]
+}
+
+var _ ApiLogAPI = (*MockApiLogAPI)(nil)
+
+func (m *MockApiLogAPI) Get(ctx context.Context, requestId string) (*ApiLog, *Error) {
+	m.record("Get", ctx, requestId)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("ApiLogAPI.Get")
+	}
+
+	return m.GetFunc(ctx, requestId)
+}
+
+func (m *MockApiLogAPI) GetList(ctx context.Context, apiLogListInput *ApiLogListInput) (*ApiLogResult, *Error) {
+	m.record("GetList", ctx, apiLogListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("ApiLogAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, apiLogListInput)
+}
+
+func (m *MockApiLogAPI) All(ctx context.Context, apiLogListInput *ApiLogListInput, opts ...IteratorOption) iter.Seq2[ApiLog, error] {
+	m.record("All", ctx, apiLogListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(ApiLog, error) bool) {
+			var zero ApiLog
+			yield(zero, mockNotImplemented("ApiLogAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, apiLogListInput, opts...)
+}
+
+// MockAppliedCouponAPI is a mock AppliedCouponAPI whose methods call the matching Func field.
+type MockAppliedCouponAPI struct {
+	mockCalls
+
+	GetListFunc             func(ctx context.Context, appliedCouponListInput *AppliedCouponListInput) (*AppliedCouponResult, *Error)
+	AllFunc                 func(ctx context.Context, appliedCouponListInput *AppliedCouponListInput, opts ...IteratorOption) iter.Seq2[AppliedCoupon, error]
+	AppliedCouponDeleteFunc func(ctx context.Context, externalCustomerID string, appliedCouponID string) (*AppliedCoupon, *Error)
+}
+
+var _ AppliedCouponAPI = (*MockAppliedCouponAPI)(nil)
+
+func (m *MockAppliedCouponAPI) GetList(ctx context.Context, appliedCouponListInput *AppliedCouponListInput) (*AppliedCouponResult, *Error) {
+	m.record("GetList", ctx, appliedCouponListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("AppliedCouponAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, appliedCouponListInput)
+}
+
+func (m *MockAppliedCouponAPI) All(ctx context.Context, appliedCouponListInput *AppliedCouponListInput, opts ...IteratorOption) iter.Seq2[AppliedCoupon, error] {
+	m.record("All", ctx, appliedCouponListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(AppliedCoupon, error) bool) {
+			var zero AppliedCoupon
+			yield(zero, mockNotImplemented("AppliedCouponAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, appliedCouponListInput, opts...)
+}
+
+func (m *MockAppliedCouponAPI) AppliedCouponDelete(ctx context.Context, externalCustomerID string, appliedCouponID string) (*AppliedCoupon, *Error) {
+	m.record("AppliedCouponDelete", ctx, externalCustomerID, appliedCouponID)
+	if m.AppliedCouponDeleteFunc == nil {
+		return nil, mockNotImplemented("AppliedCouponAPI.AppliedCouponDelete")
+	}
+
+	return m.AppliedCouponDeleteFunc(ctx, externalCustomerID, appliedCouponID)
+}
+
+// MockBillableMetricAPI is a mock BillableMetricAPI whose methods call the matching Func field.
+type MockBillableMetricAPI struct {
+	mockCalls
+
+	GetFunc                func(ctx context.Context, billableMetricCode string) (*BillableMetric, *Error)
+	GetListFunc            func(ctx context.Context, billableMetricListInput *BillableMetricListInput) (*BillableMetricResult, *Error)
+	AllFunc                func(ctx context.Context, billableMetricListInput *BillableMetricListInput, opts ...IteratorOption) iter.Seq2[BillableMetric, error]
+	CreateFunc             func(ctx context.Context, billableMetricInput *BillableMetricInput) (*BillableMetric, *Error)
+	UpdateFunc             func(ctx context.Context, billableMetricInput *BillableMetricInput) (*BillableMetric, *Error)
+	DeleteFunc             func(ctx context.Context, billableMetricCode string) (*BillableMetric, *Error)
+	EvaluateExpressionFunc func(ctx context.Context, evaluateExpressingInput *BillableMetricEvaluateExpressionInput) (*BillableMetricEvaluateExpressionResultValue, *Error)
+}
+
+var _ BillableMetricAPI = (*MockBillableMetricAPI)(nil)
+
+func (m *MockBillableMetricAPI) Get(ctx context.Context, billableMetricCode string) (*BillableMetric, *Error) {
+	m.record("Get", ctx, billableMetricCode)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("BillableMetricAPI.Get")
+	}
+
+	return m.GetFunc(ctx, billableMetricCode)
+}
+
+func (m *MockBillableMetricAPI) GetList(ctx context.Context, billableMetricListInput *BillableMetricListInput) (*BillableMetricResult, *Error) {
+	m.record("GetList", ctx, billableMetricListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("BillableMetricAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, billableMetricListInput)
+}
+
+func (m *MockBillableMetricAPI) All(ctx context.Context, billableMetricListInput *BillableMetricListInput, opts ...IteratorOption) iter.Seq2[BillableMetric, error] {
+	m.record("All", ctx, billableMetricListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(BillableMetric, error) bool) {
+			var zero BillableMetric
+			yield(zero, mockNotImplemented("BillableMetricAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, billableMetricListInput, opts...)
+}
+
+func (m *MockBillableMetricAPI) Create(ctx context.Context, billableMetricInput *BillableMetricInput) (*BillableMetric, *Error) {
+	m.record("Create", ctx, billableMetricInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("BillableMetricAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, billableMetricInput)
+}
+
+func (m *MockBillableMetricAPI) Update(ctx context.Context, billableMetricInput *BillableMetricInput) (*BillableMetric, *Error) {
+	m.record("Update", ctx, billableMetricInput)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("BillableMetricAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, billableMetricInput)
+}
+
+func (m *MockBillableMetricAPI) Delete(ctx context.Context, billableMetricCode string) (*BillableMetric, *Error) {
+	m.record("Delete", ctx, billableMetricCode)
+	if m.DeleteFunc == nil {
+		return nil, mockNotImplemented("BillableMetricAPI.Delete")
+	}
+
+	return m.DeleteFunc(ctx, billableMetricCode)
+}
+
+func (m *MockBillableMetricAPI) EvaluateExpression(ctx context.Context, evaluateExpressingInput *BillableMetricEvaluateExpressionInput) (*BillableMetricEvaluateExpressionResultValue, *Error) {
+	m.record("EvaluateExpression", ctx, evaluateExpressingInput)
+	if m.EvaluateExpressionFunc == nil {
+		return nil, mockNotImplemented("BillableMetricAPI.EvaluateExpression")
+	}
+
+	return m.EvaluateExpressionFunc(ctx, evaluateExpressingInput)
+}
+
+// MockBillingEntityAPI is a mock BillingEntityAPI whose methods call the matching Func field.
+type MockBillingEntityAPI struct {
+	mockCalls
+
+	CreateFunc  func(ctx context.Context, billingEntityInput *BillingEntityCreateInput) (*BillingEntity, *Error)
+	GetFunc     func(ctx context.Context, billingEntityCode string) (*BillingEntity, *Error)
+	GetListFunc func(ctx context.Context) (*BillingEntityResult, *Error)
+	UpdateFunc  func(ctx context.Context, billingEntityCode string, billingEntityInput *BillingEntityUpdateInput) (*BillingEntity, *Error)
+}
+
+var _ BillingEntityAPI = (*MockBillingEntityAPI)(nil)
+
+func (m *MockBillingEntityAPI) Create(ctx context.Context, billingEntityInput *BillingEntityCreateInput) (*BillingEntity, *Error) {
+	m.record("Create", ctx, billingEntityInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("BillingEntityAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, billingEntityInput)
+}
+
+func (m *MockBillingEntityAPI) Get(ctx context.Context, billingEntityCode string) (*BillingEntity, *Error) {
+	m.record("Get", ctx, billingEntityCode)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("BillingEntityAPI.Get")
+	}
+
+	return m.GetFunc(ctx, billingEntityCode)
+}
+
+func (m *MockBillingEntityAPI) GetList(ctx context.Context) (*BillingEntityResult, *Error) {
+	m.record("GetList", ctx)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("BillingEntityAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx)
+}
+
+func (m *MockBillingEntityAPI) Update(ctx context.Context, billingEntityCode string, billingEntityInput *BillingEntityUpdateInput) (*BillingEntity, *Error) {
+	m.record("Update", ctx, billingEntityCode, billingEntityInput)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("BillingEntityAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, billingEntityCode, billingEntityInput)
+}
+
+// MockCouponAPI is a mock CouponAPI whose methods call the matching Func field.
+type MockCouponAPI struct {
+	mockCalls
+
+	GetFunc             func(ctx context.Context, couponCode string) (*Coupon, *Error)
+	GetListFunc         func(ctx context.Context, couponListInput *CouponListInput) (*CouponResult, *Error)
+	AllFunc             func(ctx context.Context, couponListInput *CouponListInput, opts ...IteratorOption) iter.Seq2[Coupon, error]
+	CreateFunc          func(ctx context.Context, couponInput *CouponInput) (*Coupon, *Error)
+	UpdateFunc          func(ctx context.Context, couponInput *CouponInput) (*Coupon, *Error)
+	DeleteFunc          func(ctx context.Context, couponCode string) (*Coupon, *Error)
+	ApplyToCustomerFunc func(ctx context.Context, applyCouponInput *ApplyCouponInput) (*AppliedCoupon, *Error)
+}
+
+var _ CouponAPI = (*MockCouponAPI)(nil)
+
+func (m *MockCouponAPI) Get(ctx context.Context, couponCode string) (*Coupon, *Error) {
+	m.record("Get", ctx, couponCode)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("CouponAPI.Get")
+	}
+
+	return m.GetFunc(ctx, couponCode)
+}
+
+func (m *MockCouponAPI) GetList(ctx context.Context, couponListInput *CouponListInput) (*CouponResult, *Error) {
+	m.record("GetList", ctx, couponListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("CouponAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, couponListInput)
+}
+
+func (m *MockCouponAPI) All(ctx context.Context, couponListInput *CouponListInput, opts ...IteratorOption) iter.Seq2[Coupon, error] {
+	m.record("All", ctx, couponListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(Coupon, error) bool) {
+			var zero Coupon
+			yield(zero, mockNotImplemented("CouponAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, couponListInput, opts...)
+}
+
+func (m *MockCouponAPI) Create(ctx context.Context, couponInput *CouponInput) (*Coupon, *Error) {
+	m.record("Create", ctx, couponInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("CouponAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, couponInput)
+}
+
+func (m *MockCouponAPI) Update(ctx context.Context, couponInput *CouponInput) (*Coupon, *Error) {
+	m.record("Update", ctx, couponInput)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("CouponAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, couponInput)
+}
+
+func (m *MockCouponAPI) Delete(ctx context.Context, couponCode string) (*Coupon, *Error) {
+	m.record("Delete", ctx, couponCode)
+	if m.DeleteFunc == nil {
+		return nil, mockNotImplemented("CouponAPI.Delete")
+	}
+
+	return m.DeleteFunc(ctx, couponCode)
+}
+
+func (m *MockCouponAPI) ApplyToCustomer(ctx context.Context, applyCouponInput *ApplyCouponInput) (*AppliedCoupon, *Error) {
+	m.record("ApplyToCustomer", ctx, applyCouponInput)
+	if m.ApplyToCustomerFunc == nil {
+		return nil, mockNotImplemented("CouponAPI.ApplyToCustomer")
+	}
+
+	return m.ApplyToCustomerFunc(ctx, applyCouponInput)
+}
+
+// MockCreditNoteAPI is a mock CreditNoteAPI whose methods call the matching Func field.
+type MockCreditNoteAPI struct {
+	mockCalls
+
+	GetFunc      func(ctx context.Context, creditNoteID uuid.UUID) (*CreditNote, *Error)
+	DownloadFunc func(ctx context.Context, creditNoteID string) (*CreditNote, *Error)
+	GetListFunc  func(ctx context.Context, creditNoteListInput *CreditListInput) (*CreditNoteResult, *Error)
+	AllFunc      func(ctx context.Context, creditNoteListInput *CreditListInput, opts ...IteratorOption) iter.Seq2[CreditNote, error]
+	CreateFunc   func(ctx context.Context, creditNoteInput *CreditNoteInput) (*CreditNote, *Error)
+	UpdateFunc   func(ctx context.Context, creditNoteUpdateInput *CreditNoteUpdateInput) (*CreditNote, *Error)
+	VoidFunc     func(ctx context.Context, creditNoteID string) (*CreditNote, *Error)
+	EstimateFunc func(ctx context.Context, creditNoteEstimateInput *CreditNoteEstimateInput) (*CreditNoteEstimated, *Error)
+}
+
+var _ CreditNoteAPI = (*MockCreditNoteAPI)(nil)
+
+func (m *MockCreditNoteAPI) Get(ctx context.Context, creditNoteID uuid.UUID) (*CreditNote, *Error) {
+	m.record("Get", ctx, creditNoteID)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("CreditNoteAPI.Get")
+	}
+
+	return m.GetFunc(ctx, creditNoteID)
+}
+
+func (m *MockCreditNoteAPI) Download(ctx context.Context, creditNoteID string) (*CreditNote, *Error) {
+	m.record("Download", ctx, creditNoteID)
+	if m.DownloadFunc == nil {
+		return nil, mockNotImplemented("CreditNoteAPI.Download")
+	}
+
+	return m.DownloadFunc(ctx, creditNoteID)
+}
+
+func (m *MockCreditNoteAPI) GetList(ctx context.Context, creditNoteListInput *CreditListInput) (*CreditNoteResult, *Error) {
+	m.record("GetList", ctx, creditNoteListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("CreditNoteAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, creditNoteListInput)
+}
+
+func (m *MockCreditNoteAPI) All(ctx context.Context, creditNoteListInput *CreditListInput, opts ...IteratorOption) iter.Seq2[CreditNote, error] {
+	m.record("All", ctx, creditNoteListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(CreditNote, error) bool) {
+			var zero CreditNote
+			yield(zero, mockNotImplemented("CreditNoteAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, creditNoteListInput, opts...)
+}
+
+func (m *MockCreditNoteAPI) Create(ctx context.Context, creditNoteInput *CreditNoteInput) (*CreditNote, *Error) {
+	m.record("Create", ctx, creditNoteInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("CreditNoteAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, creditNoteInput)
+}
+
+func (m *MockCreditNoteAPI) Update(ctx context.Context, creditNoteUpdateInput *CreditNoteUpdateInput) (*CreditNote, *Error) {
+	m.record("Update", ctx, creditNoteUpdateInput)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("CreditNoteAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, creditNoteUpdateInput)
+}
+
+func (m *MockCreditNoteAPI) Void(ctx context.Context, creditNoteID string) (*CreditNote, *Error) {
+	m.record("Void", ctx, creditNoteID)
+	if m.VoidFunc == nil {
+		return nil, mockNotImplemented("CreditNoteAPI.Void")
+	}
+
+	return m.VoidFunc(ctx, creditNoteID)
+}
+
+func (m *MockCreditNoteAPI) Estimate(ctx context.Context, creditNoteEstimateInput *CreditNoteEstimateInput) (*CreditNoteEstimated, *Error) {
+	m.record("Estimate", ctx, creditNoteEstimateInput)
+	if m.EstimateFunc == nil {
+		return nil, mockNotImplemented("CreditNoteAPI.Estimate")
+	}
+
+	return m.EstimateFunc(ctx, creditNoteEstimateInput)
+}
+
+// MockCustomerAPI is a mock CustomerAPI whose methods call the matching Func field.
+type MockCustomerAPI struct {
+	mockCalls
+
+	CreateFunc       func(ctx context.Context, customerInput *CustomerInput) (*Customer, *Error)
+	UpdateFunc       func(ctx context.Context, customerInput *CustomerInput) (*Customer, *Error)
+	CurrentUsageFunc func(ctx context.Context, externalCustomerID string, customerUsageInput *CustomerUsageInput) (*CustomerUsage, *Error)
+	PastUsageFunc    func(ctx context.Context, externalCustomerID string, customerPastUsageInput *CustomerPastUsageInput) (*CustomerPastUsageResult, *Error)
+	PortalUrlFunc    func(ctx context.Context, externalCustomerID string) (*CustomerPortalUrl, *Error)
+	CheckoutUrlFunc  func(ctx context.Context, externalCustomerID string) (*CustomerCheckoutUrl, *Error)
+	DeleteFunc       func(ctx context.Context, externalCustomerID string) (*Customer, *Error)
+	GetFunc          func(ctx context.Context, externalCustomerID string) (*Customer, *Error)
+	GetListFunc      func(ctx context.Context, customerListInput *CustomerListInput) (*CustomerResult, *Error)
+	AllFunc          func(ctx context.Context, customerListInput *CustomerListInput, opts ...IteratorOption) iter.Seq2[Customer, error]
+}
+
+var _ CustomerAPI = (*MockCustomerAPI)(nil)
+
+func (m *MockCustomerAPI) Create(ctx context.Context, customerInput *CustomerInput) (*Customer, *Error) {
+	m.record("Create", ctx, customerInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("CustomerAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, customerInput)
+}
+
+func (m *MockCustomerAPI) Update(ctx context.Context, customerInput *CustomerInput) (*Customer, *Error) {
+	m.record("Update", ctx, customerInput)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("CustomerAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, customerInput)
+}
+
+func (m *MockCustomerAPI) CurrentUsage(ctx context.Context, externalCustomerID string, customerUsageInput *CustomerUsageInput) (*CustomerUsage, *Error) {
+	m.record("CurrentUsage", ctx, externalCustomerID, customerUsageInput)
+	if m.CurrentUsageFunc == nil {
+		return nil, mockNotImplemented("CustomerAPI.CurrentUsage")
+	}
+
+	return m.CurrentUsageFunc(ctx, externalCustomerID, customerUsageInput)
+}
+
+func (m *MockCustomerAPI) PastUsage(ctx context.Context, externalCustomerID string, customerPastUsageInput *CustomerPastUsageInput) (*CustomerPastUsageResult, *Error) {
+	m.record("PastUsage", ctx, externalCustomerID, customerPastUsageInput)
+	if m.PastUsageFunc == nil {
+		return nil, mockNotImplemented("CustomerAPI.PastUsage")
+	}
+
+	return m.PastUsageFunc(ctx, externalCustomerID, customerPastUsageInput)
+}
+
+func (m *MockCustomerAPI) PortalUrl(ctx context.Context, externalCustomerID string) (*CustomerPortalUrl, *Error) {
+	m.record("PortalUrl", ctx, externalCustomerID)
+	if m.PortalUrlFunc == nil {
+		return nil, mockNotImplemented("CustomerAPI.PortalUrl")
+	}
+
+	return m.PortalUrlFunc(ctx, externalCustomerID)
+}
+
+func (m *MockCustomerAPI) CheckoutUrl(ctx context.Context, externalCustomerID string) (*CustomerCheckoutUrl, *Error) {
+	m.record("CheckoutUrl", ctx, externalCustomerID)
+	if m.CheckoutUrlFunc == nil {
+		return nil, mockNotImplemented("CustomerAPI.CheckoutUrl")
+	}
+
+	return m.CheckoutUrlFunc(ctx, externalCustomerID)
+}
+
+func (m *MockCustomerAPI) Delete(ctx context.Context, externalCustomerID string) (*Customer, *Error) {
+	m.record("Delete", ctx, externalCustomerID)
+	if m.DeleteFunc == nil {
+		return nil, mockNotImplemented("CustomerAPI.Delete")
+	}
+
+	return m.DeleteFunc(ctx, externalCustomerID)
+}
+
+func (m *MockCustomerAPI) Get(ctx context.Context, externalCustomerID string) (*Customer, *Error) {
+	m.record("Get", ctx, externalCustomerID)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("CustomerAPI.Get")
+	}
+
+	return m.GetFunc(ctx, externalCustomerID)
+}
+
+func (m *MockCustomerAPI) GetList(ctx context.Context, customerListInput *CustomerListInput) (*CustomerResult, *Error) {
+	m.record("GetList", ctx, customerListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("CustomerAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, customerListInput)
+}
+
+func (m *MockCustomerAPI) All(ctx context.Context, customerListInput *CustomerListInput, opts ...IteratorOption) iter.Seq2[Customer, error] {
+	m.record("All", ctx, customerListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(Customer, error) bool) {
+			var zero Customer
+			yield(zero, mockNotImplemented("CustomerAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, customerListInput, opts...)
+}
+
+// MockEventAPI is a mock EventAPI whose methods call the matching Func field.
+type MockEventAPI struct {
+	mockCalls
+
+	CreateFunc       func(ctx context.Context, eventInput *EventInput) (*Event, *Error)
+	EstimateFeesFunc func(ctx context.Context, estimateInput EventEstimateFeesInput) (*FeeResult, *Error)
+	GetFunc          func(ctx context.Context, eventID string) (*Event, *Error)
+	BatchFunc        func(ctx context.Context, batchInput *[]EventInput) (*[]Event, *Error)
+}
+
+var _ EventAPI = (*MockEventAPI)(nil)
+
+func (m *MockEventAPI) Create(ctx context.Context, eventInput *EventInput) (*Event, *Error) {
+	m.record("Create", ctx, eventInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("EventAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, eventInput)
+}
+
+func (m *MockEventAPI) EstimateFees(ctx context.Context, estimateInput EventEstimateFeesInput) (*FeeResult, *Error) {
+	m.record("EstimateFees", ctx, estimateInput)
+	if m.EstimateFeesFunc == nil {
+		return nil, mockNotImplemented("EventAPI.EstimateFees")
+	}
+
+	return m.EstimateFeesFunc(ctx, estimateInput)
+}
+
+func (m *MockEventAPI) Get(ctx context.Context, eventID string) (*Event, *Error) {
+	m.record("Get", ctx, eventID)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("EventAPI.Get")
+	}
+
+	return m.GetFunc(ctx, eventID)
+}
+
+func (m *MockEventAPI) Batch(ctx context.Context, batchInput *[]EventInput) (*[]Event, *Error) {
+	m.record("Batch", ctx, batchInput)
+	if m.BatchFunc == nil {
+		return nil, mockNotImplemented("EventAPI.Batch")
+	}
+
+	return m.BatchFunc(ctx, batchInput)
+}
+
+// MockFeeAPI is a mock FeeAPI whose methods call the matching Func field.
+type MockFeeAPI struct {
+	mockCalls
+
+	GetFunc     func(ctx context.Context, feeID string) (*Fee, *Error)
+	UpdateFunc  func(ctx context.Context, feeInput *FeeUpdateInput) (*Fee, *Error)
+	GetListFunc func(ctx context.Context, feeListInput *FeeListInput) (*FeeResult, *Error)
+	AllFunc     func(ctx context.Context, feeListInput *FeeListInput, opts ...IteratorOption) iter.Seq2[Fee, error]
+	DeleteFunc  func(ctx context.Context, feeID string) (*Fee, *Error)
+}
+
+var _ FeeAPI = (*MockFeeAPI)(nil)
+
+func (m *MockFeeAPI) Get(ctx context.Context, feeID string) (*Fee, *Error) {
+	m.record("Get", ctx, feeID)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("FeeAPI.Get")
+	}
+
+	return m.GetFunc(ctx, feeID)
+}
+
+func (m *MockFeeAPI) Update(ctx context.Context, feeInput *FeeUpdateInput) (*Fee, *Error) {
+	m.record("Update", ctx, feeInput)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("FeeAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, feeInput)
+}
+
+func (m *MockFeeAPI) GetList(ctx context.Context, feeListInput *FeeListInput) (*FeeResult, *Error) {
+	m.record("GetList", ctx, feeListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("FeeAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, feeListInput)
+}
+
+func (m *MockFeeAPI) All(ctx context.Context, feeListInput *FeeListInput, opts ...IteratorOption) iter.Seq2[Fee, error] {
+	m.record("All", ctx, feeListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(Fee, error) bool) {
+			var zero Fee
+			yield(zero, mockNotImplemented("FeeAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, feeListInput, opts...)
+}
+
+func (m *MockFeeAPI) Delete(ctx context.Context, feeID string) (*Fee, *Error) {
+	m.record("Delete", ctx, feeID)
+	if m.DeleteFunc == nil {
+		return nil, mockNotImplemented("FeeAPI.Delete")
+	}
+
+	return m.DeleteFunc(ctx, feeID)
+}
+
+// MockGrossRevenueAPI is a mock GrossRevenueAPI whose methods call the matching Func field.
+type MockGrossRevenueAPI struct {
+	mockCalls
+
+	GetListFunc func(ctx context.Context, grossRevenueListInput *GrossRevenueListInput) (*GrossRevenueResult, *Error)
+}
+
+var _ GrossRevenueAPI = (*MockGrossRevenueAPI)(nil)
+
+func (m *MockGrossRevenueAPI) GetList(ctx context.Context, grossRevenueListInput *GrossRevenueListInput) (*GrossRevenueResult, *Error) {
+	m.record("GetList", ctx, grossRevenueListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("GrossRevenueAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, grossRevenueListInput)
+}
+
+// MockInvoiceAPI is a mock InvoiceAPI whose methods call the matching Func field.
+type MockInvoiceAPI struct {
+	mockCalls
+
+	GetFunc          func(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	GetListFunc      func(ctx context.Context, invoiceListInput *InvoiceListInput) (*InvoiceResult, *Error)
+	AllFunc          func(ctx context.Context, invoiceListInput *InvoiceListInput, opts ...IteratorOption) iter.Seq2[Invoice, error]
+	CreateFunc       func(ctx context.Context, oneOffInput *InvoiceOneOffInput) (*Invoice, *Error)
+	PreviewFunc      func(ctx context.Context, invoicePreviewInput *InvoicePreviewInput) (*Invoice, *Error)
+	UpdateFunc       func(ctx context.Context, invoiceInput *InvoiceInput) (*Invoice, *Error)
+	DownloadFunc     func(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	RefreshFunc      func(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	RetryFunc        func(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	FinalizeFunc     func(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	VoidFunc         func(ctx context.Context, invoiceID string, opts *VoidInvoiceOptions) (*Invoice, *Error)
+	LoseDisputeFunc  func(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	RetryPaymentFunc func(ctx context.Context, invoiceID string) (*Invoice, *Error)
+	PaymentUrlFunc   func(ctx context.Context, invoiceID string) (*InvoicePaymentUrl, *Error)
+}
+
+var _ InvoiceAPI = (*MockInvoiceAPI)(nil)
+
+func (m *MockInvoiceAPI) Get(ctx context.Context, invoiceID string) (*Invoice, *Error) {
+	m.record("Get", ctx, invoiceID)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("InvoiceAPI.Get")
+	}
+
+	return m.GetFunc(ctx, invoiceID)
+}
+
+func (m *MockInvoiceAPI) GetList(ctx context.Context, invoiceListInput *InvoiceListInput) (*InvoiceResult, *Error) {
+	m.record("GetList", ctx, invoiceListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("InvoiceAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, invoiceListInput)
+}
+
+func (m *MockInvoiceAPI) All(ctx context.Context, invoiceListInput *InvoiceListInput, opts ...IteratorOption) iter.Seq2[Invoice, error] {
+	m.record("All", ctx, invoiceListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(Invoice, error) bool) {
+			var zero Invoice
+			yield(zero, mockNotImplemented("InvoiceAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, invoiceListInput, opts...)
+}
+
+func (m *MockInvoiceAPI) Create(ctx context.Context, oneOffInput *InvoiceOneOffInput) (*Invoice, *Error) {
+	m.record("Create", ctx, oneOffInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("InvoiceAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, oneOffInput)
+}
+
+func (m *MockInvoiceAPI) Preview(ctx context.Context, invoicePreviewInput *InvoicePreviewInput) (*Invoice, *Error) {
+	m.record("Preview", ctx, invoicePreviewInput)
+	if m.PreviewFunc == nil {
+		return nil, mockNotImplemented("InvoiceAPI.Preview")
+	}
+
+	return m.PreviewFunc(ctx, invoicePreviewInput)
+}
+
+func (m *MockInvoiceAPI) Update(ctx context.Context, invoiceInput *InvoiceInput) (*Invoice, *Error) {
+	m.record("Update", ctx, invoiceInput)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("InvoiceAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, invoiceInput)
+}
+
+func (m *MockInvoiceAPI) Download(ctx context.Context, invoiceID string) (*Invoice, *Error) {
+	m.record("Download", ctx, invoiceID)
+	if m.DownloadFunc == nil {
+		return nil, mockNotImplemented("InvoiceAPI.Download")
+	}
+
+	return m.DownloadFunc(ctx, invoiceID)
+}
+
+func (m *MockInvoiceAPI) Refresh(ctx context.Context, invoiceID string) (*Invoice, *Error) {
+	m.record("Refresh", ctx, invoiceID)
+	if m.RefreshFunc == nil {
+		return nil, mockNotImplemented("InvoiceAPI.Refresh")
+	}
+
+	return m.RefreshFunc(ctx, invoiceID)
+}
+
+func (m *MockInvoiceAPI) Retry(ctx context.Context, invoiceID string) (*Invoice, *Error) {
+	m.record("Retry", ctx, invoiceID)
+	if m.RetryFunc == nil {
+		return nil, mockNotImplemented("InvoiceAPI.Retry")
+	}
+
+	return m.RetryFunc(ctx, invoiceID)
+}
+
+func (m *MockInvoiceAPI) Finalize(ctx context.Context, invoiceID string) (*Invoice, *Error) {
+	m.record("Finalize", ctx, invoiceID)
+	if m.FinalizeFunc == nil {
+		return nil, mockNotImplemented("InvoiceAPI.Finalize")
+	}
+
+	return m.FinalizeFunc(ctx, invoiceID)
+}
+
+func (m *MockInvoiceAPI) Void(ctx context.Context, invoiceID string, opts *VoidInvoiceOptions) (*Invoice, *Error) {
+	m.record("Void", ctx, invoiceID, opts)
+	if m.VoidFunc == nil {
+		return nil, mockNotImplemented("InvoiceAPI.Void")
+	}
+
+	return m.VoidFunc(ctx, invoiceID, opts)
+}
+
+func (m *MockInvoiceAPI) LoseDispute(ctx context.Context, invoiceID string) (*Invoice, *Error) {
+	m.record("LoseDispute", ctx, invoiceID)
+	if m.LoseDisputeFunc == nil {
+		return nil, mockNotImplemented("InvoiceAPI.LoseDispute")
+	}
+
+	return m.LoseDisputeFunc(ctx, invoiceID)
+}
+
+func (m *MockInvoiceAPI) RetryPayment(ctx context.Context, invoiceID string) (*Invoice, *Error) {
+	m.record("RetryPayment", ctx, invoiceID)
+	if m.RetryPaymentFunc == nil {
+		return nil, mockNotImplemented("InvoiceAPI.RetryPayment")
+	}
+
+	return m.RetryPaymentFunc(ctx, invoiceID)
+}
+
+func (m *MockInvoiceAPI) PaymentUrl(ctx context.Context, invoiceID string) (*InvoicePaymentUrl, *Error) {
+	m.record("PaymentUrl", ctx, invoiceID)
+	if m.PaymentUrlFunc == nil {
+		return nil, mockNotImplemented("InvoiceAPI.PaymentUrl")
+	}
+
+	return m.PaymentUrlFunc(ctx, invoiceID)
+}
+
+// MockInvoiceCollectionAPI is a mock InvoiceCollectionAPI whose methods call the matching Func field.
+type MockInvoiceCollectionAPI struct {
+	mockCalls
+
+	GetListFunc func(ctx context.Context, invoiceCollectionListInput *InvoiceCollectionListInput) (*InvoiceCollectionResult, *Error)
+}
+
+var _ InvoiceCollectionAPI = (*MockInvoiceCollectionAPI)(nil)
+
+func (m *MockInvoiceCollectionAPI) GetList(ctx context.Context, invoiceCollectionListInput *InvoiceCollectionListInput) (*InvoiceCollectionResult, *Error) {
+	m.record("GetList", ctx, invoiceCollectionListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("InvoiceCollectionAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, invoiceCollectionListInput)
+}
+
+// MockInvoicedUsageAPI is a mock InvoicedUsageAPI whose methods call the matching Func field.
+type MockInvoicedUsageAPI struct {
+	mockCalls
+
+	GetListFunc func(ctx context.Context, invoicedUsageListInput *InvoicedUsageListInput) (*InvoicedUsageResult, *Error)
+}
+
+var _ InvoicedUsageAPI = (*MockInvoicedUsageAPI)(nil)
+
+func (m *MockInvoicedUsageAPI) GetList(ctx context.Context, invoicedUsageListInput *InvoicedUsageListInput) (*InvoicedUsageResult, *Error) {
+	m.record("GetList", ctx, invoicedUsageListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("InvoicedUsageAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, invoicedUsageListInput)
+}
+
+// MockMrrAPI is a mock MrrAPI whose methods call the matching Func field.
+type MockMrrAPI struct {
+	mockCalls
+
+	GetListFunc func(ctx context.Context, mrrListInput *MrrListInput) (*MrrResult, *Error)
+}
+
+var _ MrrAPI = (*MockMrrAPI)(nil)
+
+func (m *MockMrrAPI) GetList(ctx context.Context, mrrListInput *MrrListInput) (*MrrResult, *Error) {
+	m.record("GetList", ctx, mrrListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("MrrAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, mrrListInput)
+}
+
+// MockOrganizationAPI is a mock OrganizationAPI whose methods call the matching Func field.
+type MockOrganizationAPI struct {
+	mockCalls
+
+	UpdateFunc func(ctx context.Context, organizationInput *OrganizationInput) (*Organization, *Error)
+}
+
+var _ OrganizationAPI = (*MockOrganizationAPI)(nil)
+
+func (m *MockOrganizationAPI) Update(ctx context.Context, organizationInput *OrganizationInput) (*Organization, *Error) {
+	m.record("Update", ctx, organizationInput)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("OrganizationAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, organizationInput)
+}
+
+// MockOverdueBalanceAPI is a mock OverdueBalanceAPI whose methods call the matching Func field.
+type MockOverdueBalanceAPI struct {
+	mockCalls
+
+	GetListFunc func(ctx context.Context, overdueBalanceListInput *OverdueBalanceListInput) (*OverdueBalanceResult, *Error)
+}
+
+var _ OverdueBalanceAPI = (*MockOverdueBalanceAPI)(nil)
+
+func (m *MockOverdueBalanceAPI) GetList(ctx context.Context, overdueBalanceListInput *OverdueBalanceListInput) (*OverdueBalanceResult, *Error) {
+	m.record("GetList", ctx, overdueBalanceListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("OverdueBalanceAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, overdueBalanceListInput)
+}
+
+// MockPaymentAPI is a mock PaymentAPI whose methods call the matching Func field.
+type MockPaymentAPI struct {
+	mockCalls
+
+	GetFunc     func(ctx context.Context, paymentID string) (*Payment, *Error)
+	GetListFunc func(ctx context.Context, paymentListInput *PaymentListInput) (*PaymentResult, *Error)
+	AllFunc     func(ctx context.Context, paymentListInput *PaymentListInput, opts ...IteratorOption) iter.Seq2[Payment, error]
+	CreateFunc  func(ctx context.Context, paymentInput *PaymentInput) (*Payment, *Error)
+}
+
+var _ PaymentAPI = (*MockPaymentAPI)(nil)
+
+func (m *MockPaymentAPI) Get(ctx context.Context, paymentID string) (*Payment, *Error) {
+	m.record("Get", ctx, paymentID)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("PaymentAPI.Get")
+	}
+
+	return m.GetFunc(ctx, paymentID)
+}
+
+func (m *MockPaymentAPI) GetList(ctx context.Context, paymentListInput *PaymentListInput) (*PaymentResult, *Error) {
+	m.record("GetList", ctx, paymentListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("PaymentAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, paymentListInput)
+}
+
+func (m *MockPaymentAPI) All(ctx context.Context, paymentListInput *PaymentListInput, opts ...IteratorOption) iter.Seq2[Payment, error] {
+	m.record("All", ctx, paymentListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(Payment, error) bool) {
+			var zero Payment
+			yield(zero, mockNotImplemented("PaymentAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, paymentListInput, opts...)
+}
+
+func (m *MockPaymentAPI) Create(ctx context.Context, paymentInput *PaymentInput) (*Payment, *Error) {
+	m.record("Create", ctx, paymentInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("PaymentAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, paymentInput)
+}
+
+// MockPaymentReceiptAPI is a mock PaymentReceiptAPI whose methods call the matching Func field.
+type MockPaymentReceiptAPI struct {
+	mockCalls
+
+	GetFunc     func(ctx context.Context, id string) (*PaymentReceipt, *Error)
+	GetListFunc func(ctx context.Context, paymentReceiptListInput *PaymentReceiptListInput) (*PaymentReceiptResult, *Error)
+	AllFunc     func(ctx context.Context, paymentReceiptListInput *PaymentReceiptListInput, opts ...IteratorOption) iter.Seq2[PaymentReceipt, error]
+}
+
+var _ PaymentReceiptAPI = (*MockPaymentReceiptAPI)(nil)
+
+func (m *MockPaymentReceiptAPI) Get(ctx context.Context, id string) (*PaymentReceipt, *Error) {
+	m.record("Get", ctx, id)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("PaymentReceiptAPI.Get")
+	}
+
+	return m.GetFunc(ctx, id)
+}
+
+func (m *MockPaymentReceiptAPI) GetList(ctx context.Context, paymentReceiptListInput *PaymentReceiptListInput) (*PaymentReceiptResult, *Error) {
+	m.record("GetList", ctx, paymentReceiptListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("PaymentReceiptAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, paymentReceiptListInput)
+}
+
+func (m *MockPaymentReceiptAPI) All(ctx context.Context, paymentReceiptListInput *PaymentReceiptListInput, opts ...IteratorOption) iter.Seq2[PaymentReceipt, error] {
+	m.record("All", ctx, paymentReceiptListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(PaymentReceipt, error) bool) {
+			var zero PaymentReceipt
+			yield(zero, mockNotImplemented("PaymentReceiptAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, paymentReceiptListInput, opts...)
+}
+
+// MockPaymentRequestAPI is a mock PaymentRequestAPI whose methods call the matching Func field.
+type MockPaymentRequestAPI struct {
+	mockCalls
+
+	GetListFunc func(ctx context.Context, paymentRequestListInput *PaymentRequestListInput) (*PaymentRequestResult, *Error)
+	AllFunc     func(ctx context.Context, paymentRequestListInput *PaymentRequestListInput, opts ...IteratorOption) iter.Seq2[PaymentRequest, error]
+	CreateFunc  func(ctx context.Context, paymentRequestInput *PaymentRequestInput) (*PaymentRequest, *Error)
+}
+
+var _ PaymentRequestAPI = (*MockPaymentRequestAPI)(nil)
+
+func (m *MockPaymentRequestAPI) GetList(ctx context.Context, paymentRequestListInput *PaymentRequestListInput) (*PaymentRequestResult, *Error) {
+	m.record("GetList", ctx, paymentRequestListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("PaymentRequestAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, paymentRequestListInput)
+}
+
+func (m *MockPaymentRequestAPI) All(ctx context.Context, paymentRequestListInput *PaymentRequestListInput, opts ...IteratorOption) iter.Seq2[PaymentRequest, error] {
+	m.record("All", ctx, paymentRequestListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(PaymentRequest, error) bool) {
+			var zero PaymentRequest
+			yield(zero, mockNotImplemented("PaymentRequestAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, paymentRequestListInput, opts...)
+}
+
+func (m *MockPaymentRequestAPI) Create(ctx context.Context, paymentRequestInput *PaymentRequestInput) (*PaymentRequest, *Error) {
+	m.record("Create", ctx, paymentRequestInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("PaymentRequestAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, paymentRequestInput)
+}
+
+// MockPlanAPI is a mock PlanAPI whose methods call the matching Func field.
+type MockPlanAPI struct {
+	mockCalls
+
+	GetFunc     func(ctx context.Context, planCode string) (*Plan, *Error)
+	GetListFunc func(ctx context.Context, planListInput *PlanListInput) (*PlanResult, *Error)
+	AllFunc     func(ctx context.Context, planListInput *PlanListInput, opts ...IteratorOption) iter.Seq2[Plan, error]
+	CreateFunc  func(ctx context.Context, planInput *PlanInput) (*Plan, *Error)
+	UpdateFunc  func(ctx context.Context, planInput *PlanInput) (*Plan, *Error)
+	DeleteFunc  func(ctx context.Context, planCode string) (*Plan, *Error)
+}
+
+var _ PlanAPI = (*MockPlanAPI)(nil)
+
+func (m *MockPlanAPI) Get(ctx context.Context, planCode string) (*Plan, *Error) {
+	m.record("Get", ctx, planCode)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("PlanAPI.Get")
+	}
+
+	return m.GetFunc(ctx, planCode)
+}
+
+func (m *MockPlanAPI) GetList(ctx context.Context, planListInput *PlanListInput) (*PlanResult, *Error) {
+	m.record("GetList", ctx, planListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("PlanAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, planListInput)
+}
+
+func (m *MockPlanAPI) All(ctx context.Context, planListInput *PlanListInput, opts ...IteratorOption) iter.Seq2[Plan, error] {
+	m.record("All", ctx, planListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(Plan, error) bool) {
+			var zero Plan
+			yield(zero, mockNotImplemented("PlanAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, planListInput, opts...)
+}
+
+func (m *MockPlanAPI) Create(ctx context.Context, planInput *PlanInput) (*Plan, *Error) {
+	m.record("Create", ctx, planInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("PlanAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, planInput)
+}
+
+func (m *MockPlanAPI) Update(ctx context.Context, planInput *PlanInput) (*Plan, *Error) {
+	m.record("Update", ctx, planInput)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("PlanAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, planInput)
+}
+
+func (m *MockPlanAPI) Delete(ctx context.Context, planCode string) (*Plan, *Error) {
+	m.record("Delete", ctx, planCode)
+	if m.DeleteFunc == nil {
+		return nil, mockNotImplemented("PlanAPI.Delete")
+	}
+
+	return m.DeleteFunc(ctx, planCode)
+}
+
+// MockSubscriptionAPI is a mock SubscriptionAPI whose methods call the matching Func field.
+type MockSubscriptionAPI struct {
+	mockCalls
+
+	GetLifetimeUsageFunc    func(ctx context.Context, externalSubscriptionID string) (*LifetimeUsage, *Error)
+	UpdateLifetimeUsageFunc func(ctx context.Context, lifetimeUsageInput *LifetimeUsageInput) (*LifetimeUsage, *Error)
+	CreateFunc              func(ctx context.Context, subscriptionInput *SubscriptionInput) (*Subscription, *Error)
+	TerminateFunc           func(ctx context.Context, subscriptionTerminateInput SubscriptionTerminateInput) (*Subscription, *Error)
+	GetFunc                 func(ctx context.Context, subscriptionExternalId string) (*Subscription, *Error)
+	GetListFunc             func(ctx context.Context, subscriptionListInput SubscriptionListInput) (*SubscriptionResult, *Error)
+	AllFunc                 func(ctx context.Context, subscriptionListInput SubscriptionListInput, opts ...IteratorOption) iter.Seq2[Subscription, error]
+	UpdateFunc              func(ctx context.Context, subscriptionInput *SubscriptionInput) (*Subscription, *Error)
+}
+
+var _ SubscriptionAPI = (*MockSubscriptionAPI)(nil)
+
+func (m *MockSubscriptionAPI) GetLifetimeUsage(ctx context.Context, externalSubscriptionID string) (*LifetimeUsage, *Error) {
+	m.record("GetLifetimeUsage", ctx, externalSubscriptionID)
+	if m.GetLifetimeUsageFunc == nil {
+		return nil, mockNotImplemented("SubscriptionAPI.GetLifetimeUsage")
+	}
+
+	return m.GetLifetimeUsageFunc(ctx, externalSubscriptionID)
+}
+
+func (m *MockSubscriptionAPI) UpdateLifetimeUsage(ctx context.Context, lifetimeUsageInput *LifetimeUsageInput) (*LifetimeUsage, *Error) {
+	m.record("UpdateLifetimeUsage", ctx, lifetimeUsageInput)
+	if m.UpdateLifetimeUsageFunc == nil {
+		return nil, mockNotImplemented("SubscriptionAPI.UpdateLifetimeUsage")
+	}
+
+	return m.UpdateLifetimeUsageFunc(ctx, lifetimeUsageInput)
+}
+
+func (m *MockSubscriptionAPI) Create(ctx context.Context, subscriptionInput *SubscriptionInput) (*Subscription, *Error) {
+	m.record("Create", ctx, subscriptionInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("SubscriptionAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, subscriptionInput)
+}
+
+func (m *MockSubscriptionAPI) Terminate(ctx context.Context, subscriptionTerminateInput SubscriptionTerminateInput) (*Subscription, *Error) {
+	m.record("Terminate", ctx, subscriptionTerminateInput)
+	if m.TerminateFunc == nil {
+		return nil, mockNotImplemented("SubscriptionAPI.Terminate")
+	}
+
+	return m.TerminateFunc(ctx, subscriptionTerminateInput)
+}
+
+func (m *MockSubscriptionAPI) Get(ctx context.Context, subscriptionExternalId string) (*Subscription, *Error) {
+	m.record("Get", ctx, subscriptionExternalId)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("SubscriptionAPI.Get")
+	}
+
+	return m.GetFunc(ctx, subscriptionExternalId)
+}
+
+func (m *MockSubscriptionAPI) GetList(ctx context.Context, subscriptionListInput SubscriptionListInput) (*SubscriptionResult, *Error) {
+	m.record("GetList", ctx, subscriptionListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("SubscriptionAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, subscriptionListInput)
+}
+
+func (m *MockSubscriptionAPI) All(ctx context.Context, subscriptionListInput SubscriptionListInput, opts ...IteratorOption) iter.Seq2[Subscription, error] {
+	m.record("All", ctx, subscriptionListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(Subscription, error) bool) {
+			var zero Subscription
+			yield(zero, mockNotImplemented("SubscriptionAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, subscriptionListInput, opts...)
+}
+
+func (m *MockSubscriptionAPI) Update(ctx context.Context, subscriptionInput *SubscriptionInput) (*Subscription, *Error) {
+	m.record("Update", ctx, subscriptionInput)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("SubscriptionAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, subscriptionInput)
+}
+
+// MockTaxAPI is a mock TaxAPI whose methods call the matching Func field.
+type MockTaxAPI struct {
+	mockCalls
+
+	GetFunc     func(ctx context.Context, taxCode string) (*Tax, *Error)
+	GetListFunc func(ctx context.Context, taxListInput *TaxListInput) (*TaxResult, *Error)
+	AllFunc     func(ctx context.Context, taxListInput *TaxListInput, opts ...IteratorOption) iter.Seq2[Tax, error]
+	CreateFunc  func(ctx context.Context, taxInput *TaxInput) (*Tax, *Error)
+	UpdateFunc  func(ctx context.Context, taxInput *TaxInput) (*Tax, *Error)
+	DeleteFunc  func(ctx context.Context, taxCode string) (*Tax, *Error)
+}
+
+var _ TaxAPI = (*MockTaxAPI)(nil)
+
+func (m *MockTaxAPI) Get(ctx context.Context, taxCode string) (*Tax, *Error) {
+	m.record("Get", ctx, taxCode)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("TaxAPI.Get")
+	}
+
+	return m.GetFunc(ctx, taxCode)
+}
+
+func (m *MockTaxAPI) GetList(ctx context.Context, taxListInput *TaxListInput) (*TaxResult, *Error) {
+	m.record("GetList", ctx, taxListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("TaxAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, taxListInput)
+}
+
+func (m *MockTaxAPI) All(ctx context.Context, taxListInput *TaxListInput, opts ...IteratorOption) iter.Seq2[Tax, error] {
+	m.record("All", ctx, taxListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(Tax, error) bool) {
+			var zero Tax
+			yield(zero, mockNotImplemented("TaxAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, taxListInput, opts...)
+}
+
+func (m *MockTaxAPI) Create(ctx context.Context, taxInput *TaxInput) (*Tax, *Error) {
+	m.record("Create", ctx, taxInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("TaxAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, taxInput)
+}
+
+func (m *MockTaxAPI) Update(ctx context.Context, taxInput *TaxInput) (*Tax, *Error) {
+	m.record("Update", ctx, taxInput)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("TaxAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, taxInput)
+}
+
+func (m *MockTaxAPI) Delete(ctx context.Context, taxCode string) (*Tax, *Error) {
+	m.record("Delete", ctx, taxCode)
+	if m.DeleteFunc == nil {
+		return nil, mockNotImplemented("TaxAPI.Delete")
+	}
+
+	return m.DeleteFunc(ctx, taxCode)
+}
+
+// MockWalletAPI is a mock WalletAPI whose methods call the matching Func field.
+type MockWalletAPI struct {
+	mockCalls
+
+	GetFunc     func(ctx context.Context, walletID string) (*Wallet, *Error)
+	GetListFunc func(ctx context.Context, walletListInput *WalletListInput) (*WalletResult, *Error)
+	AllFunc     func(ctx context.Context, walletListInput *WalletListInput, opts ...IteratorOption) iter.Seq2[Wallet, error]
+	CreateFunc  func(ctx context.Context, walletInput *WalletInput) (*Wallet, *Error)
+	UpdateFunc  func(ctx context.Context, walletInput *WalletInput, walletID string) (*Wallet, *Error)
+	DeleteFunc  func(ctx context.Context, walletID string) (*Wallet, *Error)
+}
+
+var _ WalletAPI = (*MockWalletAPI)(nil)
+
+func (m *MockWalletAPI) Get(ctx context.Context, walletID string) (*Wallet, *Error) {
+	m.record("Get", ctx, walletID)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("WalletAPI.Get")
+	}
+
+	return m.GetFunc(ctx, walletID)
+}
+
+func (m *MockWalletAPI) GetList(ctx context.Context, walletListInput *WalletListInput) (*WalletResult, *Error) {
+	m.record("GetList", ctx, walletListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("WalletAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, walletListInput)
+}
+
+func (m *MockWalletAPI) All(ctx context.Context, walletListInput *WalletListInput, opts ...IteratorOption) iter.Seq2[Wallet, error] {
+	m.record("All", ctx, walletListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(Wallet, error) bool) {
+			var zero Wallet
+			yield(zero, mockNotImplemented("WalletAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, walletListInput, opts...)
+}
+
+func (m *MockWalletAPI) Create(ctx context.Context, walletInput *WalletInput) (*Wallet, *Error) {
+	m.record("Create", ctx, walletInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("WalletAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, walletInput)
+}
+
+func (m *MockWalletAPI) Update(ctx context.Context, walletInput *WalletInput, walletID string) (*Wallet, *Error) {
+	m.record("Update", ctx, walletInput, walletID)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("WalletAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, walletInput, walletID)
+}
+
+func (m *MockWalletAPI) Delete(ctx context.Context, walletID string) (*Wallet, *Error) {
+	m.record("Delete", ctx, walletID)
+	if m.DeleteFunc == nil {
+		return nil, mockNotImplemented("WalletAPI.Delete")
+	}
+
+	return m.DeleteFunc(ctx, walletID)
+}
+
+// MockWalletTransactionAPI is a mock WalletTransactionAPI whose methods call the matching Func field.
+type MockWalletTransactionAPI struct {
+	mockCalls
+
+	CreateFunc     func(ctx context.Context, walletTransactionInput *WalletTransactionInput) (*WalletTransactionResult, *Error)
+	GetListFunc    func(ctx context.Context, walletTransactionListInput *WalletTransactionListInput) (*WalletTransactionResult, *Error)
+	AllFunc        func(ctx context.Context, walletTransactionListInput *WalletTransactionListInput, opts ...IteratorOption) iter.Seq2[WalletTransaction, error]
+	PaymentUrlFunc func(ctx context.Context, walletTransactionID string) (*WalletTransactionPaymentUrl, *Error)
+}
+
+var _ WalletTransactionAPI = (*MockWalletTransactionAPI)(nil)
+
+func (m *MockWalletTransactionAPI) Create(ctx context.Context, walletTransactionInput *WalletTransactionInput) (*WalletTransactionResult, *Error) {
+	m.record("Create", ctx, walletTransactionInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("WalletTransactionAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, walletTransactionInput)
+}
+
+func (m *MockWalletTransactionAPI) GetList(ctx context.Context, walletTransactionListInput *WalletTransactionListInput) (*WalletTransactionResult, *Error) {
+	m.record("GetList", ctx, walletTransactionListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("WalletTransactionAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, walletTransactionListInput)
+}
+
+func (m *MockWalletTransactionAPI) All(ctx context.Context, walletTransactionListInput *WalletTransactionListInput, opts ...IteratorOption) iter.Seq2[WalletTransaction, error] {
+	m.record("All", ctx, walletTransactionListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(WalletTransaction, error) bool) {
+			var zero WalletTransaction
+			yield(zero, mockNotImplemented("WalletTransactionAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, walletTransactionListInput, opts...)
+}
+
+func (m *MockWalletTransactionAPI) PaymentUrl(ctx context.Context, walletTransactionID string) (*WalletTransactionPaymentUrl, *Error) {
+	m.record("PaymentUrl", ctx, walletTransactionID)
+	if m.PaymentUrlFunc == nil {
+		return nil, mockNotImplemented("WalletTransactionAPI.PaymentUrl")
+	}
+
+	return m.PaymentUrlFunc(ctx, walletTransactionID)
+}
+
+// MockWebhookAPI is a mock WebhookAPI whose methods call the matching Func field.
+type MockWebhookAPI struct {
+	mockCalls
+
+	GetPublicKeyFunc         func(ctx context.Context) (*rsa.PublicKey, *Error)
+	ValidateSignatureFunc    func(ctx context.Context, signature string) (bool, *Error)
+	ValidateBodyFunc         func(ctx context.Context, signature string, body string) (bool, *Error)
+	HandlerFunc              func(dispatcher *WebhookDispatcher, opts *WebhookHandlerOptions) http.Handler
+	ValidateHmacBodyFunc     func(ctx context.Context, signature string, body string) (bool, *Error)
+	ValidateBodyWithAlgoFunc func(ctx context.Context, signatureAlgo SignatureAlgo, signature string, body string) (bool, *Error)
+}
+
+var _ WebhookAPI = (*MockWebhookAPI)(nil)
+
+func (m *MockWebhookAPI) GetPublicKey(ctx context.Context) (*rsa.PublicKey, *Error) {
+	m.record("GetPublicKey", ctx)
+	if m.GetPublicKeyFunc == nil {
+		return nil, mockNotImplemented("WebhookAPI.GetPublicKey")
+	}
+
+	return m.GetPublicKeyFunc(ctx)
+}
+
+func (m *MockWebhookAPI) ValidateSignature(ctx context.Context, signature string) (bool, *Error) {
+	m.record("ValidateSignature", ctx, signature)
+	if m.ValidateSignatureFunc == nil {
+		return false, mockNotImplemented("WebhookAPI.ValidateSignature")
+	}
+
+	return m.ValidateSignatureFunc(ctx, signature)
+}
+
+func (m *MockWebhookAPI) ValidateBody(ctx context.Context, signature string, body string) (bool, *Error) {
+	m.record("ValidateBody", ctx, signature, body)
+	if m.ValidateBodyFunc == nil {
+		return false, mockNotImplemented("WebhookAPI.ValidateBody")
+	}
+
+	return m.ValidateBodyFunc(ctx, signature, body)
+}
+
+func (m *MockWebhookAPI) Handler(dispatcher *WebhookDispatcher, opts *WebhookHandlerOptions) http.Handler {
+	m.record("Handler", dispatcher, opts)
+	if m.HandlerFunc == nil {
+		return *new(http.Handler)
+	}
+
+	return m.HandlerFunc(dispatcher, opts)
+}
+
+func (m *MockWebhookAPI) ValidateHmacBody(ctx context.Context, signature string, body string) (bool, *Error) {
+	m.record("ValidateHmacBody", ctx, signature, body)
+	if m.ValidateHmacBodyFunc == nil {
+		return false, mockNotImplemented("WebhookAPI.ValidateHmacBody")
+	}
+
+	return m.ValidateHmacBodyFunc(ctx, signature, body)
+}
+
+func (m *MockWebhookAPI) ValidateBodyWithAlgo(ctx context.Context, signatureAlgo SignatureAlgo, signature string, body string) (bool, *Error) {
+	m.record("ValidateBodyWithAlgo", ctx, signatureAlgo, signature, body)
+	if m.ValidateBodyWithAlgoFunc == nil {
+		return false, mockNotImplemented("WebhookAPI.ValidateBodyWithAlgo")
+	}
+
+	return m.ValidateBodyWithAlgoFunc(ctx, signatureAlgo, signature, body)
+}
+
+// MockWebhookEndpointAPI is a mock WebhookEndpointAPI whose methods call the matching Func field.
+type MockWebhookEndpointAPI struct {
+	mockCalls
+
+	GetFunc     func(ctx context.Context, webhookEndpointID string) (*WebhookEndpoint, *Error)
+	GetListFunc func(ctx context.Context, webhookEndpointListInput *WebhookEndpointListInput) (*WebhookEndpointResult, *Error)
+	AllFunc     func(ctx context.Context, webhookEndpointListInput *WebhookEndpointListInput, opts ...IteratorOption) iter.Seq2[WebhookEndpoint, error]
+	CreateFunc  func(ctx context.Context, webhookEndpointInput *WebhookEndpointInput) (*WebhookEndpoint, *Error)
+	UpdateFunc  func(ctx context.Context, webhookEndpointInput *WebhookEndpointInput, webhookEndpointID string) (*WebhookEndpoint, *Error)
+	DeleteFunc  func(ctx context.Context, webhookEndpointID string) (*WebhookEndpoint, *Error)
+}
+
+var _ WebhookEndpointAPI = (*MockWebhookEndpointAPI)(nil)
+
+func (m *MockWebhookEndpointAPI) Get(ctx context.Context, webhookEndpointID string) (*WebhookEndpoint, *Error) {
+	m.record("Get", ctx, webhookEndpointID)
+	if m.GetFunc == nil {
+		return nil, mockNotImplemented("WebhookEndpointAPI.Get")
+	}
+
+	return m.GetFunc(ctx, webhookEndpointID)
+}
+
+func (m *MockWebhookEndpointAPI) GetList(ctx context.Context, webhookEndpointListInput *WebhookEndpointListInput) (*WebhookEndpointResult, *Error) {
+	m.record("GetList", ctx, webhookEndpointListInput)
+	if m.GetListFunc == nil {
+		return nil, mockNotImplemented("WebhookEndpointAPI.GetList")
+	}
+
+	return m.GetListFunc(ctx, webhookEndpointListInput)
+}
+
+func (m *MockWebhookEndpointAPI) All(ctx context.Context, webhookEndpointListInput *WebhookEndpointListInput, opts ...IteratorOption) iter.Seq2[WebhookEndpoint, error] {
+	m.record("All", ctx, webhookEndpointListInput, opts)
+	if m.AllFunc == nil {
+		return func(yield func(WebhookEndpoint, error) bool) {
+			var zero WebhookEndpoint
+			yield(zero, mockNotImplemented("WebhookEndpointAPI.All"))
+		}
+	}
+
+	return m.AllFunc(ctx, webhookEndpointListInput, opts...)
+}
+
+func (m *MockWebhookEndpointAPI) Create(ctx context.Context, webhookEndpointInput *WebhookEndpointInput) (*WebhookEndpoint, *Error) {
+	m.record("Create", ctx, webhookEndpointInput)
+	if m.CreateFunc == nil {
+		return nil, mockNotImplemented("WebhookEndpointAPI.Create")
+	}
+
+	return m.CreateFunc(ctx, webhookEndpointInput)
+}
+
+func (m *MockWebhookEndpointAPI) Update(ctx context.Context, webhookEndpointInput *WebhookEndpointInput, webhookEndpointID string) (*WebhookEndpoint, *Error) {
+	m.record("Update", ctx, webhookEndpointInput, webhookEndpointID)
+	if m.UpdateFunc == nil {
+		return nil, mockNotImplemented("WebhookEndpointAPI.Update")
+	}
+
+	return m.UpdateFunc(ctx, webhookEndpointInput, webhookEndpointID)
+}
+
+func (m *MockWebhookEndpointAPI) Delete(ctx context.Context, webhookEndpointID string) (*WebhookEndpoint, *Error) {
+	m.record("Delete", ctx, webhookEndpointID)
+	if m.DeleteFunc == nil {
+		return nil, mockNotImplemented("WebhookEndpointAPI.Delete")
+	}
+
+	return m.DeleteFunc(ctx, webhookEndpointID)
+}
+
+// MockAPI is a mock API whose methods call the matching Func field.
+type MockAPI struct {
+	mockCalls
+
+	ActivityLogMock       *MockActivityLogAPI
+	AddOnMock             *MockAddOnAPI
+	AlertMock             *MockAlertAPI
+	ApiLogMock            *MockApiLogAPI
+	AppliedCouponMock     *MockAppliedCouponAPI
+	BillableMetricMock    *MockBillableMetricAPI
+	BillingEntityMock     *MockBillingEntityAPI
+	CouponMock            *MockCouponAPI
+	CreditNoteMock        *MockCreditNoteAPI
+	CustomerMock          *MockCustomerAPI
+	EventMock             *MockEventAPI
+	FeeMock               *MockFeeAPI
+	GrossRevenueMock      *MockGrossRevenueAPI
+	InvoiceMock           *MockInvoiceAPI
+	InvoiceCollectionMock *MockInvoiceCollectionAPI
+	InvoicedUsageMock     *MockInvoicedUsageAPI
+	MrrMock               *MockMrrAPI
+	OrganizationMock      *MockOrganizationAPI
+	OverdueBalanceMock    *MockOverdueBalanceAPI
+	PaymentMock           *MockPaymentAPI
+	PaymentReceiptMock    *MockPaymentReceiptAPI
+	PaymentRequestMock    *MockPaymentRequestAPI
+	PlanMock              *MockPlanAPI
+	SubscriptionMock      *MockSubscriptionAPI
+	TaxMock               *MockTaxAPI
+	WalletMock            *MockWalletAPI
+	WalletTransactionMock *MockWalletTransactionAPI
+	WebhookMock           *MockWebhookAPI
+	WebhookEndpointMock   *MockWebhookEndpointAPI
+	HealthCheckFunc       func(ctx context.Context) (*HealthCheckResponse, error)
+}
+
+var _ API = (*MockAPI)(nil)
+
+func (m *MockAPI) ActivityLog() ActivityLogAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ActivityLogMock == nil {
+		m.ActivityLogMock = &MockActivityLogAPI{}
+	}
+
+	return m.ActivityLogMock
+}
+
+func (m *MockAPI) AddOn() AddOnAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.AddOnMock == nil {
+		m.AddOnMock = &MockAddOnAPI{}
+	}
+
+	return m.AddOnMock
+}
+
+func (m *MockAPI) Alert() AlertAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.AlertMock == nil {
+		m.AlertMock = &MockAlertAPI{}
+	}
+
+	return m.AlertMock
+}
+
+func (m *MockAPI) ApiLog() ApiLogAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ApiLogMock == nil {
+		m.ApiLogMock = &MockApiLogAPI{}
+	}
+
+	return m.ApiLogMock
+}
+
+func (m *MockAPI) AppliedCoupon() AppliedCouponAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.AppliedCouponMock == nil {
+		m.AppliedCouponMock = &MockAppliedCouponAPI{}
+	}
+
+	return m.AppliedCouponMock
+}
+
+func (m *MockAPI) BillableMetric() BillableMetricAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.BillableMetricMock == nil {
+		m.BillableMetricMock = &MockBillableMetricAPI{}
+	}
+
+	return m.BillableMetricMock
+}
+
+func (m *MockAPI) BillingEntity() BillingEntityAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.BillingEntityMock == nil {
+		m.BillingEntityMock = &MockBillingEntityAPI{}
+	}
+
+	return m.BillingEntityMock
+}
+
+func (m *MockAPI) Coupon() CouponAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.CouponMock == nil {
+		m.CouponMock = &MockCouponAPI{}
+	}
+
+	return m.CouponMock
+}
+
+func (m *MockAPI) CreditNote() CreditNoteAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.CreditNoteMock == nil {
+		m.CreditNoteMock = &MockCreditNoteAPI{}
+	}
+
+	return m.CreditNoteMock
+}
+
+func (m *MockAPI) Customer() CustomerAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.CustomerMock == nil {
+		m.CustomerMock = &MockCustomerAPI{}
+	}
+
+	return m.CustomerMock
+}
+
+func (m *MockAPI) Event() EventAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.EventMock == nil {
+		m.EventMock = &MockEventAPI{}
+	}
+
+	return m.EventMock
+}
+
+func (m *MockAPI) Fee() FeeAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.FeeMock == nil {
+		m.FeeMock = &MockFeeAPI{}
+	}
+
+	return m.FeeMock
+}
+
+func (m *MockAPI) GrossRevenue() GrossRevenueAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.GrossRevenueMock == nil {
+		m.GrossRevenueMock = &MockGrossRevenueAPI{}
+	}
+
+	return m.GrossRevenueMock
+}
+
+func (m *MockAPI) Invoice() InvoiceAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.InvoiceMock == nil {
+		m.InvoiceMock = &MockInvoiceAPI{}
+	}
+
+	return m.InvoiceMock
+}
+
+func (m *MockAPI) InvoiceCollection() InvoiceCollectionAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.InvoiceCollectionMock == nil {
+		m.InvoiceCollectionMock = &MockInvoiceCollectionAPI{}
+	}
+
+	return m.InvoiceCollectionMock
+}
+
+func (m *MockAPI) InvoicedUsage() InvoicedUsageAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.InvoicedUsageMock == nil {
+		m.InvoicedUsageMock = &MockInvoicedUsageAPI{}
+	}
+
+	return m.InvoicedUsageMock
+}
+
+func (m *MockAPI) Mrr() MrrAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.MrrMock == nil {
+		m.MrrMock = &MockMrrAPI{}
+	}
+
+	return m.MrrMock
+}
+
+func (m *MockAPI) Organization() OrganizationAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.OrganizationMock == nil {
+		m.OrganizationMock = &MockOrganizationAPI{}
+	}
+
+	return m.OrganizationMock
+}
+
+func (m *MockAPI) OverdueBalance() OverdueBalanceAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.OverdueBalanceMock == nil {
+		m.OverdueBalanceMock = &MockOverdueBalanceAPI{}
+	}
+
+	return m.OverdueBalanceMock
+}
+
+func (m *MockAPI) Payment() PaymentAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.PaymentMock == nil {
+		m.PaymentMock = &MockPaymentAPI{}
+	}
+
+	return m.PaymentMock
+}
+
+func (m *MockAPI) PaymentReceipt() PaymentReceiptAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.PaymentReceiptMock == nil {
+		m.PaymentReceiptMock = &MockPaymentReceiptAPI{}
+	}
+
+	return m.PaymentReceiptMock
+}
+
+func (m *MockAPI) PaymentRequest() PaymentRequestAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.PaymentRequestMock == nil {
+		m.PaymentRequestMock = &MockPaymentRequestAPI{}
+	}
+
+	return m.PaymentRequestMock
+}
+
+func (m *MockAPI) Plan() PlanAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.PlanMock == nil {
+		m.PlanMock = &MockPlanAPI{}
+	}
+
+	return m.PlanMock
+}
+
+func (m *MockAPI) Subscription() SubscriptionAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.SubscriptionMock == nil {
+		m.SubscriptionMock = &MockSubscriptionAPI{}
+	}
+
+	return m.SubscriptionMock
+}
+
+func (m *MockAPI) Tax() TaxAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.TaxMock == nil {
+		m.TaxMock = &MockTaxAPI{}
+	}
+
+	return m.TaxMock
+}
+
+func (m *MockAPI) Wallet() WalletAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.WalletMock == nil {
+		m.WalletMock = &MockWalletAPI{}
+	}
+
+	return m.WalletMock
+}
+
+func (m *MockAPI) WalletTransaction() WalletTransactionAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.WalletTransactionMock == nil {
+		m.WalletTransactionMock = &MockWalletTransactionAPI{}
+	}
+
+	return m.WalletTransactionMock
+}
+
+func (m *MockAPI) Webhook() WebhookAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.WebhookMock == nil {
+		m.WebhookMock = &MockWebhookAPI{}
+	}
+
+	return m.WebhookMock
+}
+
+func (m *MockAPI) WebhookEndpoint() WebhookEndpointAPI {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.WebhookEndpointMock == nil {
+		m.WebhookEndpointMock = &MockWebhookEndpointAPI{}
+	}
+
+	return m.WebhookEndpointMock
+}
+
+func (m *MockAPI) HealthCheck(ctx context.Context) (*HealthCheckResponse, error) {
+	m.record("HealthCheck", ctx)
+	if m.HealthCheckFunc == nil {
+		return nil, mockNotImplemented("API.HealthCheck")
+	}
+
+	return m.HealthCheckFunc(ctx)
+}
@@ -0,0 +1,88 @@
+package subrow
+
+import (
+	"context"
+	"iter"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// createCustomer stands for application code depending on the API interface.
+func createCustomer(ctx context.Context, api API, externalID string) (*Customer, *Error) {
+	return api.Customer().Create(ctx, &CustomerInput{ExternalID: externalID})
+}
+
+func TestClientAPI(t *testing.T) {
+	c := qt.New(t)
+
+	client := New()
+	api := client.API()
+
+	customers, ok := api.Customer().(*CustomerRequest)
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(customers.client, qt.Equals, client)
+
+	payments, ok := api.Payment().(*ManualPaymentRequest)
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(payments.client, qt.Equals, client)
+}
+
+func TestMockAPI(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("When a response is programmed", func(t *testing.T) {
+		c := qt.New(t)
+
+		mock := &MockAPI{}
+		mock.Customer().(*MockCustomerAPI).CreateFunc = func(ctx context.Context, customerInput *CustomerInput) (*Customer, *Error) {
+			return &Customer{ExternalID: customerInput.ExternalID}, nil
+		}
+
+		customer, err := createCustomer(ctx, mock, "cus_1")
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(customer.ExternalID, qt.Equals, "cus_1")
+
+		calls := mock.CustomerMock.CallsTo("Create")
+		c.Assert(calls, qt.HasLen, 1)
+		c.Assert(calls[0].Args[1], qt.DeepEquals, &CustomerInput{ExternalID: "cus_1"})
+
+		mock.CustomerMock.Reset()
+		c.Assert(mock.CustomerMock.Calls(), qt.HasLen, 0)
+	})
+
+	t.Run("When a response is not programmed", func(t *testing.T) {
+		c := qt.New(t)
+
+		mock := &MockAPI{}
+
+		customer, err := createCustomer(ctx, mock, "cus_1")
+		c.Assert(customer, qt.IsNil)
+		c.Assert(err.Err, qt.ErrorMatches, "subrow: CustomerAPI.Create is not implemented by the mock")
+		c.Assert(mock.CustomerMock.Calls(), qt.HasLen, 1)
+
+		for _, err := range mock.Invoice().All(ctx, &InvoiceListInput{}) {
+			c.Assert(err, qt.ErrorMatches, `.*InvoiceAPI.All is not implemented by the mock.*`)
+		}
+	})
+
+	t.Run("When an iterator is programmed", func(t *testing.T) {
+		c := qt.New(t)
+
+		mock := &MockInvoiceAPI{
+			AllFunc: func(ctx context.Context, invoiceListInput *InvoiceListInput, opts ...IteratorOption) iter.Seq2[Invoice, error] {
+				return func(yield func(Invoice, error) bool) {
+					_ = yield(Invoice{Number: "INV-001"}, nil) && yield(Invoice{Number: "INV-002"}, nil)
+				}
+			},
+		}
+
+		var numbers []string
+		for invoice, err := range mock.All(ctx, &InvoiceListInput{}, WithMaxItems(2)) {
+			c.Assert(err, qt.IsNil)
+			numbers = append(numbers, invoice.Number)
+		}
+		c.Assert(numbers, qt.DeepEquals, []string{"INV-001", "INV-002"})
+		c.Assert(mock.Calls()[0].Args[2], qt.HasLen, 1)
+	})
+}
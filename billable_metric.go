@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -163,6 +164,26 @@ func (bmr *BillableMetricRequest) GetList(ctx context.Context, billableMetricLis
 	return billableMetricResult, nil
 }
 
+// All iterates over every billable metric matching the input, fetching pages as needed.
+func (bmr *BillableMetricRequest) All(ctx context.Context, billableMetricListInput *BillableMetricListInput, opts ...IteratorOption) iter.Seq2[BillableMetric, error] {
+	input := BillableMetricListInput{}
+	if billableMetricListInput != nil {
+		input = *billableMetricListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]BillableMetric, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := bmr.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.BillableMetrics, result.Meta, nil
+	}, opts)
+}
+
 func (bmr *BillableMetricRequest) Create(ctx context.Context, billableMetricInput *BillableMetricInput) (*BillableMetric, *Error) {
 
 	clientRequest := &ClientRequest{
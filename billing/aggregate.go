@@ -0,0 +1,430 @@
+package billing
+
+import (
+	"fmt"
+	"iter"
+	"maps"
+	"math/big"
+	"slices"
+	"strconv"
+	"time"
+
+	subrow "github.com/subrowio/subrow-go-client"
+)
+
+// Aggregation is the value of a billable metric over a billing period.
+type Aggregation struct {
+	// Value is the aggregated value, rounded as configured on the metric.
+	Value *big.Rat
+	// EventsCount is the number of events of the period that were
+	// aggregated.
+	EventsCount int
+	// Events are the values of the aggregated events, in the order they were
+	// added. They are only kept for the sum aggregation, the one percentage
+	// charges are priced on.
+	Events []*big.Rat
+	// DynamicAmount is the total precise_total_amount_cents of the events,
+	// in the major unit, for dynamic charges.
+	DynamicAmount *big.Rat
+}
+
+// Usage returns the aggregation as the usage of a charge.
+func (a *Aggregation) Usage() Usage {
+	return Usage{Units: a.Value, Events: a.Events, DynamicAmount: a.DynamicAmount}
+}
+
+type AggregateOption func(*aggregateOptions)
+
+type aggregateOptions struct {
+	filter                 map[string][]string
+	externalSubscriptionID string
+}
+
+// WithFilter only aggregates the events whose properties match values, the
+// values of a charge filter. Every key must be one of the metric filters.
+func WithFilter(values map[string][]string) AggregateOption {
+	return func(o *aggregateOptions) {
+		o.filter = values
+	}
+}
+
+// WithExternalSubscriptionID only aggregates the events of a subscription.
+func WithExternalSubscriptionID(externalID string) AggregateOption {
+	return func(o *aggregateOptions) {
+		o.externalSubscriptionID = externalID
+	}
+}
+
+// Aggregator computes a billable metric from events added one by one. Events
+// of other metrics, of other subscriptions or outside the period are skipped.
+type Aggregator struct {
+	metric  *subrow.BillableMetric
+	from    time.Time
+	to      time.Time
+	options aggregateOptions
+
+	count  int
+	sum    *big.Rat
+	max    *big.Rat
+	unique map[string]struct{}
+	// initial is the total carried over by a weighted sum, and weighted the
+	// sum of the values of its events weighted by how long they lasted.
+	initial  *big.Rat
+	weighted *big.Rat
+	events   []*big.Rat
+	dynamic  *big.Rat
+}
+
+// NewAggregator returns an Aggregator of metric over the period from..to,
+// from inclusive and to exclusive like the Start and End of a Period. The
+// ToDatetime of CustomerUsage is the last second of its period, so pass it
+// plus a second.
+//
+// For recurring metrics, and always for recurring_count_agg, events before
+// the period are carried over: they count towards the value without being
+// counted in EventsCount.
+//
+// Weighted sums are weighted by the second, the only WeightedInterval the API
+// defines; metrics with any other interval are rejected.
+func NewAggregator(metric *subrow.BillableMetric, from time.Time, to time.Time, opts ...AggregateOption) (*Aggregator, error) {
+	switch metric.AggregationType {
+	case subrow.CountAggregation, subrow.SumAggregation, subrow.MaxAggregation,
+		subrow.UniqueCountAggregation, subrow.RecurringCountAggregation:
+	case subrow.WeightedSumAggregation:
+		if metric.WeightedInterval != nil && *metric.WeightedInterval != subrow.SecondsInterval {
+			return nil, fmt.Errorf("billing: unsupported weighted interval %q", *metric.WeightedInterval)
+		}
+	default:
+		return nil, fmt.Errorf("billing: unsupported aggregation type %q", metric.AggregationType)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("billing: period ends at %s, before it starts at %s", to, from)
+	}
+
+	a := newAggregator(metric, from, to)
+	for _, opt := range opts {
+		opt(&a.options)
+	}
+
+	for key, values := range a.options.filter {
+		i := slices.IndexFunc(metric.Filters, func(f subrow.BillableMetricFilter) bool { return f.Key == key })
+		if i < 0 {
+			return nil, fmt.Errorf("billing: metric %q has no filter %q", metric.Code, key)
+		}
+		for _, value := range values {
+			if !slices.Contains(metric.Filters[i].Values, value) {
+				return nil, fmt.Errorf("billing: filter %q of metric %q has no value %q", key, metric.Code, value)
+			}
+		}
+	}
+
+	return a, nil
+}
+
+func newAggregator(metric *subrow.BillableMetric, from time.Time, to time.Time) *Aggregator {
+	a := &Aggregator{
+		metric:   metric,
+		from:     from,
+		to:       to,
+		sum:      new(big.Rat),
+		unique:   map[string]struct{}{},
+		initial:  new(big.Rat),
+		weighted: new(big.Rat),
+		dynamic:  new(big.Rat),
+	}
+	// An empty period still has its events known, so that percentage
+	// charges price it.
+	if metric.AggregationType == subrow.SumAggregation {
+		a.events = []*big.Rat{}
+	}
+
+	return a
+}
+
+// next returns an Aggregator over the period from..to that follows the one
+// of a, with the events added to a carried over as if they were added to it.
+func (a *Aggregator) next(from time.Time, to time.Time) *Aggregator {
+	n := newAggregator(a.metric, from, to)
+	n.options = a.options
+	if !a.metric.Recurring && a.metric.AggregationType != subrow.RecurringCountAggregation {
+		return n
+	}
+
+	switch a.metric.AggregationType {
+	case subrow.SumAggregation:
+		n.sum.Set(a.sum)
+	case subrow.MaxAggregation:
+		n.max = a.max
+	case subrow.UniqueCountAggregation, subrow.RecurringCountAggregation:
+		n.unique = maps.Clone(a.unique)
+	case subrow.WeightedSumAggregation:
+		n.sum.Set(a.sum)
+		n.initial.Set(a.sum)
+	}
+
+	return n
+}
+
+// Add aggregates an event returned by the API. Such events carry the result
+// of the metric expression in its field.
+func (a *Aggregator) Add(event *subrow.Event) error {
+	return a.add(event.Code, event.ExternalSubscriptionID, event.TransactionID, event.Timestamp, event.Properties, event.PreciseTotalAmountCents)
+}
+
+// AddInput aggregates an event as sent to the API. Its Timestamp is a Unix
+// time in seconds, possibly fractional, or an RFC 3339 date.
+func (a *Aggregator) AddInput(event *subrow.EventInput) error {
+	timestamp, err := parseTimestamp(event.Timestamp)
+	if err != nil {
+		return fmt.Errorf("billing: event %q: %w", event.TransactionID, err)
+	}
+
+	return a.add(event.Code, event.ExternalSubscriptionID, event.TransactionID, timestamp, event.Properties, event.PreciseTotalAmountCents)
+}
+
+func (a *Aggregator) add(code string, externalSubscriptionID string, transactionID string, timestamp time.Time, properties map[string]interface{}, preciseTotalAmountCents string) error {
+	if code != a.metric.Code || !timestamp.Before(a.to) {
+		return nil
+	}
+	if a.options.externalSubscriptionID != "" && externalSubscriptionID != a.options.externalSubscriptionID {
+		return nil
+	}
+	for key, values := range a.options.filter {
+		value, ok := properties[key]
+		if !ok || !slices.Contains(values, fmt.Sprint(value)) {
+			return nil
+		}
+	}
+
+	carriedOver := timestamp.Before(a.from)
+	if carriedOver && !a.metric.Recurring && a.metric.AggregationType != subrow.RecurringCountAggregation {
+		return nil
+	}
+
+	if a.metric.AggregationType == subrow.CountAggregation {
+		if !carriedOver {
+			a.count++
+		}
+		return nil
+	}
+
+	raw, ok := properties[a.metric.FieldName]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	switch a.metric.AggregationType {
+	case subrow.UniqueCountAggregation, subrow.RecurringCountAggregation:
+		a.unique[fmt.Sprint(raw)] = struct{}{}
+	default:
+		value, ok := number(raw)
+		if !ok {
+			return fmt.Errorf("billing: event %q: property %q is not a number: %v", transactionID, a.metric.FieldName, raw)
+		}
+
+		switch a.metric.AggregationType {
+		case subrow.SumAggregation:
+			a.sum.Add(a.sum, value)
+			if !carriedOver {
+				a.events = append(a.events, value)
+			}
+		case subrow.MaxAggregation:
+			if a.max == nil || value.Cmp(a.max) > 0 {
+				a.max = value
+			}
+		case subrow.WeightedSumAggregation:
+			a.sum.Add(a.sum, value)
+			if carriedOver {
+				a.initial.Add(a.initial, value)
+			} else {
+				a.weighted.Add(a.weighted, mul(value, seconds(a.to.Sub(timestamp))))
+			}
+		}
+	}
+
+	if !carriedOver {
+		a.count++
+		if preciseTotalAmountCents != "" {
+			cents, err := ParseDecimal(preciseTotalAmountCents)
+			if err != nil {
+				return fmt.Errorf("billing: event %q: %w", transactionID, err)
+			}
+			a.dynamic.Add(a.dynamic, new(big.Rat).Quo(cents, big.NewRat(100, 1)))
+		}
+	}
+
+	return nil
+}
+
+// Result returns the aggregation of the events added so far.
+func (a *Aggregator) Result() *Aggregation {
+	return &Aggregation{
+		Value:         a.value(),
+		EventsCount:   a.count,
+		Events:        slices.Clone(a.events),
+		DynamicAmount: new(big.Rat).Set(a.dynamic),
+	}
+}
+
+// usage returns the usage aggregated so far, sharing the events of a, which
+// must not be modified.
+func (a *Aggregator) usage() Usage {
+	return Usage{Units: a.value(), Events: a.events, DynamicAmount: new(big.Rat).Set(a.dynamic)}
+}
+
+func (a *Aggregator) value() *big.Rat {
+	var value *big.Rat
+	switch a.metric.AggregationType {
+	case subrow.CountAggregation:
+		value = big.NewRat(int64(a.count), 1)
+	case subrow.SumAggregation:
+		value = new(big.Rat).Set(a.sum)
+	case subrow.MaxAggregation:
+		value = new(big.Rat)
+		if a.max != nil {
+			value.Set(a.max)
+		}
+	case subrow.UniqueCountAggregation, subrow.RecurringCountAggregation:
+		value = big.NewRat(int64(len(a.unique)), 1)
+	case subrow.WeightedSumAggregation:
+		value = a.weightedSum()
+	}
+
+	return a.roundValue(value)
+}
+
+// weightedSum weights each successive total by the share of the period it
+// lasted, in seconds. The carried over total lasts the whole period, and the
+// value of each event from its time to the end of the period.
+func (a *Aggregator) weightedSum() *big.Rat {
+	value := new(big.Rat).Quo(a.weighted, seconds(a.to.Sub(a.from)))
+
+	return value.Add(value, a.initial)
+}
+
+func (a *Aggregator) roundValue(value *big.Rat) *big.Rat {
+	if a.metric.RoundingFunction == nil {
+		return value
+	}
+
+	precision := 0
+	if a.metric.RoundingPrecision != nil {
+		precision = *a.metric.RoundingPrecision
+	}
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(precision))), nil))
+	if precision < 0 {
+		scale.Inv(scale)
+	}
+
+	scaled := mul(value, scale)
+	switch *a.metric.RoundingFunction {
+	case subrow.CeilRoundingFunction:
+		scaled = ceil(scaled)
+	case subrow.FloorRoundingFunction:
+		scaled = floor(scaled)
+	default:
+		scaled = new(big.Rat).SetInt(round(scaled))
+	}
+
+	return scaled.Quo(scaled, scale)
+}
+
+// AggregateEvents aggregates events returned by the API over the period
+// from..to.
+func AggregateEvents(metric *subrow.BillableMetric, from time.Time, to time.Time, events []subrow.Event, opts ...AggregateOption) (*Aggregation, error) {
+	return AggregateStream(metric, from, to, EventSeq(events), opts...)
+}
+
+// AggregateInputs aggregates events as sent to the API over the period
+// from..to.
+func AggregateInputs(metric *subrow.BillableMetric, from time.Time, to time.Time, events []subrow.EventInput, opts ...AggregateOption) (*Aggregation, error) {
+	a, err := NewAggregator(metric, from, to, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		if err := a.AddInput(&events[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return a.Result(), nil
+}
+
+// AggregateStream aggregates a stream of events, such as a paginated listing
+// of the API, over the period from..to. It stops at the first error.
+func AggregateStream(metric *subrow.BillableMetric, from time.Time, to time.Time, events iter.Seq2[subrow.Event, error], opts ...AggregateOption) (*Aggregation, error) {
+	a, err := NewAggregator(metric, from, to, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for event, err := range events {
+		if err != nil {
+			return nil, err
+		}
+		if err := a.Add(&event); err != nil {
+			return nil, err
+		}
+	}
+
+	return a.Result(), nil
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, fmt.Errorf("no timestamp")
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+	}
+	nanos := new(big.Rat).Mul(r, big.NewRat(int64(time.Second), 1))
+
+	return time.Unix(0, round(nanos).Int64()).UTC(), nil
+}
+
+// number converts a property value decoded from JSON, or set by the caller,
+// to a decimal.
+func number(v interface{}) (*big.Rat, bool) {
+	switch n := v.(type) {
+	case float64:
+		return new(big.Rat).SetString(strconv.FormatFloat(n, 'f', -1, 64))
+	case float32:
+		return new(big.Rat).SetString(strconv.FormatFloat(float64(n), 'f', -1, 32))
+	case int:
+		return big.NewRat(int64(n), 1), true
+	case int64:
+		return big.NewRat(n, 1), true
+	case int32:
+		return big.NewRat(int64(n), 1), true
+	case string:
+		return new(big.Rat).SetString(n)
+	case fmt.Stringer:
+		return new(big.Rat).SetString(n.String())
+	default:
+		return nil, false
+	}
+}
+
+func seconds(d time.Duration) *big.Rat {
+	return big.NewRat(int64(d), int64(time.Second))
+}
+
+// floor rounds r down to an integer.
+func floor(r *big.Rat) *big.Rat {
+	q, _ := new(big.Int).DivMod(r.Num(), r.Denom(), new(big.Int))
+
+	return new(big.Rat).SetInt(q)
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+
+	return i
+}
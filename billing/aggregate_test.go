@@ -0,0 +1,275 @@
+package billing_test
+
+import (
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	subrow "github.com/subrowio/subrow-go-client"
+	"github.com/subrowio/subrow-go-client/billing"
+)
+
+var (
+	periodFrom = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	periodTo   = time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+)
+
+func day(d int, hour int) time.Time {
+	return time.Date(2025, 3, d, hour, 0, 0, 0, time.UTC)
+}
+
+func event(id string, at time.Time, properties map[string]interface{}) subrow.Event {
+	return subrow.Event{
+		TransactionID:          id,
+		Code:                   "storage",
+		ExternalSubscriptionID: "sub_1",
+		Timestamp:              at,
+		Properties:             properties,
+	}
+}
+
+func TestAggregateEvents(t *testing.T) {
+	events := []subrow.Event{
+		event("tr_0", day(1, 0).AddDate(0, 0, -3), map[string]interface{}{"gb": 4.0, "region": "eu", "bucket": "a"}),
+		event("tr_1", day(1, 0), map[string]interface{}{"gb": 10.0, "region": "eu", "bucket": "a"}),
+		event("tr_2", day(11, 0), map[string]interface{}{"gb": "2.5", "region": "us", "bucket": "b"}),
+		event("tr_3", day(21, 0), map[string]interface{}{"gb": -5.0, "region": "eu", "bucket": "a"}),
+		event("tr_4", day(21, 12), map[string]interface{}{"region": "eu", "bucket": "c"}),
+		event("tr_5", periodTo.Add(time.Hour), map[string]interface{}{"gb": 100.0, "region": "eu", "bucket": "d"}),
+		{TransactionID: "tr_6", Code: "api_calls", Timestamp: day(2, 0), Properties: map[string]interface{}{"gb": 1.0}},
+	}
+
+	metric := func(aggregation subrow.AggregationType, recurring bool) *subrow.BillableMetric {
+		return &subrow.BillableMetric{
+			Code:            "storage",
+			AggregationType: aggregation,
+			FieldName:       "gb",
+			Recurring:       recurring,
+			Filters:         []subrow.BillableMetricFilter{{Key: "region", Values: []string{"eu", "us"}}},
+		}
+	}
+
+	var tests = []struct {
+		name       string
+		metric     *subrow.BillableMetric
+		opts       []billing.AggregateOption
+		wantValue  string
+		wantEvents int
+	}{
+		{
+			name:       "Count",
+			metric:     metric(subrow.CountAggregation, false),
+			wantValue:  "4",
+			wantEvents: 4,
+		},
+		{
+			name:       "Sum",
+			metric:     metric(subrow.SumAggregation, false),
+			wantValue:  "7.5",
+			wantEvents: 3,
+		},
+		{
+			name:       "Recurring sum",
+			metric:     metric(subrow.SumAggregation, true),
+			wantValue:  "11.5",
+			wantEvents: 3,
+		},
+		{
+			name:       "Sum with a filter",
+			metric:     metric(subrow.SumAggregation, false),
+			opts:       []billing.AggregateOption{billing.WithFilter(map[string][]string{"region": {"eu"}})},
+			wantValue:  "5",
+			wantEvents: 2,
+		},
+		{
+			name:       "Max",
+			metric:     metric(subrow.MaxAggregation, false),
+			wantValue:  "10",
+			wantEvents: 3,
+		},
+		{
+			name:       "Unique count",
+			metric:     &subrow.BillableMetric{Code: "storage", AggregationType: subrow.UniqueCountAggregation, FieldName: "bucket"},
+			wantValue:  "3",
+			wantEvents: 4,
+		},
+		{
+			name:       "Recurring count",
+			metric:     &subrow.BillableMetric{Code: "storage", AggregationType: subrow.RecurringCountAggregation, FieldName: "region"},
+			wantValue:  "2",
+			wantEvents: 4,
+		},
+		{
+			name:   "Weighted sum",
+			metric: metric(subrow.WeightedSumAggregation, true),
+			// 4 GB for 0 days, 14 GB for 10 days, 16.5 GB for 10 days, 11.5 GB for 10 days
+			wantValue:  "14",
+			wantEvents: 3,
+		},
+		{
+			name:       "Other subscription",
+			metric:     metric(subrow.CountAggregation, false),
+			opts:       []billing.AggregateOption{billing.WithExternalSubscriptionID("sub_2")},
+			wantValue:  "0",
+			wantEvents: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			aggregation, err := billing.AggregateEvents(tt.metric, periodFrom, periodTo, events, tt.opts...)
+			c.Assert(err, qt.IsNil)
+			c.Assert(aggregation.Value.Cmp(rat(tt.wantValue)), qt.Equals, 0, qt.Commentf("value %s", aggregation.Value.FloatString(6)))
+			c.Assert(aggregation.EventsCount, qt.Equals, tt.wantEvents)
+		})
+	}
+}
+
+func TestAggregatePeriodEnd(t *testing.T) {
+	// An event at the end of a period belongs to the next one only.
+	march := billing.Period{Start: day(1, 0), End: day(1, 0).AddDate(0, 1, 0)}
+	april := billing.Period{Start: march.End, End: march.End.AddDate(0, 1, 0)}
+	events := []subrow.Event{
+		event("tr_1", march.Start, map[string]interface{}{"gb": 10.0}),
+		event("tr_2", march.End, map[string]interface{}{"gb": 5.0}),
+	}
+
+	var tests = []struct {
+		aggregation subrow.AggregationType
+		wantMarch   string
+		wantApril   string
+	}{
+		{aggregation: subrow.CountAggregation, wantMarch: "1", wantApril: "1"},
+		{aggregation: subrow.SumAggregation, wantMarch: "10", wantApril: "5"},
+		// 10 GB carried over plus 5 GB for all of April.
+		{aggregation: subrow.WeightedSumAggregation, wantMarch: "10", wantApril: "15"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.aggregation), func(t *testing.T) {
+			c := qt.New(t)
+
+			metric := &subrow.BillableMetric{Code: "storage", AggregationType: tt.aggregation, FieldName: "gb", Recurring: tt.aggregation == subrow.WeightedSumAggregation}
+			for _, p := range []struct {
+				period billing.Period
+				want   string
+			}{{march, tt.wantMarch}, {april, tt.wantApril}} {
+				aggregation, err := billing.AggregateEvents(metric, p.period.Start, p.period.End, events)
+				c.Assert(err, qt.IsNil)
+				c.Assert(aggregation.Value.Cmp(rat(p.want)), qt.Equals, 0, qt.Commentf("%s: value %s", p.period.Start.Month(), aggregation.Value.FloatString(6)))
+				c.Assert(aggregation.EventsCount, qt.Equals, 1)
+			}
+		})
+	}
+}
+
+func TestAggregateRounding(t *testing.T) {
+	var tests = []struct {
+		function  subrow.RoundingFunction
+		precision *int
+		want      string
+	}{
+		{function: subrow.RoundRoundingFunction, want: "13"},
+		{function: subrow.CeilRoundingFunction, precision: intPtr(1), want: "12.6"},
+		{function: subrow.FloorRoundingFunction, precision: intPtr(1), want: "12.5"},
+		{function: subrow.RoundRoundingFunction, precision: intPtr(-1), want: "10"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.function), func(t *testing.T) {
+			c := qt.New(t)
+
+			metric := &subrow.BillableMetric{
+				Code:              "storage",
+				AggregationType:   subrow.SumAggregation,
+				FieldName:         "gb",
+				RoundingFunction:  &tt.function,
+				RoundingPrecision: tt.precision,
+			}
+			aggregation, err := billing.AggregateInputs(metric, periodFrom, periodTo, []subrow.EventInput{
+				{TransactionID: "tr_1", Code: "storage", Timestamp: "1741046400", Properties: map[string]interface{}{"gb": "12.5"}},
+				{TransactionID: "tr_2", Code: "storage", Timestamp: "2025-03-05T10:00:00Z", Properties: map[string]interface{}{"gb": 0.02}},
+			})
+			c.Assert(err, qt.IsNil)
+			c.Assert(aggregation.Value.Cmp(rat(tt.want)), qt.Equals, 0, qt.Commentf("value %s", aggregation.Value.FloatString(6)))
+		})
+	}
+}
+
+func TestAggregateUsage(t *testing.T) {
+	c := qt.New(t)
+
+	metric := &subrow.BillableMetric{Code: "payments", AggregationType: subrow.SumAggregation, FieldName: "amount"}
+	aggregation, err := billing.AggregateInputs(metric, periodFrom, periodTo, []subrow.EventInput{
+		{TransactionID: "tr_1", Code: "payments", Timestamp: "1741046400.5", Properties: map[string]interface{}{"amount": 100}, PreciseTotalAmountCents: "120.5"},
+		{TransactionID: "tr_2", Code: "payments", Timestamp: "1741132800", Properties: map[string]interface{}{"amount": 50}},
+	})
+	c.Assert(err, qt.IsNil)
+
+	fee, err := billing.PriceUsage(subrow.PercentageProperties{Rate: "1", FixedAmount: "0.25"}, aggregation.Usage())
+	c.Assert(err, qt.IsNil)
+	c.Assert(fee.TotalCents(), qt.Equals, int64(200))
+	c.Assert(aggregation.DynamicAmount.Cmp(rat("1.205")), qt.Equals, 0)
+
+	c.Run("When the period has no events", func(c *qt.C) {
+		aggregation, err := billing.AggregateInputs(metric, periodFrom, periodTo, nil)
+		c.Assert(err, qt.IsNil)
+
+		fee, err := billing.PriceUsage(subrow.PercentageProperties{Rate: "1", FixedAmount: "0.25"}, aggregation.Usage())
+		c.Assert(err, qt.IsNil)
+		c.Assert(fee.TotalCents(), qt.Equals, int64(0))
+	})
+}
+
+func TestAggregateErrors(t *testing.T) {
+	t.Run("When the filter is not defined on the metric", func(t *testing.T) {
+		c := qt.New(t)
+
+		metric := &subrow.BillableMetric{Code: "storage", AggregationType: subrow.CountAggregation}
+		_, err := billing.NewAggregator(metric, periodFrom, periodTo, billing.WithFilter(map[string][]string{"region": {"eu"}}))
+		c.Assert(err, qt.ErrorMatches, `billing: metric "storage" has no filter "region"`)
+	})
+
+	t.Run("When the weighted interval is not supported", func(t *testing.T) {
+		c := qt.New(t)
+
+		interval := subrow.WeightedInterval("hours")
+		metric := &subrow.BillableMetric{Code: "seats", AggregationType: subrow.WeightedSumAggregation, WeightedInterval: &interval}
+		_, err := billing.NewAggregator(metric, periodFrom, periodTo)
+		c.Assert(err, qt.ErrorMatches, `billing: unsupported weighted interval "hours"`)
+	})
+
+	t.Run("When a value is not a number", func(t *testing.T) {
+		c := qt.New(t)
+
+		metric := &subrow.BillableMetric{Code: "storage", AggregationType: subrow.SumAggregation, FieldName: "gb"}
+		_, err := billing.AggregateEvents(metric, periodFrom, periodTo, []subrow.Event{
+			event("tr_1", day(2, 0), map[string]interface{}{"gb": "ten"}),
+		})
+		c.Assert(err, qt.ErrorMatches, `billing: event "tr_1": property "gb" is not a number: ten`)
+	})
+
+	t.Run("When an event input has no timestamp", func(t *testing.T) {
+		c := qt.New(t)
+
+		metric := &subrow.BillableMetric{Code: "storage", AggregationType: subrow.CountAggregation}
+		_, err := billing.AggregateInputs(metric, periodFrom, periodTo, []subrow.EventInput{{TransactionID: "tr_1", Code: "storage"}})
+		c.Assert(err, qt.ErrorMatches, `billing: event "tr_1": no timestamp`)
+	})
+
+	t.Run("When the stream fails", func(t *testing.T) {
+		c := qt.New(t)
+
+		var stream iter.Seq2[subrow.Event, error] = func(yield func(subrow.Event, error) bool) {
+			if !yield(event("tr_1", day(2, 0), nil), nil) {
+				return
+			}
+			yield(subrow.Event{}, errors.New("page 2: timeout"))
+		}
+		metric := &subrow.BillableMetric{Code: "storage", AggregationType: subrow.CountAggregation}
+		_, err := billing.AggregateStream(metric, periodFrom, periodTo, stream)
+		c.Assert(err, qt.ErrorMatches, `page 2: timeout`)
+	})
+}
@@ -0,0 +1,463 @@
+// Package billing reproduces the billing computations of Subrow locally, so
+// usage can be priced without calling the API:
+//
+//	fee, err := billing.CalculateFee(charge, billing.Usage{Units: big.NewRat(1250, 1)})
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(fee.TotalCents())
+//
+// Amounts are exact decimals in the major unit of the currency, held in
+// *big.Rat, and only rounded to cents on request.
+package billing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	subrow "github.com/subrowio/subrow-go-client"
+)
+
+// Usage is what a charge is priced on over a billing period.
+type Usage struct {
+	// Units is the aggregated value of the billable metric.
+	Units *big.Rat
+	// Events are the values of the events aggregated in Units, in the order
+	// they were received. The percentage model needs them to apply free
+	// events, fixed amounts and per-transaction amounts.
+	Events []*big.Rat
+	// DynamicAmount is the total amount of the events of a dynamic charge,
+	// the sum of their precise_total_amount_cents converted to the major
+	// unit.
+	DynamicAmount *big.Rat
+}
+
+// Fee is the price of a charge for a Usage.
+type Fee struct {
+	// Units is the number of units charged, free units excluded.
+	Units *big.Rat
+	// Events is the number of events charged, for the percentage model.
+	Events int
+	// Amount is the fee computed by the charge model.
+	Amount *big.Rat
+	// TrueUpAmount is added to Amount to reach Charge.MinAmountCents.
+	TrueUpAmount *big.Rat
+}
+
+// Total returns Amount plus TrueUpAmount.
+func (f *Fee) Total() *big.Rat {
+	return new(big.Rat).Add(f.Amount, f.TrueUpAmount)
+}
+
+// TotalCents returns Total rounded to cents, half away from zero.
+func (f *Fee) TotalCents() int64 {
+	return Cents(f.Total())
+}
+
+var errMissingEvents = errors.New("billing: percentage charge needs Usage.Events to apply free events, fixed or per-transaction amounts")
+
+// CalculateFee prices usage with the model and properties of charge, then
+// applies its minimum amount. The properties are taken from
+// Charge.TypedProperties, else decoded from Charge.Properties.
+func CalculateFee(charge *subrow.Charge, usage Usage) (*Fee, error) {
+	properties := charge.TypedProperties
+	if properties == nil {
+		var err error
+		if properties, err = decodeProperties(charge.ChargeModel, charge.Properties); err != nil {
+			return nil, err
+		}
+	}
+
+	fee, err := PriceUsage(properties, usage)
+	if err != nil {
+		return nil, err
+	}
+
+	fee.TrueUpAmount = trueUp(charge, fee.Amount)
+
+	return fee, nil
+}
+
+// trueUp returns what is added to amount to reach the minimum amount of
+// charge, zero when amount reaches it.
+func trueUp(charge *subrow.Charge, amount *big.Rat) *big.Rat {
+	minAmount := big.NewRat(int64(charge.MinAmountCents), 100)
+	if amount.Cmp(minAmount) >= 0 {
+		return new(big.Rat)
+	}
+
+	return minAmount.Sub(minAmount, amount)
+}
+
+// CalculateFilterFee prices the usage matching filter, one of the filters of
+// charge, with the properties of the filter.
+func CalculateFilterFee(charge *subrow.Charge, filter *subrow.ChargeFilter, usage Usage) (*Fee, error) {
+	properties := filter.TypedProperties
+	if properties == nil {
+		var err error
+		if properties, err = decodeProperties(charge.ChargeModel, filter.Properties); err != nil {
+			return nil, err
+		}
+	}
+
+	return PriceUsage(properties, usage)
+}
+
+func decodeProperties(chargeModel subrow.ChargeModel, properties map[string]interface{}) (subrow.ChargeProperties, error) {
+	data, err := json.Marshal(properties)
+	if err != nil {
+		return nil, err
+	}
+
+	typed, err := subrow.UnmarshalChargeProperties(chargeModel, data)
+	if err != nil {
+		return nil, fmt.Errorf("billing: invalid %s charge properties: %w", chargeModel, err)
+	}
+
+	return typed, nil
+}
+
+// PriceUsage prices usage with properties, without any minimum amount.
+func PriceUsage(properties subrow.ChargeProperties, usage Usage) (*Fee, error) {
+	if err := properties.Validate(); err != nil {
+		return nil, fmt.Errorf("billing: invalid %s charge properties: %w", properties.ChargeModel(), err)
+	}
+
+	units := usage.Units
+	if units == nil {
+		units = new(big.Rat)
+	}
+	fee := &Fee{Units: new(big.Rat).Set(units), Amount: new(big.Rat), TrueUpAmount: new(big.Rat)}
+
+	switch p := dereference(properties).(type) {
+	case subrow.StandardProperties:
+		fee.Amount = mul(units, decimal(p.Amount))
+	case subrow.GraduatedProperties:
+		fee.Amount = priceGraduated(units, graduatedTiers(p))
+	case subrow.GraduatedPercentageProperties:
+		fee.Amount = priceGraduated(units, graduatedPercentageTiers(p))
+	case subrow.PackageProperties:
+		pricePackage(fee, units, p)
+	case subrow.PercentageProperties:
+		if err := pricePercentage(fee, usage, p); err != nil {
+			return nil, err
+		}
+	case subrow.VolumeProperties:
+		fee.Amount = priceVolume(units, volumeTiers(p))
+	case subrow.DynamicProperties:
+		if usage.DynamicAmount != nil {
+			fee.Amount = new(big.Rat).Set(usage.DynamicAmount)
+		}
+	default:
+		return nil, fmt.Errorf("billing: unsupported charge properties %T", properties)
+	}
+
+	return fee, nil
+}
+
+// dereference returns the properties decoded by
+// subrow.UnmarshalChargeProperties as values, like the ones built by hand.
+func dereference(properties subrow.ChargeProperties) subrow.ChargeProperties {
+	switch p := properties.(type) {
+	case *subrow.StandardProperties:
+		return *p
+	case *subrow.GraduatedProperties:
+		return *p
+	case *subrow.GraduatedPercentageProperties:
+		return *p
+	case *subrow.PackageProperties:
+		return *p
+	case *subrow.PercentageProperties:
+		return *p
+	case *subrow.VolumeProperties:
+		return *p
+	case *subrow.DynamicProperties:
+		return *p
+	}
+
+	return properties
+}
+
+// tier is a range of units of the graduated, graduated percentage and volume
+// models. Bounds are inclusive, and to is nil on the last tier.
+type tier struct {
+	from *big.Rat
+	to   *big.Rat
+	// perUnit is the price of a unit: an amount, or a rate divided by 100.
+	perUnit *big.Rat
+	flat    *big.Rat
+}
+
+func graduatedTiers(p subrow.GraduatedProperties) []tier {
+	tiers := make([]tier, len(p.GraduatedRanges))
+	for i, r := range p.GraduatedRanges {
+		tiers[i] = newTier(r.FromValue, r.ToValue, decimal(r.PerUnitAmount), decimal(r.FlatAmount))
+	}
+
+	return tiers
+}
+
+func graduatedPercentageTiers(p subrow.GraduatedPercentageProperties) []tier {
+	tiers := make([]tier, len(p.GraduatedPercentageRanges))
+	for i, r := range p.GraduatedPercentageRanges {
+		tiers[i] = newTier(r.FromValue, r.ToValue, percent(r.Rate), decimal(r.FlatAmount))
+	}
+
+	return tiers
+}
+
+func volumeTiers(p subrow.VolumeProperties) []tier {
+	tiers := make([]tier, len(p.VolumeRanges))
+	for i, r := range p.VolumeRanges {
+		tiers[i] = newTier(r.FromValue, r.ToValue, decimal(r.PerUnitAmount), decimal(r.FlatAmount))
+	}
+
+	return tiers
+}
+
+func newTier(from int, to *int, perUnit *big.Rat, flat *big.Rat) tier {
+	t := tier{from: big.NewRat(int64(from), 1), perUnit: perUnit, flat: flat}
+	if to != nil {
+		t.to = big.NewRat(int64(*to), 1)
+	}
+
+	return t
+}
+
+// lower is the number of units below the tier: a tier from 11 to 20 holds
+// the units above 10, fractional ones included.
+func (t tier) lower() *big.Rat {
+	if t.from.Sign() == 0 {
+		return new(big.Rat)
+	}
+
+	return new(big.Rat).Sub(t.from, big.NewRat(1, 1))
+}
+
+// priceGraduated prices every tier the units reach: the units within it at
+// its unit price, plus its flat amount.
+func priceGraduated(units *big.Rat, tiers []tier) *big.Rat {
+	amount := new(big.Rat)
+	if units.Sign() <= 0 {
+		return amount
+	}
+
+	for _, t := range tiers {
+		lower := t.lower()
+		if units.Cmp(lower) <= 0 {
+			break
+		}
+
+		upper := units
+		if t.to != nil && units.Cmp(t.to) > 0 {
+			upper = t.to
+		}
+		amount.Add(amount, mul(new(big.Rat).Sub(upper, lower), t.perUnit))
+		amount.Add(amount, t.flat)
+	}
+
+	return amount
+}
+
+// priceVolume prices all the units at the price of the tier their total
+// falls in.
+func priceVolume(units *big.Rat, tiers []tier) *big.Rat {
+	if units.Sign() <= 0 {
+		return new(big.Rat)
+	}
+
+	for _, t := range tiers {
+		if t.to == nil || units.Cmp(t.to) <= 0 {
+			return new(big.Rat).Add(mul(units, t.perUnit), t.flat)
+		}
+	}
+
+	return new(big.Rat)
+}
+
+// pricePackage charges every started package of units, once the free units
+// are used up.
+func pricePackage(fee *Fee, units *big.Rat, p subrow.PackageProperties) {
+	paid := new(big.Rat).Sub(units, big.NewRat(int64(p.FreeUnits), 1))
+	if paid.Sign() <= 0 {
+		fee.Units = new(big.Rat)
+		return
+	}
+	fee.Units = paid
+
+	packages := new(big.Rat).Quo(paid, big.NewRat(int64(p.PackageSize), 1))
+	fee.Amount = mul(ceil(packages), decimal(p.Amount))
+}
+
+// pricePercentage charges a rate of the units, plus a fixed amount per
+// event. The first FreeUnitsPerEvents events and FreeUnitsPerTotalAggregation
+// units are free, whichever runs out first. With per-transaction amounts,
+// the fee of each event, fixed amount included, is kept between them.
+func pricePercentage(fee *Fee, usage Usage, p subrow.PercentageProperties) error {
+	if usage.Events == nil {
+		perTransaction := p.PerTransactionMinAmount != "" || p.PerTransactionMaxAmount != ""
+		if p.FreeUnitsPerEvents != nil || decimal(p.FixedAmount).Sign() != 0 || perTransaction {
+			return errMissingEvents
+		}
+
+		paid := new(big.Rat).Sub(fee.Units, decimal(p.FreeUnitsPerTotalAggregation))
+		if paid.Sign() < 0 {
+			paid = new(big.Rat)
+		}
+		fee.Units = paid
+		fee.Amount = mul(paid, percent(p.Rate))
+		return nil
+	}
+
+	pricer := newPercentagePricer(p)
+	for _, value := range usage.Events {
+		pricer.add(value)
+	}
+	fee.Units, fee.Events, fee.Amount = pricer.units, pricer.events, pricer.amount
+
+	return nil
+}
+
+// percentagePricer prices the events of a percentage charge one by one, in
+// the order they were received, so that pricing one more event does not
+// price the previous ones again.
+type percentagePricer struct {
+	rate       *big.Rat
+	fixed      *big.Rat
+	min        *big.Rat
+	max        *big.Rat
+	freeEvents int
+	freeUnits  *big.Rat
+	hasFree    bool
+	seen       int
+
+	units  *big.Rat
+	events int
+	amount *big.Rat
+}
+
+func newPercentagePricer(p subrow.PercentageProperties) *percentagePricer {
+	pp := &percentagePricer{
+		rate:       percent(p.Rate),
+		fixed:      decimal(p.FixedAmount),
+		freeEvents: -1,
+		units:      new(big.Rat),
+		amount:     new(big.Rat),
+	}
+	if p.PerTransactionMinAmount != "" {
+		pp.min = decimal(p.PerTransactionMinAmount)
+	}
+	if p.PerTransactionMaxAmount != "" {
+		pp.max = decimal(p.PerTransactionMaxAmount)
+	}
+	if p.FreeUnitsPerEvents != nil {
+		pp.freeEvents = *p.FreeUnitsPerEvents
+	}
+	if p.FreeUnitsPerTotalAggregation != "" {
+		pp.freeUnits = decimal(p.FreeUnitsPerTotalAggregation)
+	}
+	pp.hasFree = pp.freeEvents >= 0 || pp.freeUnits != nil
+
+	return pp
+}
+
+func (pp *percentagePricer) add(value *big.Rat) {
+	i := pp.seen
+	pp.seen++
+
+	paid := new(big.Rat).Set(value)
+	if pp.hasFree && (pp.freeEvents < 0 || i < pp.freeEvents) && (pp.freeUnits == nil || pp.freeUnits.Sign() > 0) {
+		if pp.freeUnits == nil {
+			return
+		}
+		if paid.Cmp(pp.freeUnits) <= 0 {
+			pp.freeUnits.Sub(pp.freeUnits, paid)
+			return
+		}
+		paid.Sub(paid, pp.freeUnits)
+		pp.freeUnits.SetInt64(0)
+	}
+
+	transaction := new(big.Rat).Add(mul(paid, pp.rate), pp.fixed)
+	if pp.min != nil && transaction.Cmp(pp.min) < 0 {
+		transaction.Set(pp.min)
+	}
+	if pp.max != nil && transaction.Cmp(pp.max) > 0 {
+		transaction.Set(pp.max)
+	}
+
+	pp.units.Add(pp.units, paid)
+	pp.events++
+	pp.amount.Add(pp.amount, transaction)
+}
+
+// fee returns the fee of the events priced so far.
+func (pp *percentagePricer) fee() *Fee {
+	return &Fee{
+		Units:        new(big.Rat).Set(pp.units),
+		Events:       pp.events,
+		Amount:       new(big.Rat).Set(pp.amount),
+		TrueUpAmount: new(big.Rat),
+	}
+}
+
+// ParseDecimal parses a decimal string such as "12.05" exactly.
+func ParseDecimal(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("billing: invalid decimal %q", s)
+	}
+
+	return r, nil
+}
+
+// Cents rounds an amount in the major unit of a currency to cents, half away
+// from zero.
+func Cents(amount *big.Rat) int64 {
+	cents := new(big.Rat).Mul(amount, big.NewRat(100, 1))
+
+	return round(cents).Int64()
+}
+
+// decimal parses a decimal string of properties that passed validation.
+// Empty strings are zero.
+func decimal(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return new(big.Rat)
+	}
+
+	return r
+}
+
+func percent(s string) *big.Rat {
+	return new(big.Rat).Quo(decimal(s), big.NewRat(100, 1))
+}
+
+func mul(a *big.Rat, b *big.Rat) *big.Rat {
+	return new(big.Rat).Mul(a, b)
+}
+
+// ceil rounds r up to an integer.
+func ceil(r *big.Rat) *big.Rat {
+	q, m := new(big.Int).DivMod(r.Num(), r.Denom(), new(big.Int))
+	if m.Sign() != 0 {
+		q.Add(q, big.NewInt(1))
+	}
+
+	return new(big.Rat).SetInt(q)
+}
+
+// round rounds r to an integer, half away from zero.
+func round(r *big.Rat) *big.Int {
+	abs := new(big.Rat).Abs(r)
+	abs.Add(abs, big.NewRat(1, 2))
+	q := new(big.Int).Quo(abs.Num(), abs.Denom())
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+
+	return q
+}
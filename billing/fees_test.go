@@ -0,0 +1,253 @@
+package billing_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	subrow "github.com/subrowio/subrow-go-client"
+	"github.com/subrowio/subrow-go-client/billing"
+)
+
+func rat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("invalid rat " + s)
+	}
+	return r
+}
+
+func rats(values ...string) []*big.Rat {
+	result := make([]*big.Rat, len(values))
+	for i, value := range values {
+		result[i] = rat(value)
+	}
+	return result
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestPriceUsage(t *testing.T) {
+	graduated := subrow.GraduatedProperties{GraduatedRanges: []subrow.GraduatedRange{
+		{FromValue: 0, ToValue: intPtr(10), PerUnitAmount: "1", FlatAmount: "2"},
+		{FromValue: 11, ToValue: intPtr(20), PerUnitAmount: "0.5", FlatAmount: "3"},
+		{FromValue: 21, PerUnitAmount: "0.1", FlatAmount: "0"},
+	}}
+	volume := subrow.VolumeProperties{VolumeRanges: []subrow.VolumeRange{
+		{FromValue: 0, ToValue: intPtr(100), PerUnitAmount: "2", FlatAmount: "1"},
+		{FromValue: 101, PerUnitAmount: "1.5", FlatAmount: "10"},
+	}}
+
+	var tests = []struct {
+		name       string
+		properties subrow.ChargeProperties
+		usage      billing.Usage
+		wantAmount string
+		wantUnits  string
+		wantEvents int
+	}{
+		{
+			name:       "Standard",
+			properties: subrow.StandardProperties{Amount: "0.015"},
+			usage:      billing.Usage{Units: rat("1234.5")},
+			wantAmount: "18.5175",
+			wantUnits:  "1234.5",
+		},
+		{
+			name:       "Standard without usage",
+			properties: subrow.StandardProperties{Amount: "5"},
+			usage:      billing.Usage{},
+			wantAmount: "0",
+			wantUnits:  "0",
+		},
+		{
+			name:       "Graduated within the first tier",
+			properties: graduated,
+			usage:      billing.Usage{Units: rat("4")},
+			wantAmount: "6",
+			wantUnits:  "4",
+		},
+		{
+			name:       "Graduated across every tier",
+			properties: graduated,
+			usage:      billing.Usage{Units: rat("25")},
+			// 10 * 1 + 2 + 10 * 0.5 + 3 + 5 * 0.1
+			wantAmount: "20.5",
+			wantUnits:  "25",
+		},
+		{
+			name:       "Graduated with fractional units between tiers",
+			properties: graduated,
+			usage:      billing.Usage{Units: rat("10.5")},
+			// 10 * 1 + 2 + 0.5 * 0.5 + 3
+			wantAmount: "15.25",
+			wantUnits:  "10.5",
+		},
+		{
+			name: "Graduated percentage",
+			properties: subrow.GraduatedPercentageProperties{GraduatedPercentageRanges: []subrow.GraduatedPercentageRange{
+				{FromValue: 0, ToValue: intPtr(1000), Rate: "2", FlatAmount: "1"},
+				{FromValue: 1001, Rate: "1", FlatAmount: "0"},
+			}},
+			usage: billing.Usage{Units: rat("1500")},
+			// 1000 * 2% + 1 + 500 * 1%
+			wantAmount: "26",
+			wantUnits:  "1500",
+		},
+		{
+			name:       "Package with free units",
+			properties: subrow.PackageProperties{Amount: "10", FreeUnits: 100, PackageSize: 50},
+			usage:      billing.Usage{Units: rat("201")},
+			// 101 paid units, 3 started packages
+			wantAmount: "30",
+			wantUnits:  "101",
+		},
+		{
+			name:       "Package within free units",
+			properties: subrow.PackageProperties{Amount: "10", FreeUnits: 100, PackageSize: 50},
+			usage:      billing.Usage{Units: rat("80")},
+			wantAmount: "0",
+			wantUnits:  "0",
+		},
+		{
+			name:       "Percentage on the total",
+			properties: subrow.PercentageProperties{Rate: "1.5", FreeUnitsPerTotalAggregation: "100"},
+			usage:      billing.Usage{Units: rat("1100")},
+			wantAmount: "15",
+			wantUnits:  "1000",
+		},
+		{
+			name:       "Percentage with a fixed amount and free events",
+			properties: subrow.PercentageProperties{Rate: "2", FixedAmount: "0.3", FreeUnitsPerEvents: intPtr(2)},
+			usage:      billing.Usage{Units: rat("400"), Events: rats("100", "100", "150", "50")},
+			// (150 + 50) * 2% + 2 * 0.3
+			wantAmount: "4.6",
+			wantUnits:  "200",
+			wantEvents: 2,
+		},
+		{
+			name:       "Percentage with free events capped by free units",
+			properties: subrow.PercentageProperties{Rate: "10", FreeUnitsPerEvents: intPtr(3), FreeUnitsPerTotalAggregation: "120"},
+			usage:      billing.Usage{Units: rat("300"), Events: rats("100", "100", "100")},
+			// 80 + 100 paid units
+			wantAmount: "18",
+			wantUnits:  "180",
+			wantEvents: 2,
+		},
+		{
+			name: "Percentage with per-transaction amounts",
+			properties: subrow.PercentageProperties{
+				Rate:                    "1",
+				FixedAmount:             "0.5",
+				PerTransactionMinAmount: "1",
+				PerTransactionMaxAmount: "5",
+			},
+			usage: billing.Usage{Units: rat("1020"), Events: rats("10", "210", "800")},
+			// max(0.6, 1) + 2.6 + min(8.5, 5)
+			wantAmount: "8.6",
+			wantUnits:  "1020",
+			wantEvents: 3,
+		},
+		{
+			name:       "Volume in the first tier",
+			properties: volume,
+			usage:      billing.Usage{Units: rat("100")},
+			wantAmount: "201",
+			wantUnits:  "100",
+		},
+		{
+			name:       "Volume in the last tier",
+			properties: volume,
+			usage:      billing.Usage{Units: rat("150")},
+			wantAmount: "235",
+			wantUnits:  "150",
+		},
+		{
+			name:       "Dynamic",
+			properties: subrow.DynamicProperties{},
+			usage:      billing.Usage{Units: rat("3"), DynamicAmount: rat("12.345")},
+			wantAmount: "12.345",
+			wantUnits:  "3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			fee, err := billing.PriceUsage(tt.properties, tt.usage)
+			c.Assert(err, qt.IsNil)
+			c.Assert(fee.Amount.Cmp(rat(tt.wantAmount)), qt.Equals, 0, qt.Commentf("amount %s", fee.Amount.FloatString(6)))
+			c.Assert(fee.Units.Cmp(rat(tt.wantUnits)), qt.Equals, 0, qt.Commentf("units %s", fee.Units.FloatString(6)))
+			c.Assert(fee.Events, qt.Equals, tt.wantEvents)
+		})
+	}
+}
+
+func TestPriceUsageErrors(t *testing.T) {
+	t.Run("When the properties are invalid", func(t *testing.T) {
+		c := qt.New(t)
+
+		_, err := billing.PriceUsage(subrow.PackageProperties{Amount: "10"}, billing.Usage{Units: rat("1")})
+		c.Assert(err, qt.ErrorMatches, `billing: invalid package charge properties: .*invalid_package_size.*`)
+	})
+
+	t.Run("When a percentage charge has no events", func(t *testing.T) {
+		c := qt.New(t)
+
+		_, err := billing.PriceUsage(subrow.PercentageProperties{Rate: "1", FixedAmount: "1"}, billing.Usage{Units: rat("1")})
+		c.Assert(err, qt.ErrorMatches, `billing: percentage charge needs Usage.Events .*`)
+	})
+}
+
+func TestCalculateFee(t *testing.T) {
+	t.Run("When the fee is below the minimum amount", func(t *testing.T) {
+		c := qt.New(t)
+
+		charge := &subrow.Charge{}
+		err := json.Unmarshal([]byte(`{"charge_model":"standard","min_amount_cents":1000,"properties":{"amount":"0.333"}}`), charge)
+		c.Assert(err, qt.IsNil)
+
+		fee, err := billing.CalculateFee(charge, billing.Usage{Units: rat("10")})
+		c.Assert(err, qt.IsNil)
+		c.Assert(fee.Amount.Cmp(rat("3.33")), qt.Equals, 0)
+		c.Assert(fee.TrueUpAmount.Cmp(rat("6.67")), qt.Equals, 0)
+		c.Assert(fee.TotalCents(), qt.Equals, int64(1000))
+	})
+
+	t.Run("When the charge only has untyped properties", func(t *testing.T) {
+		c := qt.New(t)
+
+		charge := &subrow.Charge{
+			ChargeModel: subrow.PackageChargeModel,
+			Properties:  map[string]interface{}{"amount": "2.5", "free_units": 0, "package_size": 10},
+		}
+
+		fee, err := billing.CalculateFee(charge, billing.Usage{Units: rat("25")})
+		c.Assert(err, qt.IsNil)
+		c.Assert(fee.TotalCents(), qt.Equals, int64(750))
+	})
+
+	t.Run("When pricing a filter", func(t *testing.T) {
+		c := qt.New(t)
+
+		charge := &subrow.Charge{ChargeModel: subrow.StandardChargeModel}
+		filter := &subrow.ChargeFilter{Properties: map[string]interface{}{"amount": "0.2"}}
+
+		fee, err := billing.CalculateFilterFee(charge, filter, billing.Usage{Units: rat("7")})
+		c.Assert(err, qt.IsNil)
+		c.Assert(fee.TotalCents(), qt.Equals, int64(140))
+	})
+}
+
+func TestCents(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(billing.Cents(rat("12.345")), qt.Equals, int64(1235))
+	c.Assert(billing.Cents(rat("12.344")), qt.Equals, int64(1234))
+	c.Assert(billing.Cents(rat("-0.005")), qt.Equals, int64(-1))
+	c.Assert(billing.Cents(rat("1/3")), qt.Equals, int64(33))
+}
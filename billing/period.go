@@ -0,0 +1,369 @@
+package billing
+
+import (
+	"fmt"
+	"iter"
+	"math/big"
+	"time"
+
+	subrow "github.com/subrowio/subrow-go-client"
+)
+
+// Period is a billing period, from Start, inclusive, to End, exclusive.
+type Period struct {
+	Start time.Time
+	End   time.Time
+	// FullStart and FullEnd bound the whole period Start..End is part of.
+	// FullStart is before Start for the first period of a subscription
+	// started in the middle of a calendar period, or of a day, and FullEnd
+	// after End for a period cut short by a termination.
+	FullStart time.Time
+	FullEnd   time.Time
+}
+
+// ToDatetime returns the last second of the period, the way the API reports
+// the end of billing periods.
+func (p Period) ToDatetime() time.Time {
+	return p.End.Add(-time.Second)
+}
+
+// Contains reports whether t is in the period.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// Days returns the number of days billed in the period. A day started counts
+// as a whole.
+func (p Period) Days() int {
+	return billedDays(p.Start, p.End)
+}
+
+// FullDays returns the number of days of the whole period, the base of
+// proration.
+func (p Period) FullDays() int {
+	return days(p.FullStart, p.FullEnd)
+}
+
+// Schedule computes the billing periods of a subscription. Periods start at
+// midnight in the timezone of the customer, so they follow daylight saving
+// time changes, and anniversary periods anchored on a day missing from a
+// month end on the last day of that month.
+type Schedule struct {
+	interval           subrow.PlanInterval
+	billingTime        subrow.BillingTime
+	billChargesMonthly bool
+	start              time.Time
+	anchor             time.Time
+	location           *time.Location
+}
+
+// NewSchedule returns the schedule of subscription to plan. The timezone is
+// that of customer, which may be nil for UTC. Subscriptions without a billing
+// time are billed on calendar periods, like the API does.
+func NewSchedule(plan *subrow.Plan, subscription *subrow.Subscription, customer *subrow.Customer) (*Schedule, error) {
+	location, err := CustomerLocation(customer)
+	if err != nil {
+		return nil, err
+	}
+
+	var start time.Time
+	switch {
+	case subscription.SubscriptionAt != nil:
+		start = *subscription.SubscriptionAt
+	case subscription.StartedAt != nil:
+		start = *subscription.StartedAt
+	default:
+		return nil, errNoSubscriptionStart
+	}
+
+	billingTime := subscription.BillingTime
+	if billingTime == "" {
+		billingTime = subrow.Calendar
+	}
+
+	return newSchedule(plan.Interval, billingTime, plan.BillChargesMonthly, start.In(location))
+}
+
+func newSchedule(interval subrow.PlanInterval, billingTime subrow.BillingTime, billChargesMonthly bool, start time.Time) (*Schedule, error) {
+	switch interval {
+	case subrow.PlanWeekly, subrow.PlanMonthly, subrow.PlanQuarterly, subrow.PlanYearly:
+	default:
+		return nil, fmt.Errorf("billing: unsupported plan interval %q", interval)
+	}
+
+	s := &Schedule{
+		interval:           interval,
+		billingTime:        billingTime,
+		billChargesMonthly: billChargesMonthly,
+		start:              start,
+		location:           start.Location(),
+	}
+	switch billingTime {
+	case subrow.Anniversary:
+		s.anchor = midnight(start)
+	case subrow.Calendar:
+		s.anchor = calendarStart(interval, start)
+	default:
+		return nil, fmt.Errorf("billing: unsupported billing time %q", billingTime)
+	}
+
+	return s, nil
+}
+
+// CustomerLocation returns the timezone billing periods of customer follow:
+// its applicable timezone, else its own, else UTC.
+func CustomerLocation(customer *subrow.Customer) (*time.Location, error) {
+	name := ""
+	if customer != nil {
+		name = customer.ApplicableTimezone
+		if name == "" {
+			name = customer.Timezone
+		}
+	}
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("billing: invalid timezone: %w", err)
+	}
+
+	return location, nil
+}
+
+// PeriodAt returns the billing period containing t, the first one when t is
+// before the subscription started. Its start and end are the
+// CurrentBillingPeriodStartedAt and CurrentBillingPeriodEndingAt of the
+// subscription at t.
+func (s *Schedule) PeriodAt(t time.Time) Period {
+	t = t.In(s.location)
+
+	k := max(s.estimate(t), 0)
+	for k > 0 && s.boundary(k).After(t) {
+		k--
+	}
+	for !s.boundary(k + 1).After(t) {
+		k++
+	}
+
+	return s.period(k)
+}
+
+// Periods returns the billing periods from the start of the subscription,
+// endlessly.
+func (s *Schedule) Periods() iter.Seq[Period] {
+	return func(yield func(Period) bool) {
+		for k := 0; ; k++ {
+			if !yield(s.period(k)) {
+				return
+			}
+		}
+	}
+}
+
+// ChargesPeriodAt returns the period the charges are billed on at t. It is
+// the billing period, or its month when the plan bills its charges monthly.
+func (s *Schedule) ChargesPeriodAt(t time.Time) Period {
+	period := s.PeriodAt(t)
+	if !s.billChargesMonthly || s.interval == subrow.PlanWeekly || s.interval == subrow.PlanMonthly {
+		return period
+	}
+
+	monthly := *s
+	monthly.interval = subrow.PlanMonthly
+	if s.billingTime == subrow.Calendar {
+		monthly.anchor = calendarStart(subrow.PlanMonthly, s.start)
+	}
+
+	return monthly.PeriodAt(t)
+}
+
+func (s *Schedule) period(k int) Period {
+	if k == 0 {
+		end := s.boundary(1)
+		return Period{Start: s.start, End: end, FullStart: s.anchor, FullEnd: end}
+	}
+
+	start, end := s.boundary(k), s.boundary(k+1)
+	return Period{Start: start, End: end, FullStart: start, FullEnd: end}
+}
+
+// boundary returns the start of the k-th period, for k > 0.
+func (s *Schedule) boundary(k int) time.Time {
+	return addInterval(s.anchor, s.interval, k)
+}
+
+// estimate returns about how many periods separate t from the anchor.
+func (s *Schedule) estimate(t time.Time) int {
+	months := (t.Year()-s.anchor.Year())*12 + int(t.Month()-s.anchor.Month())
+	switch s.interval {
+	case subrow.PlanWeekly:
+		return days(s.anchor, t) / 7
+	case subrow.PlanQuarterly:
+		return months / 3
+	case subrow.PlanYearly:
+		return months / 12
+	default:
+		return months
+	}
+}
+
+// Proration is what a subscription owes when its plan changes, or when it is
+// terminated, during a billing period. Amounts are in the major unit of the
+// currency.
+type Proration struct {
+	// At is when the old plan stops: the time of the change for upgrades
+	// and terminations, the end of the billing period for downgrades.
+	At time.Time
+	// Upgrade reports whether the new plan costs as much or more per year.
+	Upgrade bool
+	// Period is the billing period of the old plan that At ends.
+	Period Period
+	// Fee is the fee of the old plan from the start of Period to At, due
+	// when the plan is paid in arrears.
+	Fee *big.Rat
+	// Credit is the fee of the old plan from At to the end of Period,
+	// credited back when the plan was paid in advance.
+	Credit *big.Rat
+	// NewPlanFee is the fee of the new plan from At to the end of its
+	// billing period. The day of At is billed on both plans.
+	NewPlanFee *big.Rat
+}
+
+// Terminate returns the proration of the subscription to plan terminated at.
+func (s *Schedule) Terminate(plan *subrow.Plan, at time.Time) *Proration {
+	period := s.PeriodAt(at)
+
+	return s.prorate(plan, period, at.In(s.location))
+}
+
+// ChangePlan returns the proration of a change from oldPlan to newPlan at.
+// Upgrades apply at once, downgrades at the end of the billing period.
+func (s *Schedule) ChangePlan(oldPlan *subrow.Plan, newPlan *subrow.Plan, at time.Time) (*Proration, error) {
+	period := s.PeriodAt(at)
+	at = at.In(s.location)
+
+	upgrade := yearlyAmountCents(newPlan) >= yearlyAmountCents(oldPlan)
+	if !upgrade {
+		at = period.End
+	}
+	proration := s.prorate(oldPlan, period, at)
+	proration.Upgrade = upgrade
+
+	next, err := newSchedule(newPlan.Interval, s.billingTime, newPlan.BillChargesMonthly, s.start)
+	if err != nil {
+		return nil, err
+	}
+	newPeriod := next.PeriodAt(at)
+	proration.NewPlanFee = prorateAmount(newPlan.AmountCents, days(at, newPeriod.End), newPeriod.FullDays())
+
+	return proration, nil
+}
+
+func (s *Schedule) prorate(plan *subrow.Plan, period Period, at time.Time) *Proration {
+	used := billedDays(period.Start, at)
+	full := period.FullDays()
+	proration := &Proration{At: at, Period: period, Fee: new(big.Rat), Credit: new(big.Rat), NewPlanFee: new(big.Rat)}
+	if plan.PayInAdvance {
+		proration.Credit = prorateAmount(plan.AmountCents, period.Days()-used, full)
+	} else {
+		proration.Fee = prorateAmount(plan.AmountCents, used, full)
+	}
+
+	return proration
+}
+
+// prorateAmount returns amountCents, in the major unit, for n days out of
+// full.
+func prorateAmount(amountCents int, n int, full int) *big.Rat {
+	amount := big.NewRat(int64(amountCents), 100)
+	if full <= 0 || n >= full {
+		return amount
+	}
+
+	return amount.Mul(amount, big.NewRat(int64(max(n, 0)), int64(full)))
+}
+
+func yearlyAmountCents(plan *subrow.Plan) int {
+	switch plan.Interval {
+	case subrow.PlanWeekly:
+		return plan.AmountCents * 52
+	case subrow.PlanMonthly:
+		return plan.AmountCents * 12
+	case subrow.PlanQuarterly:
+		return plan.AmountCents * 4
+	default:
+		return plan.AmountCents
+	}
+}
+
+// addInterval adds n intervals to t. Days of the month past the end of the
+// resulting month are clamped to its last day, so that a period anchored on
+// January 31st ends on February 28th, then March 31st.
+func addInterval(t time.Time, interval subrow.PlanInterval, n int) time.Time {
+	switch interval {
+	case subrow.PlanWeekly:
+		return t.AddDate(0, 0, 7*n)
+	case subrow.PlanQuarterly:
+		return addMonths(t, 3*n)
+	case subrow.PlanYearly:
+		return addMonths(t, 12*n)
+	default:
+		return addMonths(t, n)
+	}
+}
+
+func addMonths(t time.Time, n int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	hour, minute, sec := t.Clock()
+
+	return time.Date(first.Year(), first.Month(), min(day, last), hour, minute, sec, t.Nanosecond(), t.Location())
+}
+
+// calendarStart returns the start of the calendar period containing t: the
+// Monday of its week, or the first day of its month, quarter or year.
+func calendarStart(interval subrow.PlanInterval, t time.Time) time.Time {
+	year, month, day := t.Date()
+	switch interval {
+	case subrow.PlanWeekly:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case subrow.PlanQuarterly:
+		return time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, t.Location())
+	case subrow.PlanYearly:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	}
+}
+
+func midnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// days returns the number of calendar days from the day of from to the day of
+// to, regardless of daylight saving time changes in between.
+func days(from time.Time, to time.Time) int {
+	date := func(t time.Time) time.Time {
+		year, month, day := t.Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	return int(date(to).Sub(date(from)).Hours() / 24)
+}
+
+// billedDays returns the days from from to to, counting the day of to when it
+// has started.
+func billedDays(from time.Time, to time.Time) int {
+	n := days(from, to)
+	if to.After(midnight(to)) {
+		n++
+	}
+
+	return n
+}
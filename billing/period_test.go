@@ -0,0 +1,285 @@
+package billing_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	subrow "github.com/subrowio/subrow-go-client"
+	"github.com/subrowio/subrow-go-client/billing"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestSchedulePeriodAt(t *testing.T) {
+	tests := []struct {
+		name        string
+		interval    subrow.PlanInterval
+		billingTime subrow.BillingTime
+		start       time.Time
+		at          time.Time
+		want        billing.Period
+		days        int
+		fullDays    int
+	}{
+		{
+			name:        "When a calendar weekly subscription starts mid-week",
+			interval:    subrow.PlanWeekly,
+			billingTime: subrow.Calendar,
+			start:       date(2025, 1, 17),
+			at:          date(2025, 1, 18),
+			want:        billing.Period{Start: date(2025, 1, 17), End: date(2025, 1, 20), FullStart: date(2025, 1, 13), FullEnd: date(2025, 1, 20)},
+			days:        3,
+			fullDays:    7,
+		},
+		{
+			name:        "When a calendar weekly period is on Monday",
+			interval:    subrow.PlanWeekly,
+			billingTime: subrow.Calendar,
+			start:       date(2025, 1, 17),
+			at:          date(2025, 1, 22),
+			want:        billing.Period{Start: date(2025, 1, 20), End: date(2025, 1, 27), FullStart: date(2025, 1, 20), FullEnd: date(2025, 1, 27)},
+			days:        7,
+			fullDays:    7,
+		},
+		{
+			name:        "When a calendar monthly period is in February",
+			interval:    subrow.PlanMonthly,
+			billingTime: subrow.Calendar,
+			start:       date(2025, 1, 17),
+			at:          date(2025, 2, 15),
+			want:        billing.Period{Start: date(2025, 2, 1), End: date(2025, 3, 1), FullStart: date(2025, 2, 1), FullEnd: date(2025, 3, 1)},
+			days:        28,
+			fullDays:    28,
+		},
+		{
+			name:        "When a calendar quarterly period is the second quarter",
+			interval:    subrow.PlanQuarterly,
+			billingTime: subrow.Calendar,
+			start:       date(2025, 1, 17),
+			at:          date(2025, 5, 1),
+			want:        billing.Period{Start: date(2025, 4, 1), End: date(2025, 7, 1), FullStart: date(2025, 4, 1), FullEnd: date(2025, 7, 1)},
+			days:        91,
+			fullDays:    91,
+		},
+		{
+			name:        "When a calendar yearly subscription starts mid-year",
+			interval:    subrow.PlanYearly,
+			billingTime: subrow.Calendar,
+			start:       date(2025, 1, 17),
+			at:          date(2025, 6, 1),
+			want:        billing.Period{Start: date(2025, 1, 17), End: date(2026, 1, 1), FullStart: date(2025, 1, 1), FullEnd: date(2026, 1, 1)},
+			days:        349,
+			fullDays:    365,
+		},
+		{
+			name:        "When an anniversary monthly subscription starts on the 31st",
+			interval:    subrow.PlanMonthly,
+			billingTime: subrow.Anniversary,
+			start:       date(2025, 1, 31),
+			at:          date(2025, 3, 15),
+			want:        billing.Period{Start: date(2025, 2, 28), End: date(2025, 3, 31), FullStart: date(2025, 2, 28), FullEnd: date(2025, 3, 31)},
+			days:        31,
+			fullDays:    31,
+		},
+		{
+			name:        "When an anniversary yearly subscription starts on February 29th",
+			interval:    subrow.PlanYearly,
+			billingTime: subrow.Anniversary,
+			start:       date(2024, 2, 29),
+			at:          date(2025, 3, 1),
+			want:        billing.Period{Start: date(2025, 2, 28), End: date(2026, 2, 28), FullStart: date(2025, 2, 28), FullEnd: date(2026, 2, 28)},
+			days:        365,
+			fullDays:    365,
+		},
+		{
+			name:        "When t is before the subscription started",
+			interval:    subrow.PlanQuarterly,
+			billingTime: subrow.Anniversary,
+			start:       date(2025, 1, 17),
+			at:          date(2024, 12, 1),
+			want:        billing.Period{Start: date(2025, 1, 17), End: date(2025, 4, 17), FullStart: date(2025, 1, 17), FullEnd: date(2025, 4, 17)},
+			days:        90,
+			fullDays:    90,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			schedule, err := billing.NewSchedule(
+				&subrow.Plan{Interval: test.interval},
+				&subrow.Subscription{BillingTime: test.billingTime, SubscriptionAt: timePtr(test.start)},
+				nil,
+			)
+			c.Assert(err, qt.IsNil)
+
+			period := schedule.PeriodAt(test.at)
+			c.Assert(period, qt.DeepEquals, test.want)
+			c.Assert(period.Days(), qt.Equals, test.days)
+			c.Assert(period.FullDays(), qt.Equals, test.fullDays)
+		})
+	}
+}
+
+func TestSchedulePeriods(t *testing.T) {
+	c := qt.New(t)
+
+	schedule, err := billing.NewSchedule(
+		&subrow.Plan{Interval: subrow.PlanMonthly},
+		&subrow.Subscription{BillingTime: subrow.Anniversary, SubscriptionAt: timePtr(date(2025, 1, 31))},
+		nil,
+	)
+	c.Assert(err, qt.IsNil)
+
+	var ends []time.Time
+	for period := range schedule.Periods() {
+		ends = append(ends, period.End)
+		if len(ends) == 4 {
+			break
+		}
+	}
+	c.Assert(ends, qt.DeepEquals, []time.Time{date(2025, 2, 28), date(2025, 3, 31), date(2025, 4, 30), date(2025, 5, 31)})
+}
+
+func TestScheduleTimezone(t *testing.T) {
+	c := qt.New(t)
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	c.Assert(err, qt.IsNil)
+
+	schedule, err := billing.NewSchedule(
+		&subrow.Plan{Interval: subrow.PlanMonthly},
+		&subrow.Subscription{BillingTime: subrow.Calendar, SubscriptionAt: timePtr(time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC))},
+		&subrow.Customer{Timezone: "America/New_York", ApplicableTimezone: "Europe/Paris"},
+	)
+	c.Assert(err, qt.IsNil)
+
+	// March ends an hour early in UTC, after the switch to summer time.
+	period := schedule.PeriodAt(time.Date(2025, 3, 31, 21, 30, 0, 0, time.UTC))
+	c.Assert(period.Start, qt.DeepEquals, time.Date(2025, 3, 1, 0, 0, 0, 0, paris))
+	c.Assert(period.End, qt.DeepEquals, time.Date(2025, 4, 1, 0, 0, 0, 0, paris))
+	c.Assert(period.End.Sub(period.Start), qt.Equals, 743*time.Hour)
+	c.Assert(period.Days(), qt.Equals, 31)
+	c.Assert(period.ToDatetime().UTC(), qt.Equals, time.Date(2025, 3, 31, 21, 59, 59, 0, time.UTC))
+	c.Assert(period.Contains(time.Date(2025, 3, 31, 22, 30, 0, 0, time.UTC)), qt.IsFalse)
+
+	c.Run("When the timezone is invalid", func(c *qt.C) {
+		_, err := billing.NewSchedule(
+			&subrow.Plan{Interval: subrow.PlanMonthly},
+			&subrow.Subscription{SubscriptionAt: timePtr(date(2025, 1, 1))},
+			&subrow.Customer{Timezone: "Mars/Olympus_Mons"},
+		)
+		c.Assert(err, qt.ErrorMatches, `billing: invalid timezone: .*`)
+	})
+}
+
+func TestScheduleChargesPeriodAt(t *testing.T) {
+	tests := []struct {
+		name        string
+		billingTime subrow.BillingTime
+		at          time.Time
+		want        billing.Period
+	}{
+		{
+			name:        "When the calendar subscription starts mid-month",
+			billingTime: subrow.Calendar,
+			at:          date(2025, 1, 20),
+			want:        billing.Period{Start: date(2025, 1, 17), End: date(2025, 2, 1), FullStart: date(2025, 1, 1), FullEnd: date(2025, 2, 1)},
+		},
+		{
+			name:        "When the calendar period is a later month",
+			billingTime: subrow.Calendar,
+			at:          date(2025, 6, 15),
+			want:        billing.Period{Start: date(2025, 6, 1), End: date(2025, 7, 1), FullStart: date(2025, 6, 1), FullEnd: date(2025, 7, 1)},
+		},
+		{
+			name:        "When the subscription is billed on its anniversary",
+			billingTime: subrow.Anniversary,
+			at:          date(2025, 3, 1),
+			want:        billing.Period{Start: date(2025, 2, 17), End: date(2025, 3, 17), FullStart: date(2025, 2, 17), FullEnd: date(2025, 3, 17)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			schedule, err := billing.NewSchedule(
+				&subrow.Plan{Interval: subrow.PlanYearly, BillChargesMonthly: true},
+				&subrow.Subscription{BillingTime: test.billingTime, SubscriptionAt: timePtr(date(2025, 1, 17))},
+				nil,
+			)
+			c.Assert(err, qt.IsNil)
+			c.Assert(schedule.ChargesPeriodAt(test.at), qt.DeepEquals, test.want)
+		})
+	}
+}
+
+func TestScheduleProration(t *testing.T) {
+	subscription := &subrow.Subscription{BillingTime: subrow.Calendar, SubscriptionAt: timePtr(date(2025, 1, 1))}
+	at := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	march := billing.Period{Start: date(2025, 3, 1), End: date(2025, 4, 1), FullStart: date(2025, 3, 1), FullEnd: date(2025, 4, 1)}
+
+	t.Run("When a plan paid in arrears is terminated", func(t *testing.T) {
+		c := qt.New(t)
+
+		plan := &subrow.Plan{Interval: subrow.PlanMonthly, AmountCents: 3100}
+		schedule, err := billing.NewSchedule(plan, subscription, nil)
+		c.Assert(err, qt.IsNil)
+
+		proration := schedule.Terminate(plan, at)
+		c.Assert(proration.Period, qt.DeepEquals, march)
+		// 10 of the 31 days, the day of the termination included.
+		c.Assert(proration.Fee.Cmp(rat("10")), qt.Equals, 0)
+		c.Assert(proration.Credit.Sign(), qt.Equals, 0)
+	})
+
+	t.Run("When a plan paid in advance is terminated", func(t *testing.T) {
+		c := qt.New(t)
+
+		plan := &subrow.Plan{Interval: subrow.PlanMonthly, AmountCents: 3100, PayInAdvance: true}
+		schedule, err := billing.NewSchedule(plan, subscription, nil)
+		c.Assert(err, qt.IsNil)
+
+		proration := schedule.Terminate(plan, at)
+		c.Assert(proration.Fee.Sign(), qt.Equals, 0)
+		c.Assert(proration.Credit.Cmp(rat("21")), qt.Equals, 0)
+	})
+
+	t.Run("When the plan is upgraded", func(t *testing.T) {
+		c := qt.New(t)
+
+		oldPlan := &subrow.Plan{Interval: subrow.PlanMonthly, AmountCents: 3100}
+		schedule, err := billing.NewSchedule(oldPlan, subscription, nil)
+		c.Assert(err, qt.IsNil)
+
+		proration, err := schedule.ChangePlan(oldPlan, &subrow.Plan{Interval: subrow.PlanMonthly, AmountCents: 6200}, at)
+		c.Assert(err, qt.IsNil)
+		c.Assert(proration.Upgrade, qt.IsTrue)
+		c.Assert(proration.At, qt.Equals, at)
+		c.Assert(proration.Fee.Cmp(rat("10")), qt.Equals, 0)
+		c.Assert(proration.NewPlanFee.Cmp(rat("44")), qt.Equals, 0)
+	})
+
+	t.Run("When the plan is downgraded", func(t *testing.T) {
+		c := qt.New(t)
+
+		oldPlan := &subrow.Plan{Interval: subrow.PlanMonthly, AmountCents: 3100, PayInAdvance: true}
+		schedule, err := billing.NewSchedule(oldPlan, subscription, nil)
+		c.Assert(err, qt.IsNil)
+
+		proration, err := schedule.ChangePlan(oldPlan, &subrow.Plan{Interval: subrow.PlanYearly, AmountCents: 30000}, at)
+		c.Assert(err, qt.IsNil)
+		c.Assert(proration.Upgrade, qt.IsFalse)
+		c.Assert(proration.At, qt.Equals, date(2025, 4, 1))
+		c.Assert(proration.Credit.Sign(), qt.Equals, 0)
+		// The rest of the calendar year: 275 of its 365 days.
+		c.Assert(proration.NewPlanFee.Cmp(big.NewRat(300*275, 365)), qt.Equals, 0)
+	})
+}
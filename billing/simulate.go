@@ -0,0 +1,981 @@
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"time"
+
+	subrow "github.com/subrowio/subrow-go-client"
+)
+
+// Simulator projects the invoices of a subscription to a plan from a stream of
+// events, without calling the API.
+//
+// It bills the subscription fee in advance or in arrears, prorated for a
+// partial first calendar period, the trial period and a termination, the
+// charges in arrears, monthly when the plan bills them so, pay in advance
+// charges on each event, the minimum commitment true-up, progressive billing
+// on usage thresholds, coupons and taxes. Credit notes for the unused part of
+// fees paid in advance and plan changes are not simulated.
+type Simulator struct {
+	Plan         *subrow.Plan
+	Subscription *subrow.Subscription
+	// Customer sets the timezone of the billing periods, and its taxes are
+	// the default taxes. It may be nil.
+	Customer *subrow.Customer
+	// BillableMetrics are the metrics of the charges of the plan.
+	BillableMetrics []subrow.BillableMetric
+	// Coupons are applied, in order, to the subscription invoices.
+	Coupons []subrow.AppliedCoupon
+	// Taxes apply to the fees without taxes of their own, of the plan or of
+	// the customer, like the taxes of the organization.
+	Taxes []subrow.Tax
+}
+
+// EventSeq returns a stream of events from a slice.
+func EventSeq(events []subrow.Event) iter.Seq2[subrow.Event, error] {
+	return func(yield func(subrow.Event, error) bool) {
+		for _, event := range events {
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Run returns the invoices issued from..to, both inclusive, given the events
+// of the subscription. The subscription is simulated from its start, so that
+// coupons and usage thresholds are consumed by earlier invoices.
+func (s *Simulator) Run(from time.Time, to time.Time, events iter.Seq2[subrow.Event, error]) ([]subrow.Invoice, error) {
+	run, err := s.newRun()
+	if err != nil {
+		return nil, err
+	}
+
+	for event, err := range events {
+		if err != nil {
+			return nil, err
+		}
+		if event.Timestamp.After(to) {
+			continue
+		}
+		if event.ExternalSubscriptionID != "" && s.Subscription.ExternalID != "" && event.ExternalSubscriptionID != s.Subscription.ExternalID {
+			continue
+		}
+		run.events = append(run.events, event)
+	}
+	slices.SortStableFunc(run.events, func(a, b subrow.Event) int { return a.Timestamp.Compare(b.Timestamp) })
+
+	if err := run.simulate(to); err != nil {
+		return nil, err
+	}
+
+	invoices := make([]subrow.Invoice, 0, len(run.invoices))
+	for _, invoice := range run.invoices {
+		if !invoice.issuedAt.Before(from) && !invoice.issuedAt.After(to) {
+			invoices = append(invoices, invoice.Invoice)
+		}
+	}
+
+	return invoices, nil
+}
+
+type simulation struct {
+	*Simulator
+	schedule   *Schedule
+	start      time.Time
+	trialEnd   time.Time
+	terminated time.Time
+	charges    []*simulatedCharge
+	thresholds []subrow.UsageThreshold
+	recurring  *subrow.UsageThreshold
+	coupons    []subrow.AppliedCoupon
+	events     []subrow.Event
+	invoices   []issuedInvoice
+
+	// lifetimeUsage is the usage of the closed periods, in cents.
+	lifetimeUsage int64
+	nextThreshold int
+	// buckets are those of the last charges period, and cursor the index of
+	// the first event after it.
+	buckets map[*simulatedCharge][]*bucket
+	cursor  int
+}
+
+type simulatedCharge struct {
+	charge     *subrow.Charge
+	metric     *subrow.BillableMetric
+	properties subrow.ChargeProperties
+	filters    []simulatedFilter
+	taxes      []subrow.Tax
+}
+
+type simulatedFilter struct {
+	filter     *subrow.ChargeFilter
+	values     map[string][]string
+	properties subrow.ChargeProperties
+}
+
+type issuedInvoice struct {
+	subrow.Invoice
+	issuedAt time.Time
+}
+
+// draftFee is a fee before coupons and taxes are allocated.
+type draftFee struct {
+	fee    subrow.Fee
+	amount *big.Rat
+	taxes  []subrow.Tax
+}
+
+var errNoSubscriptionStart = errors.New("billing: subscription has neither SubscriptionAt nor StartedAt")
+
+func (s *Simulator) newRun() (*simulation, error) {
+	if s.Plan == nil || s.Subscription == nil {
+		return nil, errors.New("billing: simulator needs a plan and a subscription")
+	}
+
+	schedule, err := NewSchedule(s.Plan, s.Subscription, s.Customer)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &simulation{Simulator: s, schedule: schedule, start: schedule.start, coupons: slices.Clone(s.Coupons)}
+	// Whole trial days are counted on the wall clock of the customer, so the
+	// trial ends at the time of day it started, across daylight saving time
+	// changes.
+	trialDays := math.Floor(float64(s.Plan.TrialPeriod))
+	run.trialEnd = run.start.AddDate(0, 0, int(trialDays)).
+		Add(time.Duration((float64(s.Plan.TrialPeriod) - trialDays) * float64(24*time.Hour)))
+	if s.Subscription.TrialEndedAt != nil {
+		run.trialEnd = *s.Subscription.TrialEndedAt
+	}
+	switch {
+	case s.Subscription.TerminatedAt != nil:
+		run.terminated = s.Subscription.TerminatedAt.In(schedule.location)
+	case s.Subscription.EndingAt != nil:
+		run.terminated = s.Subscription.EndingAt.In(schedule.location)
+	}
+
+	for i := range s.Plan.Charges {
+		charge, err := s.simulatedCharge(&s.Plan.Charges[i])
+		if err != nil {
+			return nil, err
+		}
+		run.charges = append(run.charges, charge)
+	}
+
+	for _, threshold := range s.Plan.UsageThresholds {
+		if threshold.Recurring {
+			run.recurring = &threshold
+			continue
+		}
+		run.thresholds = append(run.thresholds, threshold)
+	}
+	slices.SortFunc(run.thresholds, func(a, b subrow.UsageThreshold) int { return a.AmountCents - b.AmountCents })
+
+	return run, nil
+}
+
+func (s *Simulator) simulatedCharge(charge *subrow.Charge) (*simulatedCharge, error) {
+	i := slices.IndexFunc(s.BillableMetrics, func(m subrow.BillableMetric) bool {
+		if charge.BillableMetricCode != "" {
+			return m.Code == charge.BillableMetricCode
+		}
+		return m.SubrowID == charge.SubrowBillableMetricID
+	})
+	if i < 0 {
+		return nil, fmt.Errorf("billing: no billable metric for charge %s %s", charge.SubrowID, charge.BillableMetricCode)
+	}
+
+	sc := &simulatedCharge{charge: charge, metric: &s.BillableMetrics[i], properties: charge.TypedProperties, taxes: s.taxes(charge.Taxes)}
+	if sc.properties == nil {
+		var err error
+		if sc.properties, err = decodeProperties(charge.ChargeModel, charge.Properties); err != nil {
+			return nil, err
+		}
+	}
+
+	for j := range charge.Filters {
+		filter := simulatedFilter{filter: &charge.Filters[j], values: filterValues(charge.Filters[j].Values), properties: charge.Filters[j].TypedProperties}
+		if filter.properties == nil {
+			var err error
+			if filter.properties, err = decodeProperties(charge.ChargeModel, charge.Filters[j].Properties); err != nil {
+				return nil, err
+			}
+		}
+		sc.filters = append(sc.filters, filter)
+	}
+
+	return sc, nil
+}
+
+// taxes returns own if set, else the taxes of the plan, of the customer or
+// the default taxes of the simulator.
+func (s *Simulator) taxes(own []subrow.Tax) []subrow.Tax {
+	switch {
+	case len(own) > 0:
+		return own
+	case len(s.Plan.Taxes) > 0:
+		return s.Plan.Taxes
+	case s.Customer != nil && len(s.Customer.Taxes) > 0:
+		return s.Customer.Taxes
+	default:
+		return s.Taxes
+	}
+}
+
+// periodTotals are the amounts of a billing period that the minimum
+// commitment applies to, over its charges periods.
+type periodTotals struct {
+	usage          *big.Rat
+	advanceCharges *big.Rat
+}
+
+func (r *simulation) simulate(to time.Time) error {
+	if !r.terminated.IsZero() && !r.terminated.After(r.start) {
+		return nil
+	}
+
+	p := r.schedule.PeriodAt(r.start)
+	if r.Plan.PayInAdvance {
+		var fees []*draftFee
+		if fee := r.subscriptionFee(p); fee != nil {
+			fees = append(fees, fee)
+		}
+		r.issue(subrow.SubscriptionInvoiceType, subrow.BillingPeriodSubscriptionStarting, p, p, p.Start, fees, 0, true)
+	}
+
+	for !p.Start.After(to) {
+		// A termination on the end of p terminates p, rather than opening
+		// an empty period.
+		next := r.schedule.PeriodAt(p.End)
+		terminating := !r.terminated.IsZero() && !r.terminated.After(p.End)
+		if terminating {
+			p.End = r.terminated
+		}
+
+		totals := &periodTotals{usage: new(big.Rat), advanceCharges: new(big.Rat)}
+		for cp := r.schedule.ChargesPeriodAt(p.Start); ; cp = r.schedule.ChargesPeriodAt(cp.End) {
+			if cp.End.After(p.End) {
+				cp.End = p.End
+			}
+			last := !cp.End.Before(p.End)
+			if err := r.simulatePeriod(p, cp, next, last, terminating, totals); err != nil {
+				return err
+			}
+			if last {
+				break
+			}
+		}
+
+		if terminating {
+			break
+		}
+		p = next
+	}
+
+	return nil
+}
+
+// bucket aggregates the events of a charge, or of one of its filters, over a
+// period.
+type bucket struct {
+	charge     *simulatedCharge
+	filter     *simulatedFilter
+	aggregator *Aggregator
+	fee        *Fee
+	// percentage prices the events of a percentage charge on a sum metric
+	// as they are added. priced is the number of events it priced.
+	percentage *percentagePricer
+	priced     int
+}
+
+func newBucket(charge *simulatedCharge, filter *simulatedFilter, aggregator *Aggregator) (*bucket, error) {
+	b := &bucket{charge: charge, filter: filter, aggregator: aggregator}
+	if err := b.price(); err != nil {
+		return nil, err
+	}
+	if p, ok := dereference(b.properties()).(subrow.PercentageProperties); ok && aggregator.events != nil {
+		b.percentage = newPercentagePricer(p)
+	}
+
+	return b, nil
+}
+
+func (b *bucket) properties() subrow.ChargeProperties {
+	if b.filter != nil {
+		return b.filter.properties
+	}
+
+	return b.charge.properties
+}
+
+func (b *bucket) price() error {
+	usage := b.aggregator.usage()
+	if b.percentage != nil {
+		for _, value := range usage.Events[b.priced:] {
+			b.percentage.add(value)
+		}
+		b.priced = len(usage.Events)
+		b.fee = b.percentage.fee()
+		return nil
+	}
+
+	fee, err := PriceUsage(b.properties(), usage)
+	if err != nil {
+		return err
+	}
+	b.fee = fee
+
+	return nil
+}
+
+// newBuckets returns the buckets of the charges over cp, with the events of
+// the previous charges period carried over.
+func (r *simulation) newBuckets(cp Period) (map[*simulatedCharge][]*bucket, error) {
+	buckets := map[*simulatedCharge][]*bucket{}
+	for _, charge := range r.charges {
+		for i, filter := range append([]*simulatedFilter{nil}, pointers(charge.filters)...) {
+			var aggregator *Aggregator
+			if previous := r.buckets[charge]; previous != nil {
+				aggregator = previous[i].aggregator.next(cp.Start, cp.End)
+			} else {
+				var err error
+				if aggregator, err = NewAggregator(charge.metric, cp.Start, cp.End); err != nil {
+					return nil, err
+				}
+			}
+			b, err := newBucket(charge, filter, aggregator)
+			if err != nil {
+				return nil, err
+			}
+			buckets[charge] = append(buckets[charge], b)
+		}
+	}
+	r.buckets = buckets
+
+	return buckets, nil
+}
+
+// simulatePeriod bills the charges period cp of the billing period p, and p
+// itself when cp is its last charges period.
+func (r *simulation) simulatePeriod(p Period, cp Period, next Period, last bool, terminating bool, totals *periodTotals) error {
+	buckets, err := r.newBuckets(cp)
+	if err != nil {
+		return err
+	}
+
+	// The events before the cursor were added to the buckets of the previous
+	// charges periods, which carried them over.
+	var progressivelyBilled int64
+	for ; r.cursor < len(r.events); r.cursor++ {
+		event := &r.events[r.cursor]
+		if !event.Timestamp.Before(cp.End) {
+			break
+		}
+
+		for _, charge := range r.charges {
+			if event.Code != charge.metric.Code {
+				continue
+			}
+			b := buckets[charge][charge.match(event.Properties)]
+			before, beforeUnits := b.fee.Amount, b.aggregator.value()
+			if err := b.aggregator.Add(event); err != nil {
+				return err
+			}
+			if event.Timestamp.Before(cp.Start) {
+				continue
+			}
+
+			if charge.charge.PayInAdvance {
+				if err := b.price(); err != nil {
+					return err
+				}
+				amount := new(big.Rat).Sub(b.fee.Amount, before)
+				totals.advanceCharges.Add(totals.advanceCharges, amount)
+				if charge.charge.Invoiceable && amount.Sign() != 0 {
+					units := new(big.Rat).Sub(b.aggregator.value(), beforeUnits)
+					fee := r.chargeFee(b, amount, cp)
+					fee.fee.Units = decimalString(units)
+					fee.fee.EventsCount = 1
+					fee.fee.PreciseUnitAmount = ""
+					if units.Sign() != 0 {
+						fee.fee.PreciseUnitAmount = decimalString(new(big.Rat).Quo(amount, units))
+					}
+					r.issue(subrow.SubscriptionInvoiceType, subrow.BillingPeriodSInAdvanceCharge, p, cp, event.Timestamp, []*draftFee{fee}, 0, false)
+				}
+			} else if len(r.thresholds) > 0 || r.recurring != nil {
+				if err := b.price(); err != nil {
+					return err
+				}
+			}
+		}
+
+		if !event.Timestamp.Before(cp.Start) && (len(r.thresholds) > 0 || r.recurring != nil) {
+			progressivelyBilled += r.progressiveBilling(p, cp, event.Timestamp, buckets, progressivelyBilled)
+		}
+	}
+
+	var fees []*draftFee
+	var subscriptionFee *draftFee
+	if last {
+		subscriptionFee = r.subscriptionFee(p)
+		if subscriptionFee != nil && !r.Plan.PayInAdvance {
+			fees = append(fees, subscriptionFee)
+		}
+	}
+
+	usage := new(big.Rat)
+	for _, charge := range r.charges {
+		if charge.charge.PayInAdvance {
+			continue
+		}
+		chargeAmount := new(big.Rat)
+		for _, b := range buckets[charge] {
+			if err := b.price(); err != nil {
+				return err
+			}
+			fees = append(fees, r.chargeFee(b, b.fee.Amount, cp))
+			chargeAmount.Add(chargeAmount, b.fee.Amount)
+		}
+		if amount := trueUp(charge.charge, chargeAmount); amount.Sign() > 0 {
+			fee := r.chargeFee(buckets[charge][0], amount, cp)
+			fee.fee.Units = "1"
+			fee.fee.PreciseUnitAmount = decimalString(fee.amount)
+			fee.fee.EventsCount = 0
+			fee.fee.Description = "True-up"
+			fees = append(fees, fee)
+			chargeAmount.Add(chargeAmount, amount)
+		}
+		usage.Add(usage, chargeAmount)
+	}
+	r.lifetimeUsage += Cents(usage)
+	totals.usage.Add(totals.usage, usage)
+
+	reason := subrow.BillingPeriodSubscriptionPeriodic
+	if last {
+		if commitment := r.commitmentFee(p, subscriptionFee, totals); commitment != nil {
+			fees = append(fees, commitment)
+		}
+		if terminating {
+			reason = subrow.BillingPeriodSubscriptionTerminating
+		} else if r.Plan.PayInAdvance {
+			if fee := r.subscriptionFee(next); fee != nil {
+				fees = append(fees, fee)
+			}
+		}
+	}
+
+	r.issue(subrow.SubscriptionInvoiceType, reason, p, cp, cp.End, fees, progressivelyBilled, true)
+
+	return nil
+}
+
+// progressiveBilling issues a progressive billing invoice when the lifetime
+// usage reaches the next usage threshold, and returns the amount billed.
+func (r *simulation) progressiveBilling(p Period, cp Period, at time.Time, buckets map[*simulatedCharge][]*bucket, billed int64) int64 {
+	usage := new(big.Rat)
+	var fees []*draftFee
+	for _, charge := range r.charges {
+		if charge.charge.PayInAdvance {
+			continue
+		}
+		for _, b := range buckets[charge] {
+			usage.Add(usage, b.fee.Amount)
+			fees = append(fees, r.chargeFee(b, b.fee.Amount, cp))
+		}
+	}
+
+	lifetime := r.lifetimeUsage + Cents(usage)
+	var reached *subrow.UsageThreshold
+	for {
+		threshold := r.threshold(r.nextThreshold)
+		if threshold == nil || lifetime < int64(threshold.AmountCents) {
+			break
+		}
+		reached = threshold
+		r.nextThreshold++
+	}
+	if reached == nil || Cents(usage) <= billed {
+		return 0
+	}
+
+	invoice := r.issue(subrow.ProgressiveBillingInvoiceType, subrow.BillingPeriodSProgressiveBilling, p, cp, at, fees, billed, false)
+	invoice.AppliedUsageThreshold = []subrow.AppliedUsageThreshold{{
+		LifetimeUsageAmountCents: int(lifetime),
+		CreatedAt:                at,
+		UsageThreshold:           *reached,
+	}}
+
+	return int64(invoice.SubTotalExcludingTaxesAmountCents)
+}
+
+// threshold returns the i-th usage threshold, with the amount of the
+// recurring threshold added to the last fixed one as many times as needed.
+func (r *simulation) threshold(i int) *subrow.UsageThreshold {
+	if i < len(r.thresholds) {
+		return &r.thresholds[i]
+	}
+	if r.recurring == nil || r.recurring.AmountCents <= 0 {
+		return nil
+	}
+
+	threshold := *r.recurring
+	if len(r.thresholds) > 0 {
+		threshold.AmountCents += r.thresholds[len(r.thresholds)-1].AmountCents
+	}
+	threshold.AmountCents += (i - len(r.thresholds)) * r.recurring.AmountCents
+
+	return &threshold
+}
+
+// match returns the index of the bucket of an event: that of the filter
+// matching the most properties, or 0 for the charge itself.
+func (c *simulatedCharge) match(properties map[string]interface{}) int {
+	index, keys := 0, 0
+	for i, filter := range c.filters {
+		matches := len(filter.values) > keys
+		for key, values := range filter.values {
+			value, ok := properties[key]
+			if !ok || !slices.Contains(values, fmt.Sprint(value)) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			index, keys = i+1, len(filter.values)
+		}
+	}
+
+	return index
+}
+
+// subscriptionFee returns the fee of the plan for p, prorated for a partial
+// period and the trial, or nil when p is entirely in the trial.
+func (r *simulation) subscriptionFee(p Period) *draftFee {
+	paidFrom := p.Start
+	if r.trialEnd.After(paidFrom) {
+		paidFrom = r.trialEnd
+	}
+	if !paidFrom.Before(p.End) {
+		return nil
+	}
+
+	amount := prorateAmount(r.Plan.AmountCents, billedDays(paidFrom, p.End), p.FullDays())
+
+	fee := r.draftFee(subrow.FeeItem{
+		Type:               subrow.FeeItemSubscription,
+		Code:               r.Plan.Code,
+		Name:               r.Plan.Name,
+		InvoiceDisplayName: r.Plan.InvoiceDisplayName,
+		SubrowItemID:       r.Subscription.SubrowID,
+		ItemType:           subrow.FeeSubscription,
+	}, amount, p, r.taxes(nil))
+	fee.fee.PayInAdvance = r.Plan.PayInAdvance
+	fee.fee.Units = "1"
+
+	return fee
+}
+
+func (r *simulation) chargeFee(b *bucket, amount *big.Rat, p Period) *draftFee {
+	item := subrow.FeeItem{
+		Type:               subrow.FeeItemCharge,
+		Code:               b.charge.metric.Code,
+		Name:               b.charge.metric.Name,
+		InvoiceDisplayName: b.charge.charge.InvoiceDisplayName,
+		SubrowItemID:       b.charge.metric.SubrowID,
+		ItemType:           subrow.FeeBillableMetric,
+	}
+	if b.filter != nil {
+		item.FilterInvoiceDisplayName = b.filter.filter.InvoiceDisplayName
+		item.Filters = b.filter.filter.Values
+	}
+
+	units := b.aggregator.value()
+	fee := r.draftFee(item, amount, p, b.charge.taxes)
+	fee.fee.SubrowChargeID = b.charge.charge.SubrowID
+	fee.fee.PayInAdvance = b.charge.charge.PayInAdvance
+	fee.fee.Invoiceable = b.charge.charge.Invoiceable
+	fee.fee.Units = decimalString(units)
+	fee.fee.EventsCount = b.aggregator.count
+	if units.Sign() != 0 {
+		fee.fee.PreciseUnitAmount = decimalString(new(big.Rat).Quo(amount, units))
+	}
+
+	return fee
+}
+
+// commitmentFee returns the true-up of the minimum commitment of the plan,
+// prorated for a partial period, when the fees of p are below it.
+func (r *simulation) commitmentFee(p Period, subscriptionFee *draftFee, totals *periodTotals) *draftFee {
+	commitment := r.Plan.MinimumCommitment
+	if commitment == nil || commitment.AmountCents <= 0 {
+		return nil
+	}
+
+	amount := prorateAmount(commitment.AmountCents, p.Days(), p.FullDays())
+	total := new(big.Rat).Add(totals.usage, totals.advanceCharges)
+	if subscriptionFee != nil {
+		total.Add(total, subscriptionFee.amount)
+	}
+	if total.Cmp(amount) >= 0 {
+		return nil
+	}
+
+	fee := r.draftFee(subrow.FeeItem{
+		Type:               subrow.FeeItemCommitment,
+		Code:               "minimum_commitment",
+		Name:               "Minimum commitment true-up",
+		InvoiceDisplayName: commitment.InvoiceDisplayName,
+		SubrowItemID:       commitment.SubrowID,
+		ItemType:           subrow.FeeCommitment,
+	}, new(big.Rat).Sub(amount, total), p, r.taxes(commitment.Taxes))
+	fee.fee.Units = "1"
+
+	return fee
+}
+
+func (r *simulation) draftFee(item subrow.FeeItem, amount *big.Rat, p Period, taxes []subrow.Tax) *draftFee {
+	return &draftFee{
+		fee: subrow.Fee{
+			ExternalSubscriptionID: r.Subscription.ExternalID,
+			AmountCurrency:         string(r.Plan.AmountCurrency),
+			TotalAmountCurrency:    string(r.Plan.AmountCurrency),
+			FromDate:               p.Start.Format(time.RFC3339),
+			ToDate:                 p.ToDatetime().Format(time.RFC3339),
+			Item:                   item,
+		},
+		amount: new(big.Rat).Set(amount),
+		taxes:  taxes,
+	}
+}
+
+// issue finalizes fees into an invoice: coupons when withCoupons, then the
+// credit of the progressive billing invoices of the period, are deducted
+// before taxes, in proportion to the fees.
+func (r *simulation) issue(invoiceType subrow.InvoiceType, reason subrow.InvoicingReason, p Period, cp Period, at time.Time, fees []*draftFee, progressiveCredit int64, withCoupons bool) *subrow.Invoice {
+	if len(fees) == 0 {
+		return &subrow.Invoice{}
+	}
+
+	invoice := subrow.Invoice{
+		IssuingDate: at.Format(time.DateOnly),
+		InvoiceType: invoiceType,
+		Status:      subrow.InvoiceStatusDraft,
+		Currency:    r.Plan.AmountCurrency,
+		BillingPeriods: []subrow.BillingPeriod{{
+			SubrowSubscriptionId:     r.Subscription.SubrowID,
+			ExternalSubscriptionId:   r.Subscription.ExternalID,
+			SubrowPlanId:             r.Plan.SubrowID,
+			SubscriptionFromDatetime: p.Start,
+			SubscriptionToDatetime:   p.ToDatetime(),
+			ChargesFromDatetime:      cp.Start,
+			ChargesToDatetime:        cp.ToDatetime(),
+			InvoicingReason:          reason,
+		}},
+		Subscriptions: []subrow.Subscription{*r.Subscription},
+	}
+
+	var feesCents int64
+	for _, fee := range fees {
+		feesCents += Cents(fee.amount)
+	}
+	progressiveCredit = min(progressiveCredit, feesCents)
+	remaining := feesCents - progressiveCredit
+
+	var couponsCents int64
+	if withCoupons {
+		for i := range r.coupons {
+			credit := r.applyCoupon(&r.coupons[i], remaining)
+			if credit <= 0 {
+				continue
+			}
+			remaining -= credit
+			couponsCents += credit
+			invoice.Credits = append(invoice.Credits, subrow.InvoiceCredit{
+				Item: subrow.InvoiceCreditItem{
+					SubrowID: r.coupons[i].SubrowCouponID,
+					Type:     subrow.InvoiceCreditItemCoupon,
+					Code:     r.coupons[i].CouponCode,
+					Name:     r.coupons[i].CouponName,
+				},
+				SubrowItemID:   r.coupons[i].SubrowID,
+				AmountCents:    int(credit),
+				AmountCurrency: r.Plan.AmountCurrency,
+				BeforeTaxes:    true,
+			})
+		}
+	}
+
+	type taxTotal struct {
+		tax   subrow.Tax
+		base  *big.Rat
+		taxes *big.Rat
+	}
+	var totals []*taxTotal
+	for _, draft := range fees {
+		fee := draft.fee
+		fee.AmountCents = int(Cents(draft.amount))
+		fee.PreciseAmount = decimalString(draft.amount)
+
+		base := big.NewRat(int64(fee.AmountCents), 100)
+		if feesCents > 0 {
+			base.Mul(base, big.NewRat(remaining, feesCents))
+		}
+		feeTaxes := new(big.Rat)
+		for _, tax := range draft.taxes {
+			rate := taxRate(tax)
+			amount := mul(base, rate)
+			feeTaxes.Add(feeTaxes, amount)
+			fee.TaxesRate += tax.Rate
+			fee.AppliedTaxes = append(fee.AppliedTaxes, subrow.FeeAppliedTax{
+				SubrowTaxId:    tax.SubrowID,
+				TaxName:        tax.Name,
+				TaxCode:        tax.Code,
+				TaxRate:        tax.Rate,
+				TaxDescription: tax.Description,
+				AmountCents:    int(Cents(amount)),
+				AmountCurrency: r.Plan.AmountCurrency,
+			})
+
+			i := slices.IndexFunc(totals, func(t *taxTotal) bool { return t.tax.Code == tax.Code })
+			if i < 0 {
+				totals = append(totals, &taxTotal{tax: tax, base: new(big.Rat), taxes: new(big.Rat)})
+				i = len(totals) - 1
+			}
+			totals[i].base.Add(totals[i].base, base)
+			totals[i].taxes.Add(totals[i].taxes, amount)
+		}
+		fee.TaxesAmountCents = int(Cents(feeTaxes))
+		fee.TaxesPreciseAmount = decimalString(feeTaxes)
+		fee.TotalAmountCents = fee.AmountCents + fee.TaxesAmountCents
+		fee.PreciseTotalAmount = decimalString(new(big.Rat).Add(draft.amount, feeTaxes))
+		invoice.Fees = append(invoice.Fees, fee)
+	}
+
+	var taxesCents int64
+	for _, total := range totals {
+		amount := Cents(total.taxes)
+		taxesCents += amount
+		invoice.AppliedTaxes = append(invoice.AppliedTaxes, subrow.InvoiceAppliedTax{
+			SubrowTaxId:     total.tax.SubrowID,
+			TaxName:         total.tax.Name,
+			TaxCode:         total.tax.Code,
+			TaxRate:         total.tax.Rate,
+			TaxDescription:  total.tax.Description,
+			AmountCents:     int(amount),
+			AmountCurrency:  r.Plan.AmountCurrency,
+			FeesAmountCents: int(Cents(total.base)),
+		})
+	}
+
+	invoice.FeesAmountCents = int(feesCents)
+	invoice.CouponsAmountCents = int(couponsCents)
+	invoice.ProgressiveBillingCreditAmountCents = int(progressiveCredit)
+	invoice.SubTotalExcludingTaxesAmountCents = int(remaining)
+	invoice.TaxesAmountCents = int(taxesCents)
+	invoice.SubTotalIncludingTaxesAmountCents = int(remaining + taxesCents)
+	invoice.TotalAmountCents = invoice.SubTotalIncludingTaxesAmountCents
+	invoice.TotalDueAmountCents = invoice.TotalAmountCents
+
+	r.invoices = append(r.invoices, issuedInvoice{Invoice: invoice, issuedAt: at})
+
+	return &r.invoices[len(r.invoices)-1].Invoice
+}
+
+// applyCoupon returns the credit of coupon on an invoice of amount cents, and
+// consumes the coupon.
+func (r *simulation) applyCoupon(coupon *subrow.AppliedCoupon, amount int64) int64 {
+	if coupon.Status == subrow.AppliedCouponStatusTerminated || amount <= 0 {
+		return 0
+	}
+	if coupon.Frequency == subrow.CouponFrequencyRecurring {
+		if coupon.FrequencyDurationRemaining == 0 {
+			coupon.FrequencyDurationRemaining = coupon.FrequencyDuration
+		}
+		if coupon.FrequencyDurationRemaining <= 0 {
+			return 0
+		}
+	}
+
+	var credit int64
+	if coupon.PercentageRate != 0 {
+		rate := percent(strconv.FormatFloat(coupon.PercentageRate, 'f', -1, 64))
+		credit = round(mul(big.NewRat(amount, 1), rate)).Int64()
+	} else {
+		available := int64(coupon.AmountCents)
+		if coupon.Frequency != subrow.CouponFrequencyRecurring && coupon.AmountCentsRemaining > 0 {
+			available = int64(coupon.AmountCentsRemaining)
+		}
+		credit = available
+	}
+	credit = min(credit, amount)
+
+	switch coupon.Frequency {
+	case subrow.CouponFrequencyRecurring:
+		coupon.FrequencyDurationRemaining--
+		if coupon.FrequencyDurationRemaining == 0 {
+			coupon.Status = subrow.AppliedCouponStatusTerminated
+		}
+	default:
+		if coupon.PercentageRate != 0 {
+			coupon.Status = subrow.AppliedCouponStatusTerminated
+			break
+		}
+		if coupon.AmountCentsRemaining == 0 {
+			coupon.AmountCentsRemaining = coupon.AmountCents
+		}
+		coupon.AmountCentsRemaining -= int(credit)
+		if coupon.AmountCentsRemaining <= 0 {
+			coupon.Status = subrow.AppliedCouponStatusTerminated
+		}
+	}
+
+	return credit
+}
+
+// PlanFromInput builds the plan a PlanInput would create, for simulations.
+// Charges refer to their billable metric by id, and taxes are referred to by
+// code.
+func PlanFromInput(input *subrow.PlanInput, metrics []subrow.BillableMetric, taxes []subrow.Tax) (*subrow.Plan, error) {
+	findTaxes := func(codes []string) ([]subrow.Tax, error) {
+		var found []subrow.Tax
+		for _, code := range codes {
+			i := slices.IndexFunc(taxes, func(t subrow.Tax) bool { return t.Code == code })
+			if i < 0 {
+				return nil, fmt.Errorf("billing: unknown tax %q", code)
+			}
+			found = append(found, taxes[i])
+		}
+		return found, nil
+	}
+
+	plan := &subrow.Plan{
+		Name:               input.Name,
+		InvoiceDisplayName: input.InvoiceDisplayName,
+		Code:               input.Code,
+		Interval:           input.Interval,
+		Description:        input.Description,
+		AmountCents:        input.AmountCents,
+		AmountCurrency:     input.AmountCurrency,
+		PayInAdvance:       input.PayInAdvance,
+		BillChargesMonthly: input.BillChargesMonthly,
+		TrialPeriod:        input.TrialPeriod,
+	}
+
+	var err error
+	if plan.Taxes, err = findTaxes(input.TaxCodes); err != nil {
+		return nil, err
+	}
+
+	for _, chargeInput := range input.Charges {
+		i := slices.IndexFunc(metrics, func(m subrow.BillableMetric) bool { return m.SubrowID == chargeInput.BillableMetricID })
+		if i < 0 {
+			return nil, fmt.Errorf("billing: unknown billable metric %s", chargeInput.BillableMetricID)
+		}
+
+		charge := subrow.Charge{
+			SubrowBillableMetricID: metrics[i].SubrowID,
+			BillableMetricCode:     metrics[i].Code,
+			ChargeModel:            chargeInput.ChargeModel,
+			PayInAdvance:           chargeInput.PayInAdvance,
+			Invoiceable:            chargeInput.Invoiceable,
+			RegroupPaidFees:        chargeInput.RegroupPaidFees,
+			Prorated:               chargeInput.Prorated,
+			MinAmountCents:         chargeInput.MinAmountCents,
+			Properties:             chargeInput.Properties,
+			Filters:                chargeInput.Filters,
+			TypedProperties:        chargeInput.TypedProperties,
+		}
+		if chargeInput.SubrowID != nil {
+			charge.SubrowID = *chargeInput.SubrowID
+		}
+		if charge.ChargeModel == "" && charge.TypedProperties != nil {
+			charge.ChargeModel = charge.TypedProperties.ChargeModel()
+		}
+		if charge.Taxes, err = findTaxes(chargeInput.TaxCodes); err != nil {
+			return nil, err
+		}
+		plan.Charges = append(plan.Charges, charge)
+	}
+
+	if input.MinimumCommitment != nil {
+		plan.MinimumCommitment = &subrow.MinimumCommitment{
+			PlanCode:           input.Code,
+			InvoiceDisplayName: input.MinimumCommitment.InvoiceDisplayName,
+			AmountCents:        input.MinimumCommitment.AmountCents,
+			Interval:           input.Interval,
+		}
+		if plan.MinimumCommitment.Taxes, err = findTaxes(input.MinimumCommitment.TaxCodes); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, threshold := range input.UsageThresholds {
+		plan.UsageThresholds = append(plan.UsageThresholds, subrow.UsageThreshold{
+			ThresholdDisplayName: threshold.ThresholdDisplayName,
+			AmountCents:          threshold.AmountCents,
+			Recurring:            threshold.Recurring,
+		})
+	}
+
+	return plan, nil
+}
+
+// filterValues reads the values of a charge filter, decoded from JSON or set
+// by the caller.
+func filterValues(values map[string]interface{}) map[string][]string {
+	result := make(map[string][]string, len(values))
+	for key, value := range values {
+		switch v := value.(type) {
+		case []string:
+			result[key] = v
+		case []interface{}:
+			for _, item := range v {
+				result[key] = append(result[key], fmt.Sprint(item))
+			}
+		default:
+			result[key] = []string{fmt.Sprint(v)}
+		}
+	}
+
+	return result
+}
+
+func taxRate(tax subrow.Tax) *big.Rat {
+	rate, ok := new(big.Rat).SetString(strconv.FormatFloat(float64(tax.Rate), 'f', -1, 32))
+	if !ok {
+		return new(big.Rat)
+	}
+
+	return rate.Quo(rate, big.NewRat(100, 1))
+}
+
+// decimalString formats r as a decimal, with up to 10 decimals.
+func decimalString(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	s := r.FloatString(10)
+	for s[len(s)-1] == '0' {
+		s = s[:len(s)-1]
+	}
+
+	return s
+}
+
+func pointers[T any](values []T) []*T {
+	result := make([]*T, len(values))
+	for i := range values {
+		result[i] = &values[i]
+	}
+
+	return result
+}
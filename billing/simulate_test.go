@@ -0,0 +1,434 @@
+package billing_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/google/uuid"
+
+	subrow "github.com/subrowio/subrow-go-client"
+	"github.com/subrowio/subrow-go-client/billing"
+)
+
+type invoiceSummary struct {
+	IssuingDate       string
+	Type              subrow.InvoiceType
+	Reason            subrow.InvoicingReason
+	Fees              []int
+	Coupons           int
+	ProgressiveCredit int
+	Taxes             int
+	Total             int
+}
+
+func summarize(invoices []subrow.Invoice) []invoiceSummary {
+	var summaries []invoiceSummary
+	for _, invoice := range invoices {
+		summary := invoiceSummary{
+			IssuingDate:       invoice.IssuingDate,
+			Type:              invoice.InvoiceType,
+			Reason:            invoice.BillingPeriods[0].InvoicingReason,
+			Coupons:           invoice.CouponsAmountCents,
+			ProgressiveCredit: invoice.ProgressiveBillingCreditAmountCents,
+			Taxes:             invoice.TaxesAmountCents,
+			Total:             invoice.TotalAmountCents,
+		}
+		for _, fee := range invoice.Fees {
+			summary.Fees = append(summary.Fees, fee.AmountCents)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestSimulatorCalendarInAdvance(t *testing.T) {
+	c := qt.New(t)
+
+	vat := subrow.Tax{Code: "vat", Name: "VAT", Rate: 20}
+	simulator := &billing.Simulator{
+		Plan: &subrow.Plan{
+			Code:           "startup",
+			Interval:       subrow.PlanMonthly,
+			AmountCents:    3100,
+			AmountCurrency: "EUR",
+			PayInAdvance:   true,
+			Taxes:          []subrow.Tax{vat},
+			Charges: []subrow.Charge{{
+				BillableMetricCode: "api_calls",
+				TypedProperties:    subrow.StandardProperties{Amount: "0.01"},
+			}},
+		},
+		Subscription: &subrow.Subscription{
+			ExternalID:     "sub_1",
+			BillingTime:    subrow.Calendar,
+			SubscriptionAt: timePtr(time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)),
+		},
+		BillableMetrics: []subrow.BillableMetric{{Code: "api_calls", AggregationType: subrow.SumAggregation, FieldName: "calls"}},
+		Coupons: []subrow.AppliedCoupon{{
+			CouponCode:  "welcome",
+			AmountCents: 3000,
+			Frequency:   subrow.CouponFrequencyOnce,
+		}},
+	}
+	events := []subrow.Event{
+		{TransactionID: "tr_2", Code: "api_calls", ExternalSubscriptionID: "sub_1", Timestamp: time.Date(2025, 2, 3, 9, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"calls": 500.0}},
+		{TransactionID: "tr_1", Code: "api_calls", ExternalSubscriptionID: "sub_1", Timestamp: time.Date(2025, 1, 20, 9, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"calls": 1000.0}},
+		{TransactionID: "tr_3", Code: "api_calls", ExternalSubscriptionID: "sub_2", Timestamp: time.Date(2025, 1, 20, 9, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"calls": 1000.0}},
+	}
+
+	invoices, err := simulator.Run(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), billing.EventSeq(events))
+	c.Assert(err, qt.IsNil)
+	c.Assert(summarize(invoices), qt.DeepEquals, []invoiceSummary{
+		// 15 of the 31 days of January, paid with the coupon
+		{IssuingDate: "2025-01-17", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionStarting, Fees: []int{1500}, Coupons: 1500},
+		// January usage and February in advance, less the rest of the coupon
+		{IssuingDate: "2025-02-01", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionPeriodic, Fees: []int{1000, 3100}, Coupons: 1500, Taxes: 520, Total: 3120},
+		{IssuingDate: "2025-03-01", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionPeriodic, Fees: []int{500, 3100}, Taxes: 720, Total: 4320},
+	})
+
+	c.Assert(invoices[1].AppliedTaxes, qt.DeepEquals, []subrow.InvoiceAppliedTax{{
+		TaxName:         "VAT",
+		TaxCode:         "vat",
+		TaxRate:         20,
+		AmountCents:     520,
+		AmountCurrency:  "EUR",
+		FeesAmountCents: 2600,
+	}})
+	c.Assert(invoices[1].Credits[0].Item.Code, qt.Equals, "welcome")
+	c.Assert(invoices[1].BillingPeriods[0].SubscriptionFromDatetime, qt.Equals, time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC))
+	c.Assert(invoices[1].BillingPeriods[0].SubscriptionToDatetime, qt.Equals, time.Date(2025, 1, 31, 23, 59, 59, 0, time.UTC))
+	c.Assert(invoices[1].Fees[0].Units, qt.Equals, "1000")
+	c.Assert(invoices[1].Fees[0].PreciseUnitAmount, qt.Equals, "0.01")
+}
+
+func TestSimulatorAnniversaryInArrears(t *testing.T) {
+	c := qt.New(t)
+
+	storageID := uuid.New()
+	simulator := &billing.Simulator{
+		Plan: &subrow.Plan{
+			Code:              "scale",
+			Interval:          subrow.PlanMonthly,
+			AmountCents:       1000,
+			AmountCurrency:    "USD",
+			TrialPeriod:       10,
+			MinimumCommitment: &subrow.MinimumCommitment{AmountCents: 5000},
+			UsageThresholds:   []subrow.UsageThreshold{{AmountCents: 2000}},
+			Charges: []subrow.Charge{
+				{
+					SubrowBillableMetricID: storageID,
+					ChargeModel:            subrow.StandardChargeModel,
+					Properties:             map[string]interface{}{"amount": "1"},
+					Filters: []subrow.ChargeFilter{{
+						Values:     map[string]interface{}{"region": []interface{}{"eu"}},
+						Properties: map[string]interface{}{"amount": "2"},
+					}},
+				},
+				{
+					BillableMetricCode: "signups",
+					PayInAdvance:       true,
+					Invoiceable:        true,
+					TypedProperties:    subrow.StandardProperties{Amount: "5"},
+				},
+			},
+		},
+		Subscription: &subrow.Subscription{
+			BillingTime:    subrow.Anniversary,
+			SubscriptionAt: timePtr(time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)),
+		},
+		BillableMetrics: []subrow.BillableMetric{
+			{SubrowID: storageID, Code: "storage", AggregationType: subrow.SumAggregation, FieldName: "gb"},
+			{Code: "signups", AggregationType: subrow.CountAggregation},
+		},
+		Coupons: []subrow.AppliedCoupon{{
+			CouponCode:        "partner",
+			PercentageRate:    10,
+			Frequency:         subrow.CouponFrequencyRecurring,
+			FrequencyDuration: 1,
+		}},
+	}
+	events := []subrow.Event{
+		{TransactionID: "tr_1", Code: "signups", Timestamp: time.Date(2025, 1, 12, 0, 0, 0, 0, time.UTC)},
+		{TransactionID: "tr_2", Code: "storage", Timestamp: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"gb": 5.0, "region": "us"}},
+		{TransactionID: "tr_3", Code: "storage", Timestamp: time.Date(2025, 1, 25, 0, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"gb": 8.0, "region": "eu"}},
+	}
+
+	invoices, err := simulator.Run(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC), billing.EventSeq(events))
+	c.Assert(err, qt.IsNil)
+	c.Assert(summarize(invoices), qt.DeepEquals, []invoiceSummary{
+		{IssuingDate: "2025-01-12", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSInAdvanceCharge, Fees: []int{500}, Total: 500},
+		// The lifetime usage reaches 20 USD.
+		{IssuingDate: "2025-01-25", Type: subrow.ProgressiveBillingInvoiceType, Reason: subrow.BillingPeriodSProgressiveBilling, Fees: []int{500, 1600}, Total: 2100},
+		// 21 of the 31 days after the trial, the usage and the commitment
+		// true-up, less the usage already billed and 10%.
+		{IssuingDate: "2025-02-10", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionPeriodic, Fees: []int{677, 500, 1600, 1723}, ProgressiveCredit: 2100, Coupons: 240, Total: 2160},
+	})
+	c.Assert(invoices[1].AppliedUsageThreshold[0].LifetimeUsageAmountCents, qt.Equals, 2100)
+	c.Assert(invoices[2].Fees[2].Item.Filters, qt.DeepEquals, map[string]interface{}{"region": []interface{}{"eu"}})
+	c.Assert(invoices[2].Fees[3].Item.Type, qt.Equals, subrow.FeeItemCommitment)
+}
+
+func TestSimulatorRecurringMetrics(t *testing.T) {
+	c := qt.New(t)
+
+	simulator := &billing.Simulator{
+		Plan: &subrow.Plan{
+			Code:           "team",
+			Interval:       subrow.PlanMonthly,
+			AmountCents:    1000,
+			AmountCurrency: "EUR",
+			Charges: []subrow.Charge{
+				{BillableMetricCode: "seats", TypedProperties: subrow.StandardProperties{Amount: "1"}},
+				{BillableMetricCode: "storage", TypedProperties: subrow.StandardProperties{Amount: "1"}},
+			},
+		},
+		Subscription: &subrow.Subscription{
+			BillingTime:    subrow.Calendar,
+			SubscriptionAt: timePtr(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+		},
+		BillableMetrics: []subrow.BillableMetric{
+			{Code: "seats", AggregationType: subrow.SumAggregation, FieldName: "seats", Recurring: true},
+			{Code: "storage", AggregationType: subrow.WeightedSumAggregation, FieldName: "gb", Recurring: true},
+		},
+	}
+	events := []subrow.Event{
+		{TransactionID: "tr_1", Code: "storage", Timestamp: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"gb": 10.0}},
+		{TransactionID: "tr_2", Code: "seats", Timestamp: time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"seats": 5.0}},
+		{TransactionID: "tr_3", Code: "seats", Timestamp: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"seats": 3.0}},
+		{TransactionID: "tr_4", Code: "storage", Timestamp: time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"gb": -5.0}},
+	}
+
+	invoices, err := simulator.Run(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), billing.EventSeq(events))
+	c.Assert(err, qt.IsNil)
+	c.Assert(summarize(invoices), qt.DeepEquals, []invoiceSummary{
+		{IssuingDate: "2025-02-01", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionPeriodic, Fees: []int{1000, 500, 1000}, Total: 2500},
+		// The seats and storage of January are carried over: 8 seats, and
+		// 10 GB for half of February then 5 GB.
+		{IssuingDate: "2025-03-01", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionPeriodic, Fees: []int{1000, 800, 750}, Total: 2550},
+	})
+	c.Assert(invoices[1].Fees[1].EventsCount, qt.Equals, 1)
+}
+
+func TestSimulatorPercentageInAdvance(t *testing.T) {
+	c := qt.New(t)
+
+	freeEvents := 1
+	simulator := &billing.Simulator{
+		Plan: &subrow.Plan{
+			Code:           "payments",
+			Interval:       subrow.PlanMonthly,
+			AmountCurrency: "EUR",
+			Charges: []subrow.Charge{{
+				BillableMetricCode: "payments",
+				PayInAdvance:       true,
+				Invoiceable:        true,
+				TypedProperties:    subrow.PercentageProperties{Rate: "1", FixedAmount: "0.5", FreeUnitsPerEvents: &freeEvents},
+			}},
+		},
+		Subscription: &subrow.Subscription{
+			BillingTime:    subrow.Calendar,
+			SubscriptionAt: timePtr(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+		},
+		BillableMetrics: []subrow.BillableMetric{{Code: "payments", AggregationType: subrow.SumAggregation, FieldName: "amount"}},
+	}
+	events := []subrow.Event{
+		{TransactionID: "tr_1", Code: "payments", Timestamp: time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"amount": 100.0}},
+		{TransactionID: "tr_2", Code: "payments", Timestamp: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"amount": 200.0}},
+		{TransactionID: "tr_3", Code: "payments", Timestamp: time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"amount": 50.0}},
+	}
+
+	invoices, err := simulator.Run(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), billing.EventSeq(events))
+	c.Assert(err, qt.IsNil)
+	// The first payment is free.
+	c.Assert(summarize(invoices), qt.DeepEquals, []invoiceSummary{
+		{IssuingDate: "2025-01-06", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSInAdvanceCharge, Fees: []int{250}, Total: 250},
+		{IssuingDate: "2025-01-07", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSInAdvanceCharge, Fees: []int{100}, Total: 100},
+	})
+}
+
+func TestSimulatorTermination(t *testing.T) {
+	c := qt.New(t)
+
+	simulator := &billing.Simulator{
+		Plan: &subrow.Plan{
+			Code:           "startup",
+			Interval:       subrow.PlanMonthly,
+			AmountCents:    3100,
+			AmountCurrency: "EUR",
+		},
+		Subscription: &subrow.Subscription{
+			BillingTime:    subrow.Calendar,
+			SubscriptionAt: timePtr(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+			TerminatedAt:   timePtr(time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)),
+		},
+	}
+
+	invoices, err := simulator.Run(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), billing.EventSeq(nil))
+	c.Assert(err, qt.IsNil)
+	c.Assert(summarize(invoices), qt.DeepEquals, []invoiceSummary{
+		{IssuingDate: "2025-02-01", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionPeriodic, Fees: []int{3100}, Total: 3100},
+		// 10 of the 28 days of February.
+		{IssuingDate: "2025-02-10", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionTerminating, Fees: []int{1107}, Total: 1107},
+	})
+}
+
+func TestSimulatorTerminationOnPeriodEnd(t *testing.T) {
+	for _, payInAdvance := range []bool{false, true} {
+		t.Run(fmt.Sprintf("When the plan is paid in advance: %t", payInAdvance), func(t *testing.T) {
+			c := qt.New(t)
+
+			simulator := &billing.Simulator{
+				Plan: &subrow.Plan{
+					Code:           "startup",
+					Interval:       subrow.PlanMonthly,
+					AmountCents:    3100,
+					AmountCurrency: "EUR",
+					PayInAdvance:   payInAdvance,
+				},
+				Subscription: &subrow.Subscription{
+					BillingTime:    subrow.Calendar,
+					SubscriptionAt: timePtr(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+					EndingAt:       timePtr(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)),
+				},
+			}
+
+			invoices, err := simulator.Run(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), billing.EventSeq(nil))
+			c.Assert(err, qt.IsNil)
+
+			// January is billed once, and February not at all.
+			want := []invoiceSummary{
+				{IssuingDate: "2025-02-01", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionTerminating, Fees: []int{3100}, Total: 3100},
+			}
+			if payInAdvance {
+				want = []invoiceSummary{
+					{IssuingDate: "2025-01-01", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionStarting, Fees: []int{3100}, Total: 3100},
+				}
+			}
+			c.Assert(summarize(invoices), qt.DeepEquals, want)
+		})
+	}
+}
+
+func TestSimulatorTrialAcrossDST(t *testing.T) {
+	for _, test := range []struct {
+		name           string
+		subscriptionAt time.Time
+	}{{
+		name:           "When the trial starts at midnight",
+		subscriptionAt: time.Date(2025, 9, 30, 22, 0, 0, 0, time.UTC),
+	}, {
+		// The trial ends on November 1st at 00:30, not 31 times 24 hours
+		// later on October 31st at 23:30.
+		name:           "When the trial starts after midnight",
+		subscriptionAt: time.Date(2025, 9, 30, 22, 30, 0, 0, time.UTC),
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			simulator := &billing.Simulator{
+				Plan: &subrow.Plan{
+					Code:           "startup",
+					Interval:       subrow.PlanMonthly,
+					AmountCents:    3000,
+					AmountCurrency: "EUR",
+					TrialPeriod:    31,
+				},
+				Subscription: &subrow.Subscription{
+					BillingTime:    subrow.Calendar,
+					SubscriptionAt: timePtr(test.subscriptionAt),
+				},
+				Customer: &subrow.Customer{Timezone: "Europe/Paris"},
+			}
+
+			invoices, err := simulator.Run(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), billing.EventSeq(nil))
+			c.Assert(err, qt.IsNil)
+			// October, an hour longer than 31 days, is entirely in the trial.
+			c.Assert(summarize(invoices), qt.DeepEquals, []invoiceSummary{
+				{IssuingDate: "2025-12-01", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionPeriodic, Fees: []int{3000}, Total: 3000},
+			})
+		})
+	}
+}
+
+func TestSimulatorChargesBilledMonthly(t *testing.T) {
+	c := qt.New(t)
+
+	simulator := &billing.Simulator{
+		Plan: &subrow.Plan{
+			Code:               "enterprise",
+			Interval:           subrow.PlanYearly,
+			AmountCents:        120000,
+			AmountCurrency:     "EUR",
+			PayInAdvance:       true,
+			BillChargesMonthly: true,
+			Charges: []subrow.Charge{{
+				BillableMetricCode: "api_calls",
+				TypedProperties:    subrow.StandardProperties{Amount: "0.01"},
+			}},
+		},
+		Subscription: &subrow.Subscription{
+			BillingTime:    subrow.Calendar,
+			SubscriptionAt: timePtr(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+		},
+		BillableMetrics: []subrow.BillableMetric{{Code: "api_calls", AggregationType: subrow.SumAggregation, FieldName: "calls"}},
+	}
+	events := []subrow.Event{
+		{TransactionID: "tr_1", Code: "api_calls", Timestamp: time.Date(2025, 1, 20, 9, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"calls": 1000.0}},
+		{TransactionID: "tr_2", Code: "api_calls", Timestamp: time.Date(2025, 2, 3, 9, 0, 0, 0, time.UTC), Properties: map[string]interface{}{"calls": 500.0}},
+	}
+
+	invoices, err := simulator.Run(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), billing.EventSeq(events))
+	c.Assert(err, qt.IsNil)
+	c.Assert(summarize(invoices), qt.DeepEquals, []invoiceSummary{
+		{IssuingDate: "2025-01-01", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionStarting, Fees: []int{120000}, Total: 120000},
+		{IssuingDate: "2025-02-01", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionPeriodic, Fees: []int{1000}, Total: 1000},
+		{IssuingDate: "2025-03-01", Type: subrow.SubscriptionInvoiceType, Reason: subrow.BillingPeriodSubscriptionPeriodic, Fees: []int{500}, Total: 500},
+	})
+	c.Assert(invoices[1].BillingPeriods[0].SubscriptionToDatetime, qt.Equals, time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC))
+	c.Assert(invoices[1].BillingPeriods[0].ChargesToDatetime, qt.Equals, time.Date(2025, 1, 31, 23, 59, 59, 0, time.UTC))
+}
+
+func TestPlanFromInput(t *testing.T) {
+	metricID := uuid.New()
+	metrics := []subrow.BillableMetric{{SubrowID: metricID, Code: "api_calls"}}
+	taxes := []subrow.Tax{{Code: "vat", Rate: 20}}
+
+	t.Run("When the input is complete", func(t *testing.T) {
+		c := qt.New(t)
+
+		plan, err := billing.PlanFromInput(&subrow.PlanInput{
+			Code:        "startup",
+			Interval:    subrow.PlanYearly,
+			AmountCents: 10000,
+			TrialPeriod: 14,
+			TaxCodes:    []string{"vat"},
+			Charges: []subrow.PlanChargeInput{{
+				BillableMetricID: metricID,
+				TypedProperties:  subrow.StandardProperties{Amount: "0.01"},
+			}},
+			MinimumCommitment: &subrow.MinimumCommitmentInput{AmountCents: 20000},
+			UsageThresholds:   []subrow.UsageThresholdInput{{AmountCents: 5000, Recurring: true}},
+		}, metrics, taxes)
+		c.Assert(err, qt.IsNil)
+		c.Assert(plan.TrialPeriod, qt.Equals, float32(14))
+		c.Assert(plan.Taxes, qt.DeepEquals, taxes)
+		c.Assert(plan.Charges[0].BillableMetricCode, qt.Equals, "api_calls")
+		c.Assert(plan.Charges[0].ChargeModel, qt.Equals, subrow.StandardChargeModel)
+		c.Assert(plan.MinimumCommitment.AmountCents, qt.Equals, 20000)
+		c.Assert(plan.UsageThresholds, qt.DeepEquals, []subrow.UsageThreshold{{AmountCents: 5000, Recurring: true}})
+	})
+
+	t.Run("When a tax is unknown", func(t *testing.T) {
+		c := qt.New(t)
+
+		_, err := billing.PlanFromInput(&subrow.PlanInput{TaxCodes: []string{"gst"}}, metrics, taxes)
+		c.Assert(err, qt.ErrorMatches, `billing: unknown tax "gst"`)
+	})
+}
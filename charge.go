@@ -1,6 +1,7 @@
 package subrow
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,6 +23,10 @@ type ChargeFilter struct {
 	InvoiceDisplayName string                 `json:"invoice_display_name,omitempty"`
 	Properties         map[string]interface{} `json:"properties,omitempty"`
 	Values             map[string]interface{} `json:"values,omitempty"`
+
+	// TypedProperties replaces Properties when set. In a Charge, it is
+	// decoded from Properties when they match the charge model.
+	TypedProperties ChargeProperties `json:"-"`
 }
 
 type Charge struct {
@@ -39,5 +44,35 @@ type Charge struct {
 	Properties             map[string]interface{} `json:"properties,omitempty"`
 	Filters                []ChargeFilter         `json:"filters,omitempty"`
 
+	// TypedProperties are the Properties decoded for the charge model, or
+	// nil when they do not match it.
+	TypedProperties ChargeProperties `json:"-"`
+
 	Taxes []Tax `json:"tax,omitempty"`
 }
+
+func (cf ChargeFilter) MarshalJSON() ([]byte, error) {
+	type alias ChargeFilter
+	if cf.TypedProperties == nil {
+		return json.Marshal(alias(cf))
+	}
+
+	return json.Marshal(struct {
+		alias
+		Properties ChargeProperties `json:"properties"`
+	}{alias(cf), cf.TypedProperties})
+}
+
+func (c *Charge) UnmarshalJSON(data []byte) error {
+	type alias Charge
+	if err := json.Unmarshal(data, (*alias)(c)); err != nil {
+		return err
+	}
+
+	c.TypedProperties = typedChargeProperties(c.ChargeModel, c.Properties)
+	for i := range c.Filters {
+		c.Filters[i].TypedProperties = typedChargeProperties(c.ChargeModel, c.Filters[i].Properties)
+	}
+
+	return nil
+}
@@ -0,0 +1,350 @@
+package subrow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// ChargeProperties are the typed properties of a charge model, one of
+// StandardProperties, GraduatedProperties, GraduatedPercentageProperties,
+// PackageProperties, PercentageProperties, VolumeProperties and
+// DynamicProperties.
+type ChargeProperties interface {
+	ChargeModel() ChargeModel
+	// Validate checks the properties the way the API does, so mistakes are
+	// reported as a validation error before any call is made.
+	Validate() *Error
+
+	validate(errs fieldErrors, prefix string)
+}
+
+type StandardProperties struct {
+	Amount           string   `json:"amount"`
+	PricingGroupKeys []string `json:"pricing_group_keys,omitempty"`
+}
+
+type GraduatedRange struct {
+	FromValue int `json:"from_value"`
+	// ToValue is nil on the last range.
+	ToValue       *int   `json:"to_value"`
+	PerUnitAmount string `json:"per_unit_amount"`
+	FlatAmount    string `json:"flat_amount"`
+}
+
+type GraduatedProperties struct {
+	GraduatedRanges []GraduatedRange `json:"graduated_ranges"`
+}
+
+type GraduatedPercentageRange struct {
+	FromValue int `json:"from_value"`
+	// ToValue is nil on the last range.
+	ToValue    *int   `json:"to_value"`
+	Rate       string `json:"rate"`
+	FlatAmount string `json:"flat_amount"`
+}
+
+type GraduatedPercentageProperties struct {
+	GraduatedPercentageRanges []GraduatedPercentageRange `json:"graduated_percentage_ranges"`
+}
+
+type PackageProperties struct {
+	Amount      string `json:"amount"`
+	FreeUnits   int    `json:"free_units"`
+	PackageSize int    `json:"package_size"`
+}
+
+type PercentageProperties struct {
+	Rate                         string `json:"rate"`
+	FixedAmount                  string `json:"fixed_amount,omitempty"`
+	FreeUnitsPerEvents           *int   `json:"free_units_per_events,omitempty"`
+	FreeUnitsPerTotalAggregation string `json:"free_units_per_total_aggregation,omitempty"`
+	PerTransactionMaxAmount      string `json:"per_transaction_max_amount,omitempty"`
+	PerTransactionMinAmount      string `json:"per_transaction_min_amount,omitempty"`
+}
+
+type VolumeRange struct {
+	FromValue int `json:"from_value"`
+	// ToValue is nil on the last range.
+	ToValue       *int   `json:"to_value"`
+	PerUnitAmount string `json:"per_unit_amount"`
+	FlatAmount    string `json:"flat_amount"`
+}
+
+type VolumeProperties struct {
+	VolumeRanges []VolumeRange `json:"volume_ranges"`
+}
+
+// DynamicProperties are the properties of the dynamic charge model, whose
+// amounts are sent with each event.
+type DynamicProperties struct {
+	PricingGroupKeys []string `json:"pricing_group_keys,omitempty"`
+}
+
+// UnmarshalChargeProperties decodes the JSON properties of a charge of the
+// given model.
+func UnmarshalChargeProperties(chargeModel ChargeModel, data []byte) (ChargeProperties, error) {
+	var properties ChargeProperties
+	switch chargeModel {
+	case StandardChargeModel:
+		properties = &StandardProperties{}
+	case GraduatedChargeModel:
+		properties = &GraduatedProperties{}
+	case GraduatedPercentageChargeModel:
+		properties = &GraduatedPercentageProperties{}
+	case PackageChargeModel:
+		properties = &PackageProperties{}
+	case PercentageChargeModel:
+		properties = &PercentageProperties{}
+	case VolumeChargeModel:
+		properties = &VolumeProperties{}
+	case DynamicChargeModel:
+		properties = &DynamicProperties{}
+	default:
+		return nil, fmt.Errorf("unknown charge model %q", chargeModel)
+	}
+
+	if err := json.Unmarshal(data, properties); err != nil {
+		return nil, err
+	}
+
+	return properties, nil
+}
+
+// typedChargeProperties returns the typed version of properties, or nil when
+// they do not match the charge model.
+func typedChargeProperties(chargeModel ChargeModel, properties map[string]interface{}) ChargeProperties {
+	if properties == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(properties)
+	if err != nil {
+		return nil
+	}
+
+	typed, err := UnmarshalChargeProperties(chargeModel, data)
+	if err != nil {
+		return nil
+	}
+
+	return typed
+}
+
+func (StandardProperties) ChargeModel() ChargeModel {
+	return StandardChargeModel
+}
+
+func (GraduatedProperties) ChargeModel() ChargeModel {
+	return GraduatedChargeModel
+}
+
+func (GraduatedPercentageProperties) ChargeModel() ChargeModel {
+	return GraduatedPercentageChargeModel
+}
+
+func (PackageProperties) ChargeModel() ChargeModel {
+	return PackageChargeModel
+}
+
+func (PercentageProperties) ChargeModel() ChargeModel {
+	return PercentageChargeModel
+}
+
+func (VolumeProperties) ChargeModel() ChargeModel {
+	return VolumeChargeModel
+}
+
+func (DynamicProperties) ChargeModel() ChargeModel {
+	return DynamicChargeModel
+}
+
+func (p StandardProperties) Validate() *Error {
+	return validateProperties(p)
+}
+
+func (p GraduatedProperties) Validate() *Error {
+	return validateProperties(p)
+}
+
+func (p GraduatedPercentageProperties) Validate() *Error {
+	return validateProperties(p)
+}
+
+func (p PackageProperties) Validate() *Error {
+	return validateProperties(p)
+}
+
+func (p PercentageProperties) Validate() *Error {
+	return validateProperties(p)
+}
+
+func (p VolumeProperties) Validate() *Error {
+	return validateProperties(p)
+}
+
+func (p DynamicProperties) Validate() *Error {
+	return validateProperties(p)
+}
+
+func validateProperties(properties ChargeProperties) *Error {
+	errs := fieldErrors{}
+	properties.validate(errs, "")
+
+	return errs.err()
+}
+
+func (p StandardProperties) validate(errs fieldErrors, prefix string) {
+	errs.checkAmount(prefix+"amount", p.Amount, ErrorCodeInvalidAmount)
+}
+
+func (p GraduatedProperties) validate(errs fieldErrors, prefix string) {
+	ranges := make([]valueRange, len(p.GraduatedRanges))
+	for i, r := range p.GraduatedRanges {
+		ranges[i] = valueRange{r.FromValue, r.ToValue}
+		path := fmt.Sprintf("%sgraduated_ranges[%d].", prefix, i)
+		errs.checkAmount(path+"per_unit_amount", r.PerUnitAmount, ErrorCodeInvalidAmount)
+		errs.checkAmount(path+"flat_amount", r.FlatAmount, ErrorCodeInvalidAmount)
+	}
+	errs.checkRanges(prefix+"graduated_ranges", ranges, ErrorCodeInvalidGraduatedRanges)
+}
+
+func (p GraduatedPercentageProperties) validate(errs fieldErrors, prefix string) {
+	ranges := make([]valueRange, len(p.GraduatedPercentageRanges))
+	for i, r := range p.GraduatedPercentageRanges {
+		ranges[i] = valueRange{r.FromValue, r.ToValue}
+		path := fmt.Sprintf("%sgraduated_percentage_ranges[%d].", prefix, i)
+		errs.checkAmount(path+"rate", r.Rate, ErrorCodeInvalidRate)
+		errs.checkAmount(path+"flat_amount", r.FlatAmount, ErrorCodeInvalidAmount)
+	}
+	errs.checkRanges(prefix+"graduated_percentage_ranges", ranges, ErrorCodeInvalidGraduatedPercentageRanges)
+}
+
+func (p PackageProperties) validate(errs fieldErrors, prefix string) {
+	errs.checkAmount(prefix+"amount", p.Amount, ErrorCodeInvalidAmount)
+	if p.FreeUnits < 0 {
+		errs.add(prefix+"free_units", ErrorCodeInvalidFreeUnits)
+	}
+	if p.PackageSize <= 0 {
+		errs.add(prefix+"package_size", ErrorCodeInvalidPackageSize)
+	}
+}
+
+func (p PercentageProperties) validate(errs fieldErrors, prefix string) {
+	errs.checkAmount(prefix+"rate", p.Rate, ErrorCodeInvalidRate)
+	errs.checkOptionalAmount(prefix+"fixed_amount", p.FixedAmount, ErrorCodeInvalidFixedAmount)
+	errs.checkOptionalAmount(prefix+"free_units_per_total_aggregation", p.FreeUnitsPerTotalAggregation, ErrorCodeInvalidFreeUnits)
+	errs.checkOptionalAmount(prefix+"per_transaction_max_amount", p.PerTransactionMaxAmount, ErrorCodeInvalidAmount)
+	errs.checkOptionalAmount(prefix+"per_transaction_min_amount", p.PerTransactionMinAmount, ErrorCodeInvalidAmount)
+	if p.FreeUnitsPerEvents != nil && *p.FreeUnitsPerEvents < 0 {
+		errs.add(prefix+"free_units_per_events", ErrorCodeInvalidFreeUnits)
+	}
+}
+
+func (p VolumeProperties) validate(errs fieldErrors, prefix string) {
+	ranges := make([]valueRange, len(p.VolumeRanges))
+	for i, r := range p.VolumeRanges {
+		ranges[i] = valueRange{r.FromValue, r.ToValue}
+		path := fmt.Sprintf("%svolume_ranges[%d].", prefix, i)
+		errs.checkAmount(path+"per_unit_amount", r.PerUnitAmount, ErrorCodeInvalidAmount)
+		errs.checkAmount(path+"flat_amount", r.FlatAmount, ErrorCodeInvalidAmount)
+	}
+	errs.checkRanges(prefix+"volume_ranges", ranges, ErrorCodeInvalidVolumeRanges)
+}
+
+func (p DynamicProperties) validate(errs fieldErrors, prefix string) {
+}
+
+// fieldErrors collects the codes of the invalid fields found by a local
+// validation, keyed by field path like the error details of the API.
+type fieldErrors map[string][]string
+
+func (fe fieldErrors) add(path string, code ErrorCode) {
+	fe[path] = append(fe[path], string(code))
+}
+
+// decimalPattern matches the non-negative decimal strings amounts and rates
+// are sent as, such as "10" or "0.25".
+var decimalPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+func (fe fieldErrors) checkAmount(path string, amount string, code ErrorCode) {
+	switch {
+	case amount == "":
+		fe.add(path, ErrorCodeIsMandatory)
+	case !decimalPattern.MatchString(amount):
+		fe.add(path, code)
+	}
+}
+
+func (fe fieldErrors) checkOptionalAmount(path string, amount string, code ErrorCode) {
+	if amount != "" {
+		fe.checkAmount(path, amount, code)
+	}
+}
+
+type valueRange struct {
+	from int
+	to   *int
+}
+
+// checkRanges checks that ranges start at 0 and follow each other without
+// gaps or overlaps, the last one being open-ended.
+func (fe fieldErrors) checkRanges(path string, ranges []valueRange, code ErrorCode) {
+	if len(ranges) == 0 {
+		fe.add(path, ErrorCodeIsMandatory)
+		return
+	}
+
+	next := 0
+	for i, r := range ranges {
+		last := i == len(ranges)-1
+		valid := r.from == next &&
+			(last && r.to == nil || !last && r.to != nil && *r.to > r.from)
+		if !valid {
+			fe.add(path, code)
+			return
+		}
+		if r.to != nil {
+			next = *r.to + 1
+		}
+	}
+}
+
+func (fe fieldErrors) err() *Error {
+	if len(fe) == 0 {
+		return nil
+	}
+
+	return &Error{
+		Err:            errors.New("invalid charge properties"),
+		HTTPStatusCode: http.StatusUnprocessableEntity,
+		Message:        "Unprocessable Entity",
+		ErrorCode:      string(ErrorCodeValidationErrors),
+		ErrorDetail:    &ErrorDetail{Errors: map[int]map[string][]string{0: fe}},
+	}
+}
+
+// validateChargeProperties checks the typed properties of a charge and of
+// its filters, with paths prefixed by prefix.
+func validateChargeProperties(errs fieldErrors, prefix string, chargeModel ChargeModel, properties ChargeProperties, filters []ChargeFilter) {
+	if properties != nil {
+		if chargeModel != "" && chargeModel != properties.ChargeModel() {
+			errs.add(prefix+"charge_model", ErrorCodeIsInvalid)
+		}
+		properties.validate(errs, prefix+"properties.")
+		chargeModel = properties.ChargeModel()
+	}
+
+	for i, filter := range filters {
+		if filter.TypedProperties == nil {
+			continue
+		}
+		filterPrefix := fmt.Sprintf("%sfilters[%d].", prefix, i)
+		if chargeModel != "" && chargeModel != filter.TypedProperties.ChargeModel() {
+			errs.add(filterPrefix+"properties", ErrorCodeIsInvalid)
+		}
+		filter.TypedProperties.validate(errs, filterPrefix+"properties.")
+	}
+}
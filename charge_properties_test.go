@@ -0,0 +1,202 @@
+package subrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestChargePropertiesJSON(t *testing.T) {
+	t.Run("When decoding a charge", func(t *testing.T) {
+		c := qt.New(t)
+
+		charge := &Charge{}
+		err := json.Unmarshal([]byte(`{
+  "charge_model": "graduated",
+  "properties": {
+    "graduated_ranges": [
+      {"from_value": 0, "to_value": 10, "per_unit_amount": "1.5", "flat_amount": "0"},
+      {"from_value": 11, "to_value": null, "per_unit_amount": "1", "flat_amount": "2"}
+    ]
+  },
+  "filters": [
+    {"values": {"region": ["eu"]}, "properties": {"graduated_ranges": [{"from_value": 0, "to_value": null, "per_unit_amount": "2", "flat_amount": "0"}]}}
+  ]
+}`), charge)
+		c.Assert(err, qt.IsNil)
+		c.Assert(charge.Properties["graduated_ranges"], qt.HasLen, 2)
+		c.Assert(charge.TypedProperties, qt.DeepEquals, &GraduatedProperties{GraduatedRanges: []GraduatedRange{
+			{FromValue: 0, ToValue: intPtr(10), PerUnitAmount: "1.5", FlatAmount: "0"},
+			{FromValue: 11, PerUnitAmount: "1", FlatAmount: "2"},
+		}})
+		c.Assert(charge.Filters[0].TypedProperties, qt.DeepEquals, &GraduatedProperties{GraduatedRanges: []GraduatedRange{
+			{FromValue: 0, PerUnitAmount: "2", FlatAmount: "0"},
+		}})
+	})
+
+	t.Run("When the properties do not match the charge model", func(t *testing.T) {
+		c := qt.New(t)
+
+		charge := &Charge{}
+		err := json.Unmarshal([]byte(`{"charge_model": "package", "properties": {"amount": 10}}`), charge)
+		c.Assert(err, qt.IsNil)
+		c.Assert(charge.Properties, qt.DeepEquals, map[string]interface{}{"amount": 10.0})
+		c.Assert(charge.TypedProperties, qt.IsNil)
+	})
+
+	t.Run("When encoding a plan charge", func(t *testing.T) {
+		c := qt.New(t)
+
+		body, err := json.Marshal(PlanChargeInput{
+			TypedProperties: PackageProperties{Amount: "5", FreeUnits: 100, PackageSize: 1000},
+			Filters: []ChargeFilter{{
+				Values:          map[string]interface{}{"region": []string{"us"}},
+				TypedProperties: PackageProperties{Amount: "4", PackageSize: 1000},
+			}},
+		})
+		c.Assert(err, qt.IsNil)
+		c.Assert(string(body), qt.JSONEquals, map[string]interface{}{
+			"billable_metric_id": "00000000-0000-0000-0000-000000000000",
+			"charge_model":       "package",
+			"properties":         map[string]interface{}{"amount": "5", "free_units": 100, "package_size": 1000},
+			"filters": []interface{}{map[string]interface{}{
+				"values":     map[string]interface{}{"region": []string{"us"}},
+				"properties": map[string]interface{}{"amount": "4", "free_units": 0, "package_size": 1000},
+			}},
+		})
+	})
+
+	t.Run("When decoding properties of an unknown charge model", func(t *testing.T) {
+		c := qt.New(t)
+
+		_, err := UnmarshalChargeProperties("tiered", []byte(`{}`))
+		c.Assert(err, qt.ErrorMatches, `unknown charge model "tiered"`)
+	})
+}
+
+func TestChargePropertiesValidate(t *testing.T) {
+	var tests = []struct {
+		name       string
+		properties ChargeProperties
+		want       map[string][]string
+	}{
+		{
+			name:       "Valid standard properties",
+			properties: StandardProperties{Amount: "0.05"},
+		},
+		{
+			name:       "Missing amount",
+			properties: StandardProperties{},
+			want:       map[string][]string{"amount": {"value_is_mandatory"}},
+		},
+		{
+			name:       "Amount not a decimal string",
+			properties: StandardProperties{Amount: "1,5"},
+			want:       map[string][]string{"amount": {"invalid_amount"}},
+		},
+		{
+			name: "Valid graduated ranges",
+			properties: GraduatedProperties{GraduatedRanges: []GraduatedRange{
+				{FromValue: 0, ToValue: intPtr(100), PerUnitAmount: "1", FlatAmount: "0"},
+				{FromValue: 101, PerUnitAmount: "0.5", FlatAmount: "10"},
+			}},
+		},
+		{
+			name: "Graduated ranges with a gap",
+			properties: GraduatedProperties{GraduatedRanges: []GraduatedRange{
+				{FromValue: 0, ToValue: intPtr(100), PerUnitAmount: "1", FlatAmount: "0"},
+				{FromValue: 150, PerUnitAmount: "0.5", FlatAmount: "10"},
+			}},
+			want: map[string][]string{"graduated_ranges": {"invalid_graduated_ranges"}},
+		},
+		{
+			name: "Volume ranges not open-ended",
+			properties: VolumeProperties{VolumeRanges: []VolumeRange{
+				{FromValue: 0, ToValue: intPtr(100), PerUnitAmount: "1", FlatAmount: "0"},
+			}},
+			want: map[string][]string{"volume_ranges": {"invalid_volume_ranges"}},
+		},
+		{
+			name: "Graduated percentage ranges with an invalid rate",
+			properties: GraduatedPercentageProperties{GraduatedPercentageRanges: []GraduatedPercentageRange{
+				{FromValue: 0, Rate: "-1", FlatAmount: "0"},
+			}},
+			want: map[string][]string{"graduated_percentage_ranges[0].rate": {"invalid_rate"}},
+		},
+		{
+			name:       "Negative free units and empty package",
+			properties: PackageProperties{Amount: "10", FreeUnits: -1},
+			want: map[string][]string{
+				"free_units":   {"invalid_free_units"},
+				"package_size": {"invalid_package_size"},
+			},
+		},
+		{
+			name:       "Percentage with an invalid fixed amount",
+			properties: PercentageProperties{Rate: "2.5", FixedAmount: "abc", FreeUnitsPerEvents: intPtr(-2)},
+			want: map[string][]string{
+				"fixed_amount":          {"invalid_fixed_amount"},
+				"free_units_per_events": {"invalid_free_units"},
+			},
+		},
+		{
+			name:       "Dynamic properties",
+			properties: DynamicProperties{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			err := tt.properties.Validate()
+			if tt.want == nil {
+				c.Assert(err == nil, qt.IsTrue)
+				return
+			}
+
+			c.Assert(err == nil, qt.IsFalse)
+			c.Assert(errors.Is(err, ErrValidation), qt.IsTrue)
+			details, _ := err.ErrorDetail.Details()
+			c.Assert(details, qt.DeepEquals, tt.want)
+		})
+	}
+}
+
+func TestPlanCreateValidatesChargeProperties(t *testing.T) {
+	c := qt.New(t)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	c.Cleanup(server.Close)
+
+	client := New().SetBaseURL(server.URL).SetApiKey("test_api_key")
+	_, err := client.Plan().Create(context.Background(), &PlanInput{
+		Code: "startup",
+		Charges: []PlanChargeInput{
+			{ChargeModel: StandardChargeModel, TypedProperties: StandardProperties{Amount: "1"}},
+			{ChargeModel: StandardChargeModel, TypedProperties: PackageProperties{Amount: "1"}},
+		},
+	})
+	c.Assert(err == nil, qt.IsFalse)
+	c.Assert(calls.Load(), qt.Equals, int32(0))
+
+	var validationErr *ValidationError
+	c.Assert(errors.As(err, &validationErr), qt.IsTrue)
+	c.Assert(validationErr.Fields, qt.DeepEquals, []FieldError{
+		{Path: "charges[1].charge_model", Code: ErrorCodeIsInvalid},
+		{Path: "charges[1].properties.package_size", Code: ErrorCodeInvalidPackageSize},
+	})
+	c.Assert(IsRetryable(err), qt.IsFalse)
+}
@@ -0,0 +1,213 @@
+package subrow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvApiKey              string = "SUBROW_API_KEY"
+	EnvApiURL              string = "SUBROW_API_URL"
+	EnvIngestURL           string = "SUBROW_INGEST_URL"
+	EnvUseIngestService    string = "SUBROW_USE_INGEST_SERVICE"
+	EnvTimeout             string = "SUBROW_TIMEOUT"
+	EnvRetryMaxAttempts    string = "SUBROW_RETRY_MAX_ATTEMPTS"
+	EnvRetryInitialBackoff string = "SUBROW_RETRY_INITIAL_BACKOFF"
+	EnvRetryMaxBackoff     string = "SUBROW_RETRY_MAX_BACKOFF"
+	// EnvProfile names the profile LoadProfile reads when none is given.
+	EnvProfile string = "SUBROW_PROFILE"
+)
+
+// Profile is the configuration of a Client, read from the environment or
+// from a profile file. Empty fields keep the client defaults.
+type Profile struct {
+	ApiKey           string        `yaml:"api_key" toml:"api_key"`
+	ApiURL           string        `yaml:"api_url" toml:"api_url"`
+	IngestURL        string        `yaml:"ingest_url" toml:"ingest_url"`
+	UseIngestService bool          `yaml:"use_ingest_service" toml:"use_ingest_service"`
+	Timeout          time.Duration `yaml:"timeout" toml:"timeout"`
+	// Retry enables retries, starting from DefaultRetryPolicy.
+	Retry *ProfileRetry `yaml:"retry" toml:"retry"`
+}
+
+type ProfileRetry struct {
+	MaxAttempts    int           `yaml:"max_attempts" toml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" toml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" toml:"max_backoff"`
+}
+
+// profileFile is the layout of a profile file:
+//
+//	default_profile: sandbox
+//	profiles:
+//	  sandbox:
+//	    api_key: ${SUBROW_SANDBOX_API_KEY}
+//	    api_url: https://api.sandbox.example.com
+//	    timeout: 10s
+//	  production:
+//	    api_key: ${SUBROW_API_KEY}
+//	    use_ingest_service: true
+//	    retry:
+//	      max_attempts: 5
+type profileFile struct {
+	DefaultProfile string              `yaml:"default_profile" toml:"default_profile"`
+	Profiles       map[string]*Profile `yaml:"profiles" toml:"profiles"`
+}
+
+// NewFromEnv returns a Client configured by the SUBROW_* environment
+// variables, with opts applied on top. Durations are Go durations, e.g. 30s.
+func NewFromEnv(opts ...Option) (*Client, error) {
+	profile, err := ProfileFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(append(profile.Options(), opts...)...), nil
+}
+
+// NewFromProfile returns a Client configured by the profile name of the file
+// at path, with opts applied on top. See LoadProfile.
+func NewFromProfile(path string, name string, opts ...Option) (*Client, error) {
+	profile, err := LoadProfile(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(append(profile.Options(), opts...)...), nil
+}
+
+func ProfileFromEnv() (*Profile, error) {
+	profile := &Profile{
+		ApiKey:    os.Getenv(EnvApiKey),
+		ApiURL:    os.Getenv(EnvApiURL),
+		IngestURL: os.Getenv(EnvIngestURL),
+	}
+
+	var err error
+	if value := os.Getenv(EnvUseIngestService); value != "" {
+		if profile.UseIngestService, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("subrow: invalid %s: %w", EnvUseIngestService, err)
+		}
+	}
+	if profile.Timeout, err = durationFromEnv(EnvTimeout); err != nil {
+		return nil, err
+	}
+
+	retry := &ProfileRetry{}
+	if value := os.Getenv(EnvRetryMaxAttempts); value != "" {
+		if retry.MaxAttempts, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("subrow: invalid %s: %w", EnvRetryMaxAttempts, err)
+		}
+	}
+	if retry.InitialBackoff, err = durationFromEnv(EnvRetryInitialBackoff); err != nil {
+		return nil, err
+	}
+	if retry.MaxBackoff, err = durationFromEnv(EnvRetryMaxBackoff); err != nil {
+		return nil, err
+	}
+	if *retry != (ProfileRetry{}) {
+		profile.Retry = retry
+	}
+
+	return profile, nil
+}
+
+func durationFromEnv(name string) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("subrow: invalid %s: %w", name, err)
+	}
+
+	return duration, nil
+}
+
+// LoadProfile reads the profile name of a YAML file, or of a TOML file when
+// path ends in .toml. Without a name, it reads the profile named by
+// SUBROW_PROFILE, else the default_profile of the file. Environment
+// variables in the api_key, api_url and ingest_url fields, such as
+// ${SUBROW_API_KEY}, are expanded so keys can stay out of the file.
+func LoadProfile(path string, name string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("subrow: read profile file: %w", err)
+	}
+
+	file := profileFile{}
+	if filepath.Ext(path) == ".toml" {
+		err = toml.Unmarshal(data, &file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("subrow: parse profile file %s: %w", path, err)
+	}
+
+	if name == "" {
+		name = os.Getenv(EnvProfile)
+	}
+	if name == "" {
+		name = file.DefaultProfile
+	}
+	if name == "" {
+		return nil, fmt.Errorf("subrow: no profile name given and no default_profile in %s", path)
+	}
+
+	profile, ok := file.Profiles[name]
+	if !ok || profile == nil {
+		return nil, fmt.Errorf("subrow: no profile %q in %s", name, path)
+	}
+
+	profile.ApiKey = os.ExpandEnv(profile.ApiKey)
+	profile.ApiURL = os.ExpandEnv(profile.ApiURL)
+	profile.IngestURL = os.ExpandEnv(profile.IngestURL)
+
+	return profile, nil
+}
+
+// Options returns the options configuring a Client like p.
+func (p *Profile) Options() []Option {
+	var opts []Option
+	if p.ApiKey != "" {
+		opts = append(opts, WithApiKey(p.ApiKey))
+	}
+	if p.ApiURL != "" {
+		opts = append(opts, WithBaseURL(p.ApiURL))
+	}
+	if p.IngestURL != "" {
+		opts = append(opts, WithBaseIngestURL(p.IngestURL))
+	}
+	if p.UseIngestService {
+		opts = append(opts, WithIngestService(true))
+	}
+	if p.Timeout > 0 {
+		opts = append(opts, WithTimeout(p.Timeout))
+	}
+
+	if p.Retry != nil {
+		retryPolicy := DefaultRetryPolicy()
+		if p.Retry.MaxAttempts > 0 {
+			retryPolicy.MaxAttempts = p.Retry.MaxAttempts
+		}
+		if p.Retry.InitialBackoff > 0 {
+			retryPolicy.InitialBackoff = p.Retry.InitialBackoff
+		}
+		if p.Retry.MaxBackoff > 0 {
+			retryPolicy.MaxBackoff = p.Retry.MaxBackoff
+		}
+		opts = append(opts, WithRetryPolicy(retryPolicy))
+	}
+
+	return opts
+}
@@ -0,0 +1,150 @@
+package subrow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func writeProfileFile(c *qt.C, name string, content string) string {
+	path := filepath.Join(c.TempDir(), name)
+	c.Assert(os.WriteFile(path, []byte(content), 0o600), qt.IsNil)
+
+	return path
+}
+
+// clearEnv unsets every variable NewFromEnv reads for the duration of the
+// test.
+func clearEnv(c *qt.C) {
+	for _, name := range []string{
+		EnvApiKey, EnvApiURL, EnvIngestURL, EnvUseIngestService, EnvTimeout,
+		EnvRetryMaxAttempts, EnvRetryInitialBackoff, EnvRetryMaxBackoff,
+	} {
+		c.Setenv(name, "")
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Run("When the environment is set", func(t *testing.T) {
+		c := qt.New(t)
+
+		clearEnv(c)
+		c.Setenv(EnvApiKey, "test_api_key")
+		c.Setenv(EnvApiURL, "http://api.local")
+		c.Setenv(EnvIngestURL, "http://ingest.local")
+		c.Setenv(EnvTimeout, "15s")
+		c.Setenv(EnvRetryMaxAttempts, "6")
+		c.Setenv(EnvRetryMaxBackoff, "1m")
+
+		client, err := NewFromEnv(WithUserAgentSuffix("worker"))
+		c.Assert(err, qt.IsNil)
+		c.Assert(client.HttpClient.Token, qt.Equals, "test_api_key")
+		c.Assert(client.BaseUrl, qt.Equals, "http://api.local")
+		c.Assert(client.BaseIngestUrl, qt.Equals, "http://ingest.local")
+		c.Assert(client.HttpClient.GetClient().Timeout, qt.Equals, 15*time.Second)
+		c.Assert(client.RetryPolicy.MaxAttempts, qt.Equals, 6)
+		c.Assert(client.RetryPolicy.InitialBackoff, qt.Equals, DefaultRetryPolicy().InitialBackoff)
+		c.Assert(client.RetryPolicy.MaxBackoff, qt.Equals, time.Minute)
+	})
+
+	t.Run("When the environment is empty", func(t *testing.T) {
+		c := qt.New(t)
+
+		clearEnv(c)
+
+		client, err := NewFromEnv()
+		c.Assert(err, qt.IsNil)
+		c.Assert(client.BaseUrl, qt.Equals, baseURL)
+		c.Assert(client.BaseIngestUrl, qt.Equals, baseURL)
+		c.Assert(client.RetryPolicy, qt.IsNil)
+	})
+
+	t.Run("When a value is invalid", func(t *testing.T) {
+		c := qt.New(t)
+
+		clearEnv(c)
+		c.Setenv(EnvTimeout, "15")
+
+		_, err := NewFromEnv()
+		c.Assert(err, qt.ErrorMatches, `subrow: invalid SUBROW_TIMEOUT: .*`)
+	})
+}
+
+func TestLoadProfile(t *testing.T) {
+	t.Run("When reading a YAML file", func(t *testing.T) {
+		c := qt.New(t)
+
+		c.Setenv("TEST_SANDBOX_API_KEY", "sandbox_key")
+		c.Setenv(EnvProfile, "")
+		path := writeProfileFile(c, "subrow.yaml", `
+default_profile: sandbox
+profiles:
+  sandbox:
+    api_key: ${TEST_SANDBOX_API_KEY}
+    api_url: http://sandbox.local
+    timeout: 10s
+  production:
+    api_key: production_key
+    use_ingest_service: true
+    retry:
+      max_attempts: 5
+`)
+
+		profile, err := LoadProfile(path, "")
+		c.Assert(err, qt.IsNil)
+		c.Assert(profile, qt.DeepEquals, &Profile{
+			ApiKey:  "sandbox_key",
+			ApiURL:  "http://sandbox.local",
+			Timeout: 10 * time.Second,
+		})
+
+		client, err := NewFromProfile(path, "production")
+		c.Assert(err, qt.IsNil)
+		c.Assert(client.HttpClient.Token, qt.Equals, "production_key")
+		c.Assert(client.BaseIngestUrl, qt.Equals, baseIngestURL)
+		c.Assert(client.RetryPolicy.MaxAttempts, qt.Equals, 5)
+	})
+
+	t.Run("When reading a TOML file", func(t *testing.T) {
+		c := qt.New(t)
+
+		c.Setenv(EnvProfile, "production")
+		path := writeProfileFile(c, "subrow.toml", `
+[profiles.sandbox]
+api_key = "sandbox_key"
+
+[profiles.production]
+api_key = "production_key"
+ingest_url = "http://ingest.local"
+timeout = "30s"
+
+[profiles.production.retry]
+initial_backoff = "1s"
+`)
+
+		profile, err := LoadProfile(path, "")
+		c.Assert(err, qt.IsNil)
+		c.Assert(profile, qt.DeepEquals, &Profile{
+			ApiKey:    "production_key",
+			IngestURL: "http://ingest.local",
+			Timeout:   30 * time.Second,
+			Retry:     &ProfileRetry{InitialBackoff: time.Second},
+		})
+	})
+
+	t.Run("When the profile does not exist", func(t *testing.T) {
+		c := qt.New(t)
+
+		c.Setenv(EnvProfile, "")
+		path := writeProfileFile(c, "subrow.yml", "profiles:\n  sandbox:\n    api_key: sandbox_key\n")
+
+		_, err := LoadProfile(path, "staging")
+		c.Assert(err, qt.ErrorMatches, `subrow: no profile "staging" in .*`)
+
+		_, err = LoadProfile(path, "")
+		c.Assert(err, qt.ErrorMatches, `subrow: no profile name given .*`)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/go-querystring/query"
@@ -221,6 +222,26 @@ func (cr *CouponRequest) GetList(ctx context.Context, couponListInput *CouponLis
 	return couponResult, nil
 }
 
+// All iterates over every coupon matching the input, fetching pages as needed.
+func (cr *CouponRequest) All(ctx context.Context, couponListInput *CouponListInput, opts ...IteratorOption) iter.Seq2[Coupon, error] {
+	input := CouponListInput{}
+	if couponListInput != nil {
+		input = *couponListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]Coupon, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := cr.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.Coupons, result.Meta, nil
+	}, opts)
+}
+
 func (cr *CouponRequest) Create(ctx context.Context, couponInput *CouponInput) (*Coupon, *Error) {
 	couponParams := &CouponParams{
 		Coupon: couponInput,
@@ -315,6 +336,26 @@ func (cr *AppliedCouponRequest) GetList(ctx context.Context, appliedCouponListIn
 	return appliedCouponResult, nil
 }
 
+// All iterates over every applied coupon matching the input, fetching pages as needed.
+func (cr *AppliedCouponRequest) All(ctx context.Context, appliedCouponListInput *AppliedCouponListInput, opts ...IteratorOption) iter.Seq2[AppliedCoupon, error] {
+	input := AppliedCouponListInput{}
+	if appliedCouponListInput != nil {
+		input = *appliedCouponListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]AppliedCoupon, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := cr.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.AppliedCoupons, result.Meta, nil
+	}, opts)
+}
+
 func (cr *CouponRequest) ApplyToCustomer(ctx context.Context, applyCouponInput *ApplyCouponInput) (*AppliedCoupon, *Error) {
 	applyCouponParams := &ApplyCouponParams{
 		AppliedCoupon: applyCouponInput,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -251,6 +252,26 @@ func (cr *CreditNoteRequest) GetList(ctx context.Context, creditNoteListInput *C
 	return creditNoteResult, nil
 }
 
+// All iterates over every credit note matching the input, fetching pages as needed.
+func (cr *CreditNoteRequest) All(ctx context.Context, creditNoteListInput *CreditListInput, opts ...IteratorOption) iter.Seq2[CreditNote, error] {
+	input := CreditListInput{}
+	if creditNoteListInput != nil {
+		input = *creditNoteListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]CreditNote, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := cr.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.CreditNotes, result.Meta, nil
+	}, opts)
+}
+
 func (cr *CreditNoteRequest) Create(ctx context.Context, creditNoteInput *CreditNoteInput) (*CreditNote, *Error) {
 	creditNoteParams := &CreditNoteParams{
 		CreditNote: creditNoteInput,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"strconv"
 	"time"
 
@@ -491,3 +492,23 @@ func (cr *CustomerRequest) GetList(ctx context.Context, customerListInput *Custo
 
 	return customerResult, nil
 }
+
+// All iterates over every customer matching the input, fetching pages as needed.
+func (cr *CustomerRequest) All(ctx context.Context, customerListInput *CustomerListInput, opts ...IteratorOption) iter.Seq2[Customer, error] {
+	input := CustomerListInput{}
+	if customerListInput != nil {
+		input = *customerListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]Customer, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := cr.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.Customers, result.Meta, nil
+	}, opts)
+}
@@ -3,14 +3,50 @@ package subrow
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 )
 
 type ErrorCode string
 
 const (
-	ErrorCodeAlreadyExist ErrorCode = "value_already_exist"
-	ErrorCodeInvalidValue
+	ErrorCodeValidationErrors ErrorCode = "validation_errors"
+
+	ErrorCodeAlreadyExist   ErrorCode = "value_already_exist"
+	ErrorCodeInvalidValue   ErrorCode = "invalid_value"
+	ErrorCodeIsMandatory    ErrorCode = "value_is_mandatory"
+	ErrorCodeIsInvalid      ErrorCode = "value_is_invalid"
+	ErrorCodeIsOutOfRange   ErrorCode = "value_is_out_of_range"
+	ErrorCodeDoesNotExist   ErrorCode = "does_not_exist"
+	ErrorCodeInvalidDate    ErrorCode = "invalid_date"
+	ErrorCodeInvalidFormat  ErrorCode = "invalid_format"
+	ErrorCodeInvalidType    ErrorCode = "invalid_type"
+	ErrorCodeUrlIsInvalid   ErrorCode = "url_is_invalid"
+	ErrorCodeExceedsLimit   ErrorCode = "value_exceeds_limit"
+	ErrorCodeNotAllowed     ErrorCode = "not_allowed"
+	ErrorCodeAlreadyApplied ErrorCode = "already_applied"
+
+	// Charge properties.
+	ErrorCodeInvalidAmount                    ErrorCode = "invalid_amount"
+	ErrorCodeInvalidRate                      ErrorCode = "invalid_rate"
+	ErrorCodeInvalidFixedAmount               ErrorCode = "invalid_fixed_amount"
+	ErrorCodeInvalidFreeUnits                 ErrorCode = "invalid_free_units"
+	ErrorCodeInvalidPackageSize               ErrorCode = "invalid_package_size"
+	ErrorCodeInvalidGraduatedRanges           ErrorCode = "invalid_graduated_ranges"
+	ErrorCodeInvalidGraduatedPercentageRanges ErrorCode = "invalid_graduated_percentage_ranges"
+	ErrorCodeInvalidVolumeRanges              ErrorCode = "invalid_volume_ranges"
+)
+
+// Sentinel errors matched by errors.Is against the status of an *Error.
+var (
+	// ErrUnauthorized matches 401 and 403 responses.
+	ErrUnauthorized = errors.New("subrow: unauthorized")
+	ErrNotFound     = errors.New("subrow: not found")
+	ErrConflict     = errors.New("subrow: conflict")
+	ErrValidation   = errors.New("subrow: validation failed")
+	ErrRateLimited  = errors.New("subrow: rate limited")
 )
 
 var ErrorTypeAssert = Error{
@@ -83,6 +119,10 @@ type Error struct {
 	ErrorCode      string `json:"code"`
 
 	ErrorDetail *ErrorDetail `json:"error_details,omitempty"`
+
+	// IdempotencyKey is the Idempotency-Key the failed request was sent
+	// with, if any. Reuse it to safely retry the same operation later.
+	IdempotencyKey string `json:"-"`
 }
 
 func (e Error) Error() string {
@@ -101,3 +141,166 @@ func (e Error) Error() string {
 func (e ErrorCode) Error() string {
 	return string(e)
 }
+
+func (e *Error) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+
+	return e.Err
+}
+
+// Is matches the sentinel errors of the response status, and the ErrorCode
+// of the response or of any of its validation errors:
+//
+//	errors.Is(err, subrow.ErrNotFound)
+//	errors.Is(err, subrow.ErrorCodeAlreadyExist)
+func (e *Error) Is(target error) bool {
+	if e == nil {
+		return false
+	}
+
+	switch target {
+	case ErrUnauthorized:
+		return e.HTTPStatusCode == http.StatusUnauthorized || e.HTTPStatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.HTTPStatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.HTTPStatusCode == http.StatusConflict
+	case ErrValidation:
+		return e.HTTPStatusCode == http.StatusUnprocessableEntity
+	case ErrRateLimited:
+		return e.HTTPStatusCode == http.StatusTooManyRequests
+	}
+
+	code, ok := target.(ErrorCode)
+	if !ok {
+		return false
+	}
+	if e.ErrorCode == string(code) {
+		return true
+	}
+	if e.ErrorDetail == nil {
+		return false
+	}
+	for _, fields := range e.ErrorDetail.Errors {
+		for _, codes := range fields {
+			for _, fieldCode := range codes {
+				if fieldCode == string(code) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// As sets a **ValidationError target when e is a 422 response with
+// validation error details.
+func (e *Error) As(target interface{}) bool {
+	validationErr, ok := target.(**ValidationError)
+	if !ok || e == nil || e.ErrorDetail == nil || e.HTTPStatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+
+	*validationErr = newValidationError(e)
+
+	return true
+}
+
+// IsRetryable reports whether err is a transient failure worth retrying: a
+// transport error other than a canceled context, or a 408, 429 or 5xx
+// response.
+func IsRetryable(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return isRetryableError(apiErr)
+}
+
+// FieldError is an invalid field of a validation error.
+type FieldError struct {
+	// Row is the index of the invalid item of a batch call, and 0 otherwise.
+	Row  int
+	Path string
+	Code ErrorCode
+}
+
+// ValidationError lists the invalid fields of a 422 response. Get one with
+// errors.As:
+//
+//	var validationErr *subrow.ValidationError
+//	if errors.As(err, &validationErr) && validationErr.Has("external_id", subrow.ErrorCodeAlreadyExist) {
+//		...
+//	}
+type ValidationError struct {
+	Err *Error
+	// Batch is set when Fields are reported per row of a batch call.
+	Batch  bool
+	Fields []FieldError
+}
+
+func newValidationError(e *Error) *ValidationError {
+	validationErr := &ValidationError{Err: e, Batch: e.ErrorDetail.Multiple}
+
+	for row, fields := range e.ErrorDetail.Errors {
+		for path, codes := range fields {
+			for _, code := range codes {
+				validationErr.Fields = append(validationErr.Fields, FieldError{Row: row, Path: path, Code: ErrorCode(code)})
+			}
+		}
+	}
+
+	sort.SliceStable(validationErr.Fields, func(i, j int) bool {
+		a, b := validationErr.Fields[i], validationErr.Fields[j]
+		if a.Row != b.Row {
+			return a.Row < b.Row
+		}
+		return a.Path < b.Path
+	})
+
+	return validationErr
+}
+
+func (ve *ValidationError) Error() string {
+	fields := make([]string, 0, len(ve.Fields))
+	for _, field := range ve.Fields {
+		if ve.Batch {
+			fields = append(fields, fmt.Sprintf("[%d].%s: %s", field.Row, field.Path, field.Code))
+			continue
+		}
+		fields = append(fields, fmt.Sprintf("%s: %s", field.Path, field.Code))
+	}
+
+	return "subrow: validation failed: " + strings.Join(fields, ", ")
+}
+
+func (ve *ValidationError) Unwrap() error {
+	return ve.Err
+}
+
+// Has reports whether the field at path failed with code, in any row.
+func (ve *ValidationError) Has(path string, code ErrorCode) bool {
+	for _, field := range ve.Fields {
+		if field.Path == path && field.Code == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ForRow returns the invalid fields of a row of a batch call.
+func (ve *ValidationError) ForRow(row int) []FieldError {
+	var fields []FieldError
+	for _, field := range ve.Fields {
+		if field.Row == row {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
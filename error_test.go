@@ -1,9 +1,15 @@
 package subrow
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	qt "github.com/frankban/quicktest"
 )
 
 func TestErrorErr(t *testing.T) {
@@ -136,3 +142,154 @@ func TestErrorDetails(t *testing.T) {
 		})
 	}
 }
+
+func TestErrorIs(t *testing.T) {
+	t.Run("When matching sentinel errors", func(t *testing.T) {
+		c := qt.New(t)
+
+		for status, sentinel := range map[int]error{
+			http.StatusUnauthorized:        ErrUnauthorized,
+			http.StatusForbidden:           ErrUnauthorized,
+			http.StatusNotFound:            ErrNotFound,
+			http.StatusConflict:            ErrConflict,
+			http.StatusUnprocessableEntity: ErrValidation,
+			http.StatusTooManyRequests:     ErrRateLimited,
+		} {
+			var err error = &Error{HTTPStatusCode: status}
+			c.Assert(errors.Is(err, sentinel), qt.IsTrue, qt.Commentf("status %d", status))
+			c.Assert(errors.Is(fmt.Errorf("wrapped: %w", err), sentinel), qt.IsTrue)
+		}
+
+		c.Assert(errors.Is(&Error{HTTPStatusCode: http.StatusNotFound}, ErrConflict), qt.IsFalse)
+		c.Assert(errors.Is(&Error{HTTPStatusCode: http.StatusInternalServerError}, ErrNotFound), qt.IsFalse)
+	})
+
+	t.Run("When matching error codes", func(t *testing.T) {
+		c := qt.New(t)
+
+		err := &Error{
+			HTTPStatusCode: http.StatusUnprocessableEntity,
+			ErrorCode:      string(ErrorCodeValidationErrors),
+			ErrorDetail: &ErrorDetail{Errors: map[int]map[string][]string{
+				0: {"external_id": {"value_already_exist"}},
+			}},
+		}
+
+		c.Assert(errors.Is(err, ErrorCodeValidationErrors), qt.IsTrue)
+		c.Assert(errors.Is(err, ErrorCodeAlreadyExist), qt.IsTrue)
+		c.Assert(errors.Is(err, ErrorCodeInvalidValue), qt.IsFalse)
+	})
+
+	t.Run("When unwrapping the transport error", func(t *testing.T) {
+		c := qt.New(t)
+
+		err := &Error{Err: context.DeadlineExceeded}
+		c.Assert(errors.Is(err, context.DeadlineExceeded), qt.IsTrue)
+	})
+}
+
+func TestValidationError(t *testing.T) {
+	t.Run("When the error has a single detail", func(t *testing.T) {
+		c := qt.New(t)
+
+		var err error = &Error{
+			HTTPStatusCode: http.StatusUnprocessableEntity,
+			ErrorDetail: &ErrorDetail{Errors: map[int]map[string][]string{
+				0: {
+					"external_id": {"value_already_exist"},
+					"currency":    {"value_is_mandatory", "value_is_invalid"},
+				},
+			}},
+		}
+
+		var validationErr *ValidationError
+		c.Assert(errors.As(err, &validationErr), qt.IsTrue)
+		c.Assert(validationErr.Batch, qt.IsFalse)
+		c.Assert(validationErr.Fields, qt.DeepEquals, []FieldError{
+			{Path: "currency", Code: ErrorCodeIsMandatory},
+			{Path: "currency", Code: ErrorCodeIsInvalid},
+			{Path: "external_id", Code: ErrorCodeAlreadyExist},
+		})
+		c.Assert(validationErr.Has("external_id", ErrorCodeAlreadyExist), qt.IsTrue)
+		c.Assert(validationErr.Has("external_id", ErrorCodeIsMandatory), qt.IsFalse)
+		c.Assert(validationErr.Error(), qt.Equals, "subrow: validation failed: currency: value_is_mandatory, currency: value_is_invalid, external_id: value_already_exist")
+		c.Assert(errors.Is(validationErr, ErrValidation), qt.IsTrue)
+	})
+
+	t.Run("When the error has details per row", func(t *testing.T) {
+		c := qt.New(t)
+
+		var err error = &Error{
+			HTTPStatusCode: http.StatusUnprocessableEntity,
+			ErrorDetail: &ErrorDetail{Multiple: true, Errors: map[int]map[string][]string{
+				2: {"transaction_id": {"value_already_exist"}},
+				0: {"code": {"does_not_exist"}},
+			}},
+		}
+
+		var validationErr *ValidationError
+		c.Assert(errors.As(err, &validationErr), qt.IsTrue)
+		c.Assert(validationErr.Batch, qt.IsTrue)
+		c.Assert(validationErr.ForRow(2), qt.DeepEquals, []FieldError{{Row: 2, Path: "transaction_id", Code: ErrorCodeAlreadyExist}})
+		c.Assert(validationErr.ForRow(1), qt.HasLen, 0)
+		c.Assert(validationErr.Error(), qt.Equals, "subrow: validation failed: [0].code: does_not_exist, [2].transaction_id: value_already_exist")
+	})
+
+	t.Run("When the error has no details", func(t *testing.T) {
+		c := qt.New(t)
+
+		var validationErr *ValidationError
+		c.Assert(errors.As(&Error{HTTPStatusCode: http.StatusNotFound}, &validationErr), qt.IsFalse)
+	})
+
+	t.Run("When the error has details but is not a validation error", func(t *testing.T) {
+		c := qt.New(t)
+
+		for _, status := range []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict} {
+			err := &Error{
+				HTTPStatusCode: status,
+				ErrorDetail: &ErrorDetail{Errors: map[int]map[string][]string{
+					0: {"external_id": {"value_already_exist"}},
+				}},
+			}
+
+			var validationErr *ValidationError
+			c.Assert(errors.As(err, &validationErr), qt.IsFalse, qt.Commentf("status %d", status))
+		}
+	})
+}
+
+func TestIsRetryable(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(IsRetryable(&Error{HTTPStatusCode: http.StatusServiceUnavailable}), qt.IsTrue)
+	c.Assert(IsRetryable(fmt.Errorf("wrapped: %w", &Error{HTTPStatusCode: http.StatusTooManyRequests})), qt.IsTrue)
+	c.Assert(IsRetryable(&Error{Err: errors.New("connection reset")}), qt.IsTrue)
+	c.Assert(IsRetryable(&Error{Err: context.Canceled}), qt.IsFalse)
+	c.Assert(IsRetryable(&Error{HTTPStatusCode: http.StatusUnprocessableEntity}), qt.IsFalse)
+	c.Assert(IsRetryable(errors.New("not an api error")), qt.IsFalse)
+	c.Assert(IsRetryable(nil), qt.IsFalse)
+}
+
+func TestErrorFromClient(t *testing.T) {
+	c := qt.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"status":422,"error":"Unprocessable Entity","code":"validation_errors","error_details":{"code":["value_already_exist"]}}`))
+	}))
+	c.Cleanup(server.Close)
+
+	client := New().SetBaseURL(server.URL).SetApiKey("test_api_key")
+	_, clientErr := client.Tax().Create(context.Background(), &TaxInput{Code: "vat"})
+	c.Assert(clientErr == nil, qt.IsFalse)
+
+	var err error = clientErr
+	c.Assert(errors.Is(err, ErrValidation), qt.IsTrue)
+	c.Assert(errors.Is(err, ErrorCodeAlreadyExist), qt.IsTrue)
+
+	var validationErr *ValidationError
+	c.Assert(errors.As(err, &validationErr), qt.IsTrue)
+	c.Assert(validationErr.Has("code", ErrorCodeAlreadyExist), qt.IsTrue)
+}
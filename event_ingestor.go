@@ -0,0 +1,456 @@
+package subrow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type BackpressurePolicy string
+
+const (
+	// BackpressureBlock makes Enqueue wait for room in the queue.
+	BackpressureBlock BackpressurePolicy = "block"
+	// BackpressureDrop discards events that do not fit in the queue.
+	BackpressureDrop BackpressurePolicy = "drop"
+	// BackpressureSpill hands events that do not fit in the queue to Spill.
+	BackpressureSpill BackpressurePolicy = "spill"
+)
+
+const (
+	defaultIngestQueueSize     int           = 10000
+	defaultIngestBatchSize     int           = 100
+	defaultIngestFlushInterval time.Duration = time.Second
+	defaultSpoolReplayInterval time.Duration = 30 * time.Second
+)
+
+var (
+	ErrIngestorClosed  = errors.New("subrow: event ingestor is closed")
+	ErrIngestQueueFull = errors.New("subrow: event ingestor queue is full")
+)
+
+// EventFailure describes an event the API did not accept. Details holds the
+// validation errors of the event's row when the API reported any.
+type EventFailure struct {
+	Event   EventInput
+	Err     *Error
+	Details map[string][]string
+}
+
+type EventIngestorConfig struct {
+	QueueSize     int
+	BatchSize     int
+	FlushInterval time.Duration
+	// Flushers is the number of batches sent concurrently.
+	Flushers     int
+	Backpressure BackpressurePolicy
+
+	// Spill receives events rejected by a full queue under BackpressureSpill,
+	// and batches that could not be delivered because of a transient error.
+	Spill func(events []EventInput) error
+	// OnFailure is called for every event that was not ingested.
+	OnFailure func(failure EventFailure)
+	// OnDrop is called for every event discarded under BackpressureDrop.
+	OnDrop func(event EventInput)
+
+	// Spool persists spilled events on disk. When set, it is used as the
+	// default Spill and the ingestor replays it every SpoolReplayInterval.
+	Spool               *EventSpool
+	SpoolReplayInterval time.Duration
+}
+
+type EventIngestorStats struct {
+	Enqueued int64
+	Sent     int64
+	Failed   int64
+	Dropped  int64
+	Spilled  int64
+}
+
+// EventIngestor buffers events in memory and sends them in batches to the
+// events/batch endpoint of the ingest service.
+type EventIngestor struct {
+	client *Client
+	config EventIngestorConfig
+
+	queue   chan EventInput
+	batches chan []EventInput
+	flushes chan chan struct{}
+
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	replayDone chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+	// closing is closed when Close is called, to release the calls blocked
+	// while holding mu.
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	inflightMu sync.Mutex
+	inflight   int
+	idle       chan struct{}
+
+	enqueued atomic.Int64
+	sent     atomic.Int64
+	failed   atomic.Int64
+	dropped  atomic.Int64
+	spilled  atomic.Int64
+}
+
+func NewEventIngestor(client *Client, config EventIngestorConfig) *EventIngestor {
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultIngestQueueSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultIngestBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultIngestFlushInterval
+	}
+	if config.Flushers <= 0 {
+		config.Flushers = 1
+	}
+	if config.Backpressure == "" {
+		config.Backpressure = BackpressureBlock
+	}
+	if config.Spool != nil && config.Spill == nil {
+		config.Spill = config.Spool.Spill
+	}
+	if config.SpoolReplayInterval <= 0 {
+		config.SpoolReplayInterval = defaultSpoolReplayInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ei := &EventIngestor{
+		client:  client,
+		config:  config,
+		queue:   make(chan EventInput, config.QueueSize),
+		batches: make(chan []EventInput, config.Flushers),
+		flushes: make(chan chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+		idle:    make(chan struct{}),
+		closing: make(chan struct{}),
+	}
+	close(ei.idle)
+
+	ei.wg.Add(1 + config.Flushers)
+	go ei.dispatch()
+	for i := 0; i < config.Flushers; i++ {
+		go ei.flusher()
+	}
+
+	if config.Spool != nil {
+		ei.replayDone = make(chan struct{})
+		go ei.replaySpool()
+	}
+
+	return ei
+}
+
+// Enqueue adds an event to the queue. What happens when the queue is full
+// depends on the configured BackpressurePolicy. An Enqueue blocked on a full
+// queue returns ErrIngestorClosed when the ingestor is closed.
+func (ei *EventIngestor) Enqueue(ctx context.Context, event EventInput) error {
+	ei.mu.RLock()
+	defer ei.mu.RUnlock()
+
+	if ei.closed {
+		return ErrIngestorClosed
+	}
+
+	select {
+	case ei.queue <- event:
+		ei.enqueued.Add(1)
+		return nil
+	default:
+	}
+
+	switch ei.config.Backpressure {
+	case BackpressureDrop:
+		ei.dropped.Add(1)
+		ei.client.telemetry.eventsDroppedAdd(ctx, 1, dropReasonQueueFull)
+		if ei.config.OnDrop != nil {
+			ei.config.OnDrop(event)
+		}
+		return ErrIngestQueueFull
+	case BackpressureSpill:
+		if ei.config.Spill == nil {
+			return ErrIngestQueueFull
+		}
+		if err := ei.config.Spill([]EventInput{event}); err != nil {
+			return err
+		}
+		ei.spilled.Add(1)
+		return nil
+	}
+
+	select {
+	case ei.queue <- event:
+		ei.enqueued.Add(1)
+		return nil
+	case <-ei.closing:
+		return ErrIngestorClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Flush sends every event enqueued so far and waits until they have been
+// delivered or ctx is done.
+func (ei *EventIngestor) Flush(ctx context.Context) error {
+	ei.mu.RLock()
+	if ei.closed {
+		ei.mu.RUnlock()
+		return ErrIngestorClosed
+	}
+
+	dispatched := make(chan struct{})
+	select {
+	case ei.flushes <- dispatched:
+	case <-ei.closing:
+		ei.mu.RUnlock()
+		return ErrIngestorClosed
+	case <-ctx.Done():
+		ei.mu.RUnlock()
+		return ctx.Err()
+	}
+	ei.mu.RUnlock()
+
+	select {
+	case <-dispatched:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return ei.waitIdle(ctx)
+}
+
+// Close stops accepting events, flushes the queue and waits for the
+// flushers to finish. When ctx is done first, in-flight requests are
+// canceled and ctx.Err() is returned.
+func (ei *EventIngestor) Close(ctx context.Context) error {
+	ei.closeOnce.Do(func() { close(ei.closing) })
+	ei.mu.Lock()
+	if ei.closed {
+		ei.mu.Unlock()
+		return ErrIngestorClosed
+	}
+	ei.closed = true
+	close(ei.queue)
+	ei.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		ei.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		ei.cancel()
+	case <-ctx.Done():
+		ei.cancel()
+		return ctx.Err()
+	}
+
+	if ei.replayDone != nil {
+		<-ei.replayDone
+	}
+
+	return nil
+}
+
+func (ei *EventIngestor) Stats() EventIngestorStats {
+	return EventIngestorStats{
+		Enqueued: ei.enqueued.Load(),
+		Sent:     ei.sent.Load(),
+		Failed:   ei.failed.Load(),
+		Dropped:  ei.dropped.Load(),
+		Spilled:  ei.spilled.Load(),
+	}
+}
+
+func (ei *EventIngestor) dispatch() {
+	defer ei.wg.Done()
+	defer close(ei.batches)
+
+	ticker := time.NewTicker(ei.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]EventInput, 0, ei.config.BatchSize)
+	emit := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ei.addInflight(1)
+		ei.batches <- batch
+		batch = make([]EventInput, 0, ei.config.BatchSize)
+	}
+
+	for {
+		select {
+		case event, ok := <-ei.queue:
+			if !ok {
+				emit()
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= ei.config.BatchSize {
+				emit()
+			}
+		case <-ticker.C:
+			emit()
+		case dispatched := <-ei.flushes:
+			for pending := len(ei.queue); pending > 0; pending-- {
+				batch = append(batch, <-ei.queue)
+				if len(batch) >= ei.config.BatchSize {
+					emit()
+				}
+			}
+			emit()
+			close(dispatched)
+		}
+	}
+}
+
+func (ei *EventIngestor) flusher() {
+	defer ei.wg.Done()
+
+	for batch := range ei.batches {
+		ei.send(batch)
+		ei.addInflight(-1)
+	}
+}
+
+func (ei *EventIngestor) send(batch []EventInput) {
+	err := ei.deliver(ei.ctx, batch)
+	if err == nil {
+		return
+	}
+
+	if isRetryableError(err) && ei.config.Spill != nil && ei.config.Spill(batch) == nil {
+		ei.spilled.Add(int64(len(batch)))
+		return
+	}
+
+	ei.fail(batch, err, nil)
+}
+
+// deliver sends a batch and reports the rows rejected by validation through
+// OnFailure. The error is returned only when the batch as a whole could not
+// be delivered.
+func (ei *EventIngestor) deliver(ctx context.Context, batch []EventInput) *Error {
+	_, err := ei.client.Event().Batch(ctx, &batch)
+	if err == nil {
+		ei.sent.Add(int64(len(batch)))
+		ei.client.telemetry.eventsIngestedAdd(ctx, len(batch))
+		return nil
+	}
+
+	if err.ErrorDetail == nil || err.HTTPStatusCode != http.StatusUnprocessableEntity {
+		return err
+	}
+
+	if !err.ErrorDetail.Multiple {
+		details, _ := err.ErrorDetail.Details()
+		ei.fail(batch, err, details)
+		return nil
+	}
+
+	// The API rejects the whole batch when any row is invalid: report the
+	// invalid rows and send the valid ones again on their own.
+	valid := make([]EventInput, 0, len(batch))
+	for i, event := range batch {
+		details, _ := err.ErrorDetail.DetailsForRow(i)
+		if details == nil {
+			valid = append(valid, event)
+			continue
+		}
+		ei.fail([]EventInput{event}, err, details)
+	}
+
+	if len(valid) > 0 && len(valid) < len(batch) {
+		return ei.deliver(ctx, valid)
+	} else if len(valid) > 0 {
+		ei.fail(valid, err, nil)
+	}
+
+	return nil
+}
+
+// replaySpool sends the spooled events on start and then periodically, so
+// events spilled during an outage are ingested once the API is reachable.
+func (ei *EventIngestor) replaySpool() {
+	defer close(ei.replayDone)
+
+	ticker := time.NewTicker(ei.config.SpoolReplayInterval)
+	defer ticker.Stop()
+
+	for {
+		_ = ei.config.Spool.Replay(ei.ctx, func(ctx context.Context, events []EventInput) error {
+			if err := ei.deliver(ctx, events); err != nil {
+				return err
+			}
+			return nil
+		})
+
+		select {
+		case <-ticker.C:
+		case <-ei.ctx.Done():
+			return
+		}
+	}
+}
+
+func (ei *EventIngestor) fail(events []EventInput, err *Error, details map[string][]string) {
+	ei.failed.Add(int64(len(events)))
+	reason := dropReasonUndelivered
+	if details != nil {
+		reason = dropReasonRejected
+	}
+	ei.client.telemetry.eventsDroppedAdd(ei.ctx, len(events), reason)
+	if ei.config.OnFailure == nil {
+		return
+	}
+
+	for _, event := range events {
+		ei.config.OnFailure(EventFailure{
+			Event:   event,
+			Err:     err,
+			Details: details,
+		})
+	}
+}
+
+func (ei *EventIngestor) addInflight(delta int) {
+	ei.inflightMu.Lock()
+	defer ei.inflightMu.Unlock()
+
+	if ei.inflight == 0 && delta > 0 {
+		ei.idle = make(chan struct{})
+	}
+	ei.inflight += delta
+	if ei.inflight == 0 {
+		close(ei.idle)
+	}
+}
+
+func (ei *EventIngestor) waitIdle(ctx context.Context) error {
+	ei.inflightMu.Lock()
+	idle := ei.idle
+	ei.inflightMu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
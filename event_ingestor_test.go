@@ -0,0 +1,266 @@
+package subrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]EventInput
+}
+
+func (br *batchRecorder) transactionIDs() []string {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	var ids []string
+	for _, batch := range br.batches {
+		for _, event := range batch {
+			ids = append(ids, event.TransactionID)
+		}
+	}
+
+	return ids
+}
+
+func ingestServer(c *qt.C, recorder *batchRecorder, invalid map[string]bool) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, qt.Equals, "/api/v1/events/batch")
+
+		var params struct {
+			Events []EventInput `json:"events"`
+		}
+		c.Check(json.NewDecoder(r.Body).Decode(&params), qt.IsNil)
+		w.Header().Set("Content-Type", "application/json")
+
+		details := map[int]map[string][]string{}
+		for i, event := range params.Events {
+			if invalid[event.TransactionID] {
+				details[i] = map[string][]string{"code": {"does_not_exist"}}
+			}
+		}
+
+		if len(details) > 0 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"status":        422,
+				"error":         "Unprocessable Entity",
+				"code":          "validation_errors",
+				"error_details": details,
+			})
+			return
+		}
+
+		recorder.mu.Lock()
+		recorder.batches = append(recorder.batches, params.Events)
+		recorder.mu.Unlock()
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"events": params.Events})
+	}))
+	c.Cleanup(server.Close)
+
+	return server
+}
+
+func TestEventIngestor(t *testing.T) {
+	t.Run("When events are flushed by size and on close", func(t *testing.T) {
+		c := qt.New(t)
+
+		recorder := &batchRecorder{}
+		server := ingestServer(c, recorder, nil)
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key")
+
+		ingestor := NewEventIngestor(client, EventIngestorConfig{
+			BatchSize:     2,
+			FlushInterval: time.Hour,
+			Flushers:      2,
+		})
+
+		for _, id := range []string{"tr_1", "tr_2", "tr_3"} {
+			c.Assert(ingestor.Enqueue(context.Background(), EventInput{TransactionID: id, Code: "calls"}), qt.IsNil)
+		}
+
+		c.Assert(ingestor.Close(context.Background()), qt.IsNil)
+		c.Assert(recorder.transactionIDs(), qt.ContentEquals, []string{"tr_1", "tr_2", "tr_3"})
+		c.Assert(ingestor.Stats().Sent, qt.Equals, int64(3))
+		c.Assert(ingestor.Enqueue(context.Background(), EventInput{}), qt.Equals, ErrIngestorClosed)
+	})
+
+	t.Run("When Flush is called", func(t *testing.T) {
+		c := qt.New(t)
+
+		recorder := &batchRecorder{}
+		server := ingestServer(c, recorder, nil)
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key")
+
+		ingestor := NewEventIngestor(client, EventIngestorConfig{FlushInterval: time.Hour})
+		defer ingestor.Close(context.Background())
+
+		c.Assert(ingestor.Enqueue(context.Background(), EventInput{TransactionID: "tr_1"}), qt.IsNil)
+		c.Assert(ingestor.Flush(context.Background()), qt.IsNil)
+		c.Assert(recorder.transactionIDs(), qt.DeepEquals, []string{"tr_1"})
+	})
+
+	t.Run("When some rows are invalid", func(t *testing.T) {
+		c := qt.New(t)
+
+		recorder := &batchRecorder{}
+		server := ingestServer(c, recorder, map[string]bool{"tr_2": true})
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key")
+
+		var mu sync.Mutex
+		var failures []EventFailure
+		ingestor := NewEventIngestor(client, EventIngestorConfig{
+			FlushInterval: time.Hour,
+			OnFailure: func(failure EventFailure) {
+				mu.Lock()
+				defer mu.Unlock()
+				failures = append(failures, failure)
+			},
+		})
+
+		for _, id := range []string{"tr_1", "tr_2", "tr_3"} {
+			c.Assert(ingestor.Enqueue(context.Background(), EventInput{TransactionID: id}), qt.IsNil)
+		}
+		c.Assert(ingestor.Close(context.Background()), qt.IsNil)
+
+		c.Assert(recorder.transactionIDs(), qt.DeepEquals, []string{"tr_1", "tr_3"})
+		c.Assert(failures, qt.HasLen, 1)
+		c.Assert(failures[0].Event.TransactionID, qt.Equals, "tr_2")
+		c.Assert(failures[0].Details, qt.DeepEquals, map[string][]string{"code": {"does_not_exist"}})
+		c.Assert(ingestor.Stats().Failed, qt.Equals, int64(1))
+	})
+
+	t.Run("When the queue is full and events are dropped", func(t *testing.T) {
+		c := qt.New(t)
+
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"events":[]}`))
+		}))
+		defer server.Close()
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key")
+
+		var dropped []string
+		ingestor := NewEventIngestor(client, EventIngestorConfig{
+			QueueSize:     1,
+			BatchSize:     1,
+			FlushInterval: time.Hour,
+			Backpressure:  BackpressureDrop,
+			OnDrop: func(event EventInput) {
+				dropped = append(dropped, event.TransactionID)
+			},
+		})
+
+		var err error
+		for i := 0; i < 10 && err == nil; i++ {
+			err = ingestor.Enqueue(context.Background(), EventInput{TransactionID: "tr"})
+		}
+		c.Assert(err, qt.Equals, ErrIngestQueueFull)
+		c.Assert(dropped, qt.HasLen, 1)
+
+		close(release)
+		c.Assert(ingestor.Close(context.Background()), qt.IsNil)
+		c.Assert(ingestor.Stats().Dropped, qt.Equals, int64(1))
+	})
+
+	t.Run("When Close times out while Enqueue is blocked", func(t *testing.T) {
+		c := qt.New(t)
+
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"events":[]}`))
+		}))
+		defer server.Close()
+		defer close(release)
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key")
+
+		ingestor := NewEventIngestor(client, EventIngestorConfig{
+			QueueSize:     1,
+			BatchSize:     1,
+			FlushInterval: time.Hour,
+		})
+
+		// The flusher and the dispatcher hold the first events, the queue
+		// the next one, and the last Enqueue blocks.
+		blocked := make(chan error)
+		go func() {
+			for {
+				if err := ingestor.Enqueue(context.Background(), EventInput{TransactionID: "tr"}); err != nil {
+					blocked <- err
+					return
+				}
+			}
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		c.Assert(ingestor.Close(ctx), qt.Equals, context.DeadlineExceeded)
+		c.Assert(time.Since(start) < time.Second, qt.IsTrue)
+		c.Assert(<-blocked, qt.Equals, ErrIngestorClosed)
+	})
+}
+
+func TestEventIngestorSpool(t *testing.T) {
+	c := qt.New(t)
+
+	var available bool
+	var mu sync.Mutex
+	recorder := &batchRecorder{}
+	healthy := ingestServer(c, recorder, nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		up := available
+		mu.Unlock()
+
+		if !up {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"status":503,"error":"Service Unavailable"}`))
+			return
+		}
+		healthy.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	client := New().SetBaseURL(server.URL).SetApiKey("test_api_key")
+
+	spool, err := OpenEventSpool(EventSpoolConfig{Dir: c.TempDir()})
+	c.Assert(err, qt.IsNil)
+
+	ingestor := NewEventIngestor(client, EventIngestorConfig{
+		FlushInterval:       time.Hour,
+		Spool:               spool,
+		SpoolReplayInterval: 10 * time.Millisecond,
+	})
+
+	c.Assert(ingestor.Enqueue(context.Background(), EventInput{TransactionID: "tr_1"}), qt.IsNil)
+	c.Assert(ingestor.Flush(context.Background()), qt.IsNil)
+	c.Assert(ingestor.Stats().Spilled, qt.Equals, int64(1))
+	c.Assert(spool.Size() > 0, qt.IsTrue)
+
+	mu.Lock()
+	available = true
+	mu.Unlock()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for spool.Size() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	c.Assert(ingestor.Close(context.Background()), qt.IsNil)
+	c.Assert(recorder.transactionIDs(), qt.DeepEquals, []string{"tr_1"})
+}
@@ -0,0 +1,415 @@
+package subrow
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SpoolSyncPolicy string
+
+const (
+	// SpoolSyncAlways fsyncs the active segment after every append.
+	SpoolSyncAlways SpoolSyncPolicy = "always"
+	// SpoolSyncInterval fsyncs the active segment at most once per SyncInterval.
+	SpoolSyncInterval SpoolSyncPolicy = "interval"
+	// SpoolSyncNever leaves flushing to the operating system.
+	SpoolSyncNever SpoolSyncPolicy = "never"
+)
+
+const (
+	spoolSegmentExt          string        = ".spool"
+	spoolRecordHeaderSize    int           = 8
+	spoolMaxRecordSize       uint32        = 16 << 20
+	defaultSpoolSegmentSize  int64         = 8 << 20
+	defaultSpoolMaxDiskBytes int64         = 1 << 30
+	defaultSpoolSyncInterval time.Duration = time.Second
+	defaultSpoolReplayBatch  int           = 100
+)
+
+var (
+	ErrSpoolFull   = errors.New("subrow: event spool is full")
+	ErrSpoolClosed = errors.New("subrow: event spool is closed")
+)
+
+type EventSpoolConfig struct {
+	Dir string
+	// SegmentSize is the size after which the active segment is sealed and
+	// a new one is started.
+	SegmentSize int64
+	// MaxDiskBytes caps the size of all segments together. Appends that
+	// would exceed it fail with ErrSpoolFull.
+	MaxDiskBytes int64
+	SyncPolicy   SpoolSyncPolicy
+	SyncInterval time.Duration
+	// ReplayBatchSize is the number of events handed to the send function
+	// of Replay at once.
+	ReplayBatchSize int
+}
+
+type spoolSegment struct {
+	id   uint64
+	path string
+	size int64
+}
+
+// EventSpool is a write-ahead log of events waiting to be ingested. Events
+// are appended to segment files in Dir; a segment is deleted once every
+// event it holds has been acknowledged by Replay. Spooled events survive
+// process restarts: OpenEventSpool picks up the segments left on disk.
+//
+// Delivery is at-least-once. A segment that is only partly acknowledged is
+// replayed in full, and the API deduplicates events by transaction ID.
+type EventSpool struct {
+	config EventSpoolConfig
+
+	mu         sync.Mutex
+	sealed     []spoolSegment
+	active     *os.File
+	activeSeg  spoolSegment
+	nextID     uint64
+	totalBytes int64
+	lastSync   time.Time
+	closed     bool
+
+	replayMu sync.Mutex
+}
+
+func OpenEventSpool(config EventSpoolConfig) (*EventSpool, error) {
+	if config.Dir == "" {
+		return nil, errors.New("subrow: event spool directory is required")
+	}
+	if config.SegmentSize <= 0 {
+		config.SegmentSize = defaultSpoolSegmentSize
+	}
+	if config.MaxDiskBytes <= 0 {
+		config.MaxDiskBytes = defaultSpoolMaxDiskBytes
+	}
+	if config.SyncPolicy == "" {
+		config.SyncPolicy = SpoolSyncAlways
+	}
+	if config.SyncInterval <= 0 {
+		config.SyncInterval = defaultSpoolSyncInterval
+	}
+	if config.ReplayBatchSize <= 0 {
+		config.ReplayBatchSize = defaultSpoolReplayBatch
+	}
+
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(config.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	spool := &EventSpool{config: config, nextID: 1}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, spoolSegmentExt) {
+			continue
+		}
+
+		id, parseErr := strconv.ParseUint(strings.TrimSuffix(name, spoolSegmentExt), 10, 64)
+		if parseErr != nil {
+			continue
+		}
+
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			return nil, infoErr
+		}
+
+		spool.sealed = append(spool.sealed, spoolSegment{
+			id:   id,
+			path: filepath.Join(config.Dir, name),
+			size: info.Size(),
+		})
+		spool.totalBytes += info.Size()
+		spool.nextID = max(spool.nextID, id+1)
+	}
+
+	sort.Slice(spool.sealed, func(i, j int) bool {
+		return spool.sealed[i].id < spool.sealed[j].id
+	})
+
+	return spool, nil
+}
+
+// Append writes events to the active segment, honoring the sync policy.
+func (s *EventSpool) Append(events ...EventInput) error {
+	var buf []byte
+	for _, event := range events {
+		record, err := encodeSpoolRecord(event)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, record...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSpoolClosed
+	}
+
+	size := int64(len(buf))
+	if s.totalBytes+size > s.config.MaxDiskBytes {
+		return ErrSpoolFull
+	}
+
+	if s.active != nil && s.activeSeg.size > 0 && s.activeSeg.size+size > s.config.SegmentSize {
+		if err := s.sealActive(); err != nil {
+			return err
+		}
+	}
+
+	if s.active == nil {
+		if err := s.openActive(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.active.Write(buf)
+	s.activeSeg.size += int64(n)
+	s.totalBytes += int64(n)
+	if err != nil {
+		// Readers stop at a torn record, so never append after one.
+		_ = s.sealActive()
+		return err
+	}
+
+	switch s.config.SyncPolicy {
+	case SpoolSyncAlways:
+		return s.active.Sync()
+	case SpoolSyncInterval:
+		if time.Since(s.lastSync) >= s.config.SyncInterval {
+			s.lastSync = time.Now()
+			return s.active.Sync()
+		}
+	}
+
+	return nil
+}
+
+// Spill appends events to the spool. It matches EventIngestorConfig.Spill.
+func (s *EventSpool) Spill(events []EventInput) error {
+	return s.Append(events...)
+}
+
+// Replay hands every spooled event to send, oldest first and in batches of
+// ReplayBatchSize, and deletes each segment once all of its batches were
+// sent without error. It stops at the first error and returns it.
+func (s *EventSpool) Replay(ctx context.Context, send func(ctx context.Context, events []EventInput) error) error {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		segment, ok, err := s.oldestSegment()
+		if err != nil || !ok {
+			return err
+		}
+
+		events, err := readSpoolSegment(segment.path)
+		if err != nil {
+			return err
+		}
+
+		for start := 0; start < len(events); start += s.config.ReplayBatchSize {
+			end := min(start+s.config.ReplayBatchSize, len(events))
+			if err := send(ctx, events[start:end]); err != nil {
+				return err
+			}
+		}
+
+		if err := s.removeSegment(segment); err != nil {
+			return err
+		}
+	}
+}
+
+// Size returns the number of bytes currently held on disk.
+func (s *EventSpool) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.totalBytes
+}
+
+func (s *EventSpool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if s.active == nil {
+		return nil
+	}
+
+	return s.sealActive()
+}
+
+// oldestSegment returns the oldest sealed segment, sealing the active one
+// first when nothing else is left to replay.
+func (s *EventSpool) oldestSegment() (spoolSegment, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.sealed) == 0 && s.active != nil && s.activeSeg.size > 0 {
+		if err := s.sealActive(); err != nil {
+			return spoolSegment{}, false, err
+		}
+	}
+
+	if len(s.sealed) == 0 {
+		return spoolSegment{}, false, nil
+	}
+
+	return s.sealed[0], true, nil
+}
+
+func (s *EventSpool) removeSegment(segment spoolSegment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(segment.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	for i, sealed := range s.sealed {
+		if sealed.id == segment.id {
+			s.sealed = append(s.sealed[:i], s.sealed[i+1:]...)
+			s.totalBytes -= sealed.size
+			break
+		}
+	}
+
+	return nil
+}
+
+func (s *EventSpool) openActive() error {
+	segment := spoolSegment{
+		id:   s.nextID,
+		path: filepath.Join(s.config.Dir, fmt.Sprintf("%020d%s", s.nextID, spoolSegmentExt)),
+	}
+
+	file, err := os.OpenFile(segment.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if s.config.SyncPolicy == SpoolSyncAlways {
+		if err := syncDir(s.config.Dir); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	s.nextID++
+	s.active = file
+	s.activeSeg = segment
+
+	return nil
+}
+
+func (s *EventSpool) sealActive() error {
+	syncErr := s.active.Sync()
+	closeErr := s.active.Close()
+
+	if s.activeSeg.size > 0 {
+		s.sealed = append(s.sealed, s.activeSeg)
+	} else {
+		_ = os.Remove(s.activeSeg.path)
+	}
+	s.active = nil
+
+	return errors.Join(syncErr, closeErr)
+}
+
+// A record is a big-endian uint32 length, a CRC-32 of the payload and the
+// JSON encoded event.
+func encodeSpoolRecord(event EventInput) ([]byte, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	record := make([]byte, spoolRecordHeaderSize, spoolRecordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+
+	return append(record, payload...), nil
+}
+
+// readSpoolSegment decodes every complete record of a segment. A torn or
+// corrupted tail, left by a crash during a write, ends the segment.
+func readSpoolSegment(path string) ([]EventInput, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	header := make([]byte, spoolRecordHeaderSize)
+
+	var events []EventInput
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			break
+		}
+
+		size := binary.BigEndian.Uint32(header[0:4])
+		if size > spoolMaxRecordSize {
+			break
+		}
+
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			break
+		}
+
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			break
+		}
+
+		var event EventInput
+		if err := json.Unmarshal(payload, &event); err != nil {
+			break
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return file.Sync()
+}
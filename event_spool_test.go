@@ -0,0 +1,93 @@
+package subrow
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func replayAll(c *qt.C, spool *EventSpool) []string {
+	var ids []string
+	err := spool.Replay(context.Background(), func(ctx context.Context, events []EventInput) error {
+		for _, event := range events {
+			ids = append(ids, event.TransactionID)
+		}
+		return nil
+	})
+	c.Assert(err, qt.IsNil)
+
+	return ids
+}
+
+func TestEventSpool(t *testing.T) {
+	t.Run("When the process restarts", func(t *testing.T) {
+		c := qt.New(t)
+		dir := c.TempDir()
+
+		spool, err := OpenEventSpool(EventSpoolConfig{Dir: dir, SegmentSize: 64})
+		c.Assert(err, qt.IsNil)
+		for _, id := range []string{"tr_1", "tr_2", "tr_3"} {
+			c.Assert(spool.Append(EventInput{TransactionID: id, Code: "calls"}), qt.IsNil)
+		}
+		c.Assert(spool.Close(), qt.IsNil)
+
+		segments, _ := filepath.Glob(filepath.Join(dir, "*.spool"))
+		c.Assert(len(segments) > 1, qt.IsTrue)
+
+		reopened, err := OpenEventSpool(EventSpoolConfig{Dir: dir})
+		c.Assert(err, qt.IsNil)
+		c.Assert(replayAll(c, reopened), qt.DeepEquals, []string{"tr_1", "tr_2", "tr_3"})
+		c.Assert(reopened.Size(), qt.Equals, int64(0))
+
+		segments, _ = filepath.Glob(filepath.Join(dir, "*.spool"))
+		c.Assert(segments, qt.HasLen, 0)
+	})
+
+	t.Run("When sending fails", func(t *testing.T) {
+		c := qt.New(t)
+
+		spool, err := OpenEventSpool(EventSpoolConfig{Dir: c.TempDir()})
+		c.Assert(err, qt.IsNil)
+		c.Assert(spool.Append(EventInput{TransactionID: "tr_1"}), qt.IsNil)
+
+		sendErr := errors.New("unreachable")
+		err = spool.Replay(context.Background(), func(ctx context.Context, events []EventInput) error {
+			return sendErr
+		})
+		c.Assert(err, qt.Equals, sendErr)
+		c.Assert(replayAll(c, spool), qt.DeepEquals, []string{"tr_1"})
+	})
+
+	t.Run("When the disk budget is exhausted", func(t *testing.T) {
+		c := qt.New(t)
+
+		spool, err := OpenEventSpool(EventSpoolConfig{Dir: c.TempDir(), MaxDiskBytes: 100})
+		c.Assert(err, qt.IsNil)
+		c.Assert(spool.Append(EventInput{TransactionID: "tr_1"}), qt.IsNil)
+		c.Assert(spool.Append(EventInput{TransactionID: "tr_2", Code: "a_code_long_enough_to_overflow_the_budget"}), qt.Equals, ErrSpoolFull)
+	})
+
+	t.Run("When a segment has a torn tail", func(t *testing.T) {
+		c := qt.New(t)
+		dir := c.TempDir()
+
+		spool, err := OpenEventSpool(EventSpoolConfig{Dir: dir})
+		c.Assert(err, qt.IsNil)
+		c.Assert(spool.Append(EventInput{TransactionID: "tr_1"}, EventInput{TransactionID: "tr_2"}), qt.IsNil)
+		c.Assert(spool.Close(), qt.IsNil)
+
+		segments, _ := filepath.Glob(filepath.Join(dir, "*.spool"))
+		c.Assert(segments, qt.HasLen, 1)
+		info, err := os.Stat(segments[0])
+		c.Assert(err, qt.IsNil)
+		c.Assert(os.Truncate(segments[0], info.Size()-3), qt.IsNil)
+
+		reopened, err := OpenEventSpool(EventSpoolConfig{Dir: dir})
+		c.Assert(err, qt.IsNil)
+		c.Assert(replayAll(c, reopened), qt.DeepEquals, []string{"tr_1"})
+	})
+}
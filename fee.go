@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +18,7 @@ const (
 	FeeItemSubscription FeeType = "subscription"
 	FeeItemCharge       FeeType = "charge"
 	FeeItemAddOn        FeeType = "add_on"
+	FeeItemCommitment   FeeType = "commitment"
 )
 
 const (
@@ -31,6 +33,7 @@ const (
 	FeeBillableMetric    FeeItemType = "BillableMetric"
 	FeeSubscription      FeeItemType = "Subscription"
 	FeeWalletTransaction FeeItemType = "WalletTransaction"
+	FeeCommitment        FeeItemType = "Commitment"
 )
 
 type FeeRequest struct {
@@ -224,6 +227,26 @@ func (fr *FeeRequest) GetList(ctx context.Context, feeListInput *FeeListInput) (
 	return feeResult, nil
 }
 
+// All iterates over every fee matching the input, fetching pages as needed.
+func (fr *FeeRequest) All(ctx context.Context, feeListInput *FeeListInput, opts ...IteratorOption) iter.Seq2[Fee, error] {
+	input := FeeListInput{}
+	if feeListInput != nil {
+		input = *feeListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]Fee, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := fr.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.Fees, result.Meta, nil
+	}, opts)
+}
+
 func (fr *FeeRequest) Delete(ctx context.Context, feeID string) (*Fee, *Error) {
 	subPath := fmt.Sprintf("%s/%s", "fees", feeID)
 	clientRequest := &ClientRequest{
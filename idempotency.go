@@ -0,0 +1,54 @@
+package subrow
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey attaches an Idempotency-Key to every mutating request
+// made with the returned context, e.g.
+//
+//	ctx = subrow.WithIdempotencyKey(ctx, "invoice-"+orderID)
+//	invoice, err := client.Invoice().Create(ctx, input)
+//
+// Use one key per logical operation and reuse it when retrying that
+// operation so the API can deduplicate it.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key, ok && key != ""
+}
+
+func NewIdempotencyKey() string {
+	return uuid.NewString()
+}
+
+// idempotencyKey resolves the key of a logical operation: the request's own
+// key first, then the context's, then a generated one when the client runs in
+// automatic mode. Reads never carry a key.
+func (c *Client) idempotencyKey(ctx context.Context, method string, cr *ClientRequest) string {
+	if method == http.MethodGet {
+		return ""
+	}
+
+	if cr.IdempotencyKey != "" {
+		return cr.IdempotencyKey
+	}
+
+	if key, ok := IdempotencyKeyFromContext(ctx); ok {
+		return key
+	}
+
+	if c.AutoIdempotencyKey {
+		return NewIdempotencyKey()
+	}
+
+	return ""
+}
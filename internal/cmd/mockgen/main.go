@@ -0,0 +1,260 @@
+// Command mockgen writes a mock for every interface declared in a file of
+// package subrow. A mock has a Func field per method, records its calls and
+// returns a "not implemented" error for methods whose Func is not set.
+// Accessors returning another interface of the file return its mock instead.
+//
+//	go run ./internal/cmd/mockgen -source api.go -destination api_mock.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	source := flag.String("source", "", "file declaring the interfaces")
+	destination := flag.String("destination", "", "file to write the mocks to")
+	flag.Parse()
+
+	if *source == "" || *destination == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, *source, nil, parser.SkipObjectResolution)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g := &generator{fset: fset, interfaces: map[string]*ast.InterfaceType{}}
+	var names []string
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if iface, ok := typeSpec.Type.(*ast.InterfaceType); ok && typeSpec.Name.IsExported() {
+				g.interfaces[typeSpec.Name.Name] = iface
+				names = append(names, typeSpec.Name.Name)
+			}
+		}
+	}
+
+	g.printf("// Code generated by mockgen from %s. DO NOT EDIT.\n\n", *source)
+	g.printf("package %s\n\n", file.Name.Name)
+	g.printf("import (\n")
+	for i, spec := range file.Imports {
+		// Keep the blank lines separating import groups.
+		if i > 0 && fset.Position(spec.Pos()).Line > fset.Position(file.Imports[i-1].End()).Line+1 {
+			g.printf("\n")
+		}
+		g.printf("\t%s\n", g.node(spec))
+	}
+	g.printf(")\n")
+	for _, name := range names {
+		g.mock(name)
+	}
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		log.Fatalf("formatting mocks: %v\n%s", err, g.buf.Bytes())
+	}
+
+	if err := os.WriteFile(*destination, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type generator struct {
+	buf        bytes.Buffer
+	fset       *token.FileSet
+	interfaces map[string]*ast.InterfaceType
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) node(n ast.Node) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, g.fset, n); err != nil {
+		log.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func (g *generator) mock(name string) {
+	mockName := "Mock" + name
+	methods := g.interfaces[name].Methods.List
+
+	g.printf("\n// %s is a mock %s whose methods call the matching Func field.\n", mockName, name)
+	g.printf("type %s struct {\n\tmockCalls\n\n", mockName)
+	for _, method := range methods {
+		if accessed := g.accessor(method); accessed != "" {
+			g.printf("\t%sMock *Mock%s\n", method.Names[0].Name, accessed)
+			continue
+		}
+		g.printf("\t%sFunc %s\n", method.Names[0].Name, g.node(method.Type))
+	}
+	g.printf("}\n")
+
+	g.printf("\nvar _ %s = (*%s)(nil)\n", name, mockName)
+
+	for _, method := range methods {
+		methodName := method.Names[0].Name
+		funcType := method.Type.(*ast.FuncType)
+
+		if accessed := g.accessor(method); accessed != "" {
+			g.printf("\nfunc (m *%s) %s() %s {\n", mockName, methodName, accessed)
+			g.printf("\tm.mu.Lock()\n\tdefer m.mu.Unlock()\n\n")
+			g.printf("\tif m.%sMock == nil {\n\t\tm.%[1]sMock = &Mock%s{}\n\t}\n\n", methodName, accessed)
+			g.printf("\treturn m.%sMock\n}\n", methodName)
+			continue
+		}
+
+		params, args, call := g.params(funcType)
+		g.printf("\nfunc (m *%s) %s(%s) %s {\n", mockName, methodName, params, g.results(funcType))
+		g.printf("\tm.record(%q%s)\n", methodName, prefixed(args))
+		g.printf("\tif m.%sFunc == nil {\n", methodName)
+		g.printf("\t\t%s\n\t}\n\n", g.notImplemented(name+"."+methodName, funcType))
+		if funcType.Results == nil {
+			g.printf("\tm.%sFunc(%s)\n}\n", methodName, call)
+			continue
+		}
+		g.printf("\treturn m.%sFunc(%s)\n}\n", methodName, call)
+	}
+}
+
+// accessor returns the interface a method without parameters returns, if it
+// is declared in the source file.
+func (g *generator) accessor(method *ast.Field) string {
+	funcType := method.Type.(*ast.FuncType)
+	if len(funcType.Params.List) != 0 || funcType.Results == nil || len(funcType.Results.List) != 1 {
+		return ""
+	}
+
+	ident, ok := funcType.Results.List[0].Type.(*ast.Ident)
+	if !ok || g.interfaces[ident.Name] == nil {
+		return ""
+	}
+
+	return ident.Name
+}
+
+// params returns the parameter list of a mock method, the recorded
+// arguments and the arguments the Func is called with.
+func (g *generator) params(funcType *ast.FuncType) (string, string, string) {
+	var params, args, call []string
+	for i, field := range funcType.Params.List {
+		names := []string{}
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		if len(names) == 0 {
+			names = []string{fmt.Sprintf("p%d", i)}
+		}
+
+		for _, name := range names {
+			args = append(args, name)
+			if _, variadic := field.Type.(*ast.Ellipsis); variadic {
+				call = append(call, name+"...")
+			} else {
+				call = append(call, name)
+			}
+		}
+		params = append(params, strings.Join(names, ", ")+" "+g.node(field.Type))
+	}
+
+	return strings.Join(params, ", "), strings.Join(args, ", "), strings.Join(call, ", ")
+}
+
+func (g *generator) results(funcType *ast.FuncType) string {
+	if funcType.Results == nil {
+		return ""
+	}
+
+	var results []string
+	for _, field := range funcType.Results.List {
+		for range max(len(field.Names), 1) {
+			results = append(results, g.node(field.Type))
+		}
+	}
+	if len(results) == 1 {
+		return results[0]
+	}
+
+	return "(" + strings.Join(results, ", ") + ")"
+}
+
+// notImplemented returns the statement run when a mock method has no Func.
+// The last result, when it is an error, gets the "not implemented" error,
+// and iterators yield it.
+func (g *generator) notImplemented(method string, funcType *ast.FuncType) string {
+	if funcType.Results == nil {
+		return "return"
+	}
+
+	var values []string
+	for _, field := range funcType.Results.List {
+		count := max(len(field.Names), 1)
+		for range count {
+			values = append(values, g.zero(method, field.Type))
+		}
+	}
+
+	last := funcType.Results.List[len(funcType.Results.List)-1].Type
+	if ident, ok := last.(*ast.Ident); ok && ident.Name == "error" {
+		values[len(values)-1] = fmt.Sprintf("mockNotImplemented(%q)", method)
+	}
+	if star, ok := last.(*ast.StarExpr); ok {
+		if ident, ok := star.X.(*ast.Ident); ok && ident.Name == "Error" {
+			values[len(values)-1] = fmt.Sprintf("mockNotImplemented(%q)", method)
+		}
+	}
+
+	return "return " + strings.Join(values, ", ")
+}
+
+func (g *generator) zero(method string, expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr, *ast.ArrayType, *ast.MapType, *ast.FuncType, *ast.ChanType, *ast.InterfaceType:
+		return "nil"
+	case *ast.Ident:
+		switch t.Name {
+		case "bool":
+			return "false"
+		case "string":
+			return `""`
+		case "error":
+			return "nil"
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64", "byte", "rune":
+			return "0"
+		}
+	case *ast.IndexListExpr:
+		if g.node(t.X) == "iter.Seq2" && len(t.Indices) == 2 && g.node(t.Indices[1]) == "error" {
+			return fmt.Sprintf("func(yield func(%s, error) bool) {\n\t\t\tvar zero %[1]s\n\t\t\tyield(zero, mockNotImplemented(%q))\n\t\t}", g.node(t.Indices[0]), method)
+		}
+	}
+
+	return fmt.Sprintf("*new(%s)", g.node(expr))
+}
+
+func prefixed(args string) string {
+	if args == "" {
+		return ""
+	}
+
+	return ", " + args
+}
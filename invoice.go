@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -282,6 +283,26 @@ func (ir *InvoiceRequest) GetList(ctx context.Context, invoiceListInput *Invoice
 	return invoiceResult, nil
 }
 
+// All iterates over every invoice matching the input, fetching pages as needed.
+func (ir *InvoiceRequest) All(ctx context.Context, invoiceListInput *InvoiceListInput, opts ...IteratorOption) iter.Seq2[Invoice, error] {
+	input := InvoiceListInput{}
+	if invoiceListInput != nil {
+		input = *invoiceListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]Invoice, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := ir.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.Invoices, result.Meta, nil
+	}, opts)
+}
+
 func (ir *InvoiceRequest) Create(ctx context.Context, oneOffInput *InvoiceOneOffInput) (*Invoice, *Error) {
 	invoiceOneOffParams := &InvoiceOneOffParams{
 		Invoice: oneOffInput,
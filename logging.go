@@ -0,0 +1,213 @@
+package subrow
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	RequestIDHeader string = "X-Request-Id"
+
+	redactedValue          string = "[REDACTED]"
+	defaultLogMaxBodyBytes int    = 2048
+)
+
+// credentialHeaders are redacted whatever the RedactHeaders of LogOptions.
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "X-Api-Key"}
+
+// LogOptions configures what the Client logs about each API call.
+type LogOptions struct {
+	// Level is the level of successful calls. Failed calls are logged at
+	// slog.LevelWarn. Defaults to slog.LevelInfo.
+	Level slog.Level
+	// LogHeaders adds the request headers to every record.
+	LogHeaders bool
+	// LogBodies adds the request and response bodies to every record, with
+	// RedactFields redacted and truncated to MaxBodyBytes.
+	LogBodies    bool
+	MaxBodyBytes int
+	// RedactHeaders lists the headers whose value is never logged, on top of
+	// the Authorization and X-Api-Key headers.
+	RedactHeaders []string
+	// RedactFields lists the JSON fields whose value is never logged, at any
+	// depth of a body. Start from DefaultLogOptions to keep the customer
+	// fields redacted by default.
+	RedactFields []string
+}
+
+func DefaultLogOptions() *LogOptions {
+	return &LogOptions{
+		Level:         slog.LevelInfo,
+		MaxBodyBytes:  defaultLogMaxBodyBytes,
+		RedactHeaders: []string{"Authorization", "Cookie", "Set-Cookie"},
+		RedactFields: []string{
+			"email",
+			"phone",
+			"address_line1",
+			"address_line2",
+			"zipcode",
+			"shipping_address",
+			"tax_identification_number",
+		},
+	}
+}
+
+// SetLogger makes the client log every API call attempt to logger.
+func (c *Client) SetLogger(logger *slog.Logger) *Client {
+	c.Logger = logger
+
+	return c
+}
+
+func (c *Client) SetLogOptions(logOptions *LogOptions) *Client {
+	c.LogOptions = logOptions
+
+	return c
+}
+
+// logger returns the logger and options calls are logged with, if any.
+// Without a Logger, Debug logs every call and its bodies to stdout.
+func (c *Client) logger() (*slog.Logger, *LogOptions) {
+	options := c.LogOptions
+	if options == nil {
+		options = DefaultLogOptions()
+	}
+
+	if c.Logger != nil {
+		return c.Logger, options
+	}
+
+	if !c.Debug {
+		return nil, nil
+	}
+
+	debugOptions := *options
+	debugOptions.Level = slog.LevelDebug
+	debugOptions.LogBodies = true
+
+	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})), &debugOptions
+}
+
+func (c *Client) logAttempt(ctx context.Context, method string, path string, request *resty.Request, resp *resty.Response, attempt int, latency time.Duration, clientErr *Error) {
+	logger, options := c.logger()
+	if logger == nil {
+		return
+	}
+
+	level := options.Level
+	if clientErr != nil {
+		level = slog.LevelWarn
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("path", path),
+		slog.Int("attempt", attempt),
+		slog.Duration("latency", latency),
+	}
+
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode()))
+		if requestID := resp.Header().Get(RequestIDHeader); requestID != "" {
+			attrs = append(attrs, slog.String("request_id", requestID))
+		}
+	}
+
+	if clientErr != nil {
+		attrs = append(attrs, slog.String("error", clientErr.Error()))
+	}
+
+	if options.LogHeaders {
+		// RawRequest carries the client-wide headers, such as Authorization.
+		header := request.Header
+		if request.RawRequest != nil {
+			header = request.RawRequest.Header
+		}
+		attrs = append(attrs, slog.Any("headers", redactHeaders(header, options.RedactHeaders)))
+	}
+
+	if options.LogBodies {
+		if request.Body != nil {
+			body, _ := json.Marshal(request.Body)
+			attrs = append(attrs, slog.String("request_body", redactBody(body, options)))
+		}
+		if resp != nil {
+			attrs = append(attrs, slog.String("response_body", redactBody(resp.Body(), options)))
+		}
+	}
+
+	logger.LogAttrs(ctx, level, "subrow api call", attrs...)
+}
+
+func redactHeaders(header http.Header, redactHeaders []string) http.Header {
+	redacted := header.Clone()
+	for _, names := range [][]string{credentialHeaders, redactHeaders} {
+		for _, name := range names {
+			if redacted.Get(name) != "" {
+				redacted.Set(name, redactedValue)
+			}
+		}
+	}
+
+	return redacted
+}
+
+// redactBody redacts the RedactFields of a JSON body and truncates it.
+// Bodies that are not JSON are only truncated.
+func redactBody(body []byte, options *LogOptions) string {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		if redacted, err := json.Marshal(redactValue(value, options.RedactFields)); err == nil {
+			body = redacted
+		}
+	}
+
+	maxBodyBytes := options.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultLogMaxBodyBytes
+	}
+	if len(body) > maxBodyBytes {
+		return string(body[:maxBodyBytes]) + "...(truncated)"
+	}
+
+	return string(body)
+}
+
+func redactValue(value interface{}, fields []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, fieldValue := range v {
+			if isRedactedField(key, fields) && fieldValue != nil {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactValue(fieldValue, fields)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item, fields)
+		}
+	}
+
+	return value
+}
+
+func isRedactedField(key string, fields []string) bool {
+	for _, field := range fields {
+		if strings.EqualFold(key, field) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,135 @@
+package subrow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func decodeLogRecords(c *qt.C, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		c.Assert(json.Unmarshal([]byte(line), &record), qt.IsNil)
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestLogging(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("When a call succeeds", func(t *testing.T) {
+		c := qt.New(t)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set(RequestIDHeader, "req_1")
+			_, _ = w.Write([]byte(`{"customer":{"external_id":"cus_1","email":"jane@example.com","shipping_address":{"city":"Paris"}}}`))
+		}))
+		defer server.Close()
+
+		var buf bytes.Buffer
+		logOptions := DefaultLogOptions()
+		logOptions.LogHeaders = true
+		logOptions.LogBodies = true
+		client := New().
+			SetBaseURL(server.URL).
+			SetApiKey("secret_api_key").
+			SetLogger(slog.New(slog.NewJSONHandler(&buf, nil))).
+			SetLogOptions(logOptions)
+
+		_, err := client.Customer().Create(ctx, &CustomerInput{ExternalID: "cus_1", Email: "jane@example.com", TaxIdentificationNumber: "FR123"})
+		c.Assert(err == nil, qt.IsTrue)
+
+		records := decodeLogRecords(c, &buf)
+		c.Assert(records, qt.HasLen, 1)
+		record := records[0]
+		c.Assert(record["level"], qt.Equals, "INFO")
+		c.Assert(record["method"], qt.Equals, "POST")
+		c.Assert(record["path"], qt.Equals, "customers")
+		c.Assert(record["status"], qt.Equals, 200.0)
+		c.Assert(record["attempt"], qt.Equals, 1.0)
+		c.Assert(record["request_id"], qt.Equals, "req_1")
+		c.Assert(record["headers"].(map[string]interface{})["Authorization"], qt.DeepEquals, []interface{}{"[REDACTED]"})
+		c.Assert(record["request_body"], qt.Equals, `{"customer":{"billing_configuration":{},"email":"[REDACTED]","external_id":"cus_1","shipping_address":"[REDACTED]","tax_identification_number":"[REDACTED]"}}`)
+		c.Assert(record["response_body"], qt.Equals, `{"customer":{"email":"[REDACTED]","external_id":"cus_1","shipping_address":"[REDACTED]"}}`)
+		c.Assert(strings.Contains(buf.String(), "secret_api_key"), qt.IsFalse)
+	})
+
+	t.Run("When the log options do not redact the Authorization header", func(t *testing.T) {
+		c := qt.New(t)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"customer":{"external_id":"cus_1"}}`))
+		}))
+		defer server.Close()
+
+		var buf bytes.Buffer
+		client := New().
+			SetBaseURL(server.URL).
+			SetApiKey("secret_api_key").
+			SetLogger(slog.New(slog.NewJSONHandler(&buf, nil))).
+			SetLogOptions(&LogOptions{LogHeaders: true})
+		client.HttpClient.SetHeader("X-Api-Key", "secret_api_key")
+
+		_, err := client.Customer().Get(ctx, "cus_1")
+		c.Assert(err == nil, qt.IsTrue)
+
+		headers := decodeLogRecords(c, &buf)[0]["headers"].(map[string]interface{})
+		c.Assert(headers["Authorization"], qt.DeepEquals, []interface{}{"[REDACTED]"})
+		c.Assert(headers["X-Api-Key"], qt.DeepEquals, []interface{}{"[REDACTED]"})
+		c.Assert(strings.Contains(buf.String(), "secret_api_key"), qt.IsFalse)
+	})
+
+	t.Run("When a call is retried", func(t *testing.T) {
+		c := qt.New(t)
+
+		var hits atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if hits.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`{"status":503,"error":"Service Unavailable"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"customer":{"external_id":"cus_1"}}`))
+		}))
+		defer server.Close()
+
+		var buf bytes.Buffer
+		client := New().
+			SetBaseURL(server.URL).
+			SetRetryPolicy(&RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}).
+			SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+		_, err := client.Customer().Get(ctx, "cus_1")
+		c.Assert(err == nil, qt.IsTrue)
+
+		records := decodeLogRecords(c, &buf)
+		c.Assert(records, qt.HasLen, 2)
+		c.Assert(records[0]["level"], qt.Equals, "WARN")
+		c.Assert(records[0]["status"], qt.Equals, 503.0)
+		c.Assert(records[0]["error"], qt.Not(qt.IsNil))
+		c.Assert(records[1]["attempt"], qt.Equals, 2.0)
+		c.Assert(records[1]["response_body"], qt.IsNil)
+	})
+
+	t.Run("When a body is too long", func(t *testing.T) {
+		c := qt.New(t)
+
+		body := redactBody([]byte(`{"name":"`+strings.Repeat("a", 100)+`"}`), &LogOptions{MaxBodyBytes: 10})
+		c.Assert(body, qt.Equals, `{"name":"a...(truncated)`)
+	})
+}
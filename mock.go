@@ -0,0 +1,62 @@
+package subrow
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MockCall is a call recorded by one of the mocks in api_mock.go.
+type MockCall struct {
+	Method string
+	Args   []interface{}
+}
+
+// mockCalls records the calls made to a mock. Mocks embed it to expose
+// Calls, CallsTo and Reset.
+type mockCalls struct {
+	mu    sync.Mutex
+	calls []MockCall
+}
+
+func (mc *mockCalls) record(method string, args ...interface{}) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.calls = append(mc.calls, MockCall{Method: method, Args: args})
+}
+
+// Calls returns every call made to the mock, in order.
+func (mc *mockCalls) Calls() []MockCall {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	return append([]MockCall(nil), mc.calls...)
+}
+
+// CallsTo returns the calls made to method, in order.
+func (mc *mockCalls) CallsTo(method string) []MockCall {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	var calls []MockCall
+	for _, call := range mc.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// Reset forgets the recorded calls.
+func (mc *mockCalls) Reset() {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.calls = nil
+}
+
+// mockNotImplemented is returned by mock methods whose Func field is not set.
+func mockNotImplemented(method string) *Error {
+	return &Error{Err: fmt.Errorf("subrow: %s is not implemented by the mock", method)}
+}
@@ -0,0 +1,379 @@
+package subrow
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const userAgent string = "subrow-go-client github.com/subrowio/subrow-go-client/v1"
+
+// Option configures a Client built with NewClient or derived with With.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	apiKey           string
+	baseURL          string
+	baseIngestURL    string
+	useIngestService bool
+	userAgentSuffix  string
+
+	debug              bool
+	retryPolicy        *RetryPolicy
+	autoIdempotencyKey bool
+	logger             *slog.Logger
+	logOptions         *LogOptions
+	webhookHmacKey     string
+	telemetry          *telemetry
+
+	// rateLimits and webhookKeys update the rate limiter and the webhook
+	// public key cache of the client. A client derived with such options
+	// gets its own, starting from the configuration of its parent.
+	rateLimits  []func(*rateLimiter)
+	webhookKeys []func(*webhookKeyCache)
+
+	// transport is replaced, never updated, by the options changing it, so
+	// clients deriving from one another share its HTTP client as long as
+	// they do not change it.
+	transport *transportOptions
+}
+
+type transportOptions struct {
+	httpClient *http.Client
+	roundTrip  http.RoundTripper
+	timeout    time.Duration
+	proxy      *url.URL
+	tlsConfig  *tls.Config
+
+	// client is built from the options above on first use.
+	client *http.Client
+}
+
+func WithApiKey(apiKey string) Option {
+	return func(o *clientOptions) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithBaseURL sets the URL of the API, e.g. https://api.subrow.com. Events
+// are sent to it too unless WithBaseIngestURL or WithIngestService is set.
+func WithBaseURL(url string) Option {
+	return func(o *clientOptions) {
+		o.baseURL = url
+	}
+}
+
+func WithBaseIngestURL(url string) Option {
+	return func(o *clientOptions) {
+		o.baseIngestURL = url
+	}
+}
+
+// WithIngestService sends events to the ingest service rather than to the
+// API.
+func WithIngestService(useIngestService bool) Option {
+	return func(o *clientOptions) {
+		o.useIngestService = useIngestService
+	}
+}
+
+// WithUserAgentSuffix appends suffix, such as "my-app/1.2", to the
+// User-Agent of every request.
+func WithUserAgentSuffix(suffix string) Option {
+	return func(o *clientOptions) {
+		o.userAgentSuffix = suffix
+	}
+}
+
+// WithHTTPClient sends requests with a copy of httpClient. httpClient itself
+// is never modified by the other options.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return withTransport(func(t *transportOptions) {
+		t.httpClient = httpClient
+	})
+}
+
+// WithTransport sends requests through roundTripper, in place of the
+// transport of the HTTP client.
+func WithTransport(roundTripper http.RoundTripper) Option {
+	return withTransport(func(t *transportOptions) {
+		t.roundTrip = roundTripper
+	})
+}
+
+// WithTimeout limits the time of every request attempt, from dialing to
+// reading the response body. Zero keeps the timeout of the HTTP client.
+func WithTimeout(timeout time.Duration) Option {
+	return withTransport(func(t *transportOptions) {
+		t.timeout = timeout
+	})
+}
+
+// WithProxy sends requests through the proxy at proxyURL. Like WithTLSConfig,
+// it only applies to an *http.Transport, which is cloned first.
+func WithProxy(proxyURL *url.URL) Option {
+	return withTransport(func(t *transportOptions) {
+		t.proxy = proxyURL
+	})
+}
+
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return withTransport(func(t *transportOptions) {
+		t.tlsConfig = tlsConfig
+	})
+}
+
+func WithDebug(debug bool) Option {
+	return func(o *clientOptions) {
+		o.debug = debug
+	}
+}
+
+func WithRetryPolicy(retryPolicy *RetryPolicy) Option {
+	return func(o *clientOptions) {
+		o.retryPolicy = retryPolicy
+	}
+}
+
+func WithAutoIdempotencyKey(autoIdempotencyKey bool) Option {
+	return func(o *clientOptions) {
+		o.autoIdempotencyKey = autoIdempotencyKey
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *clientOptions) {
+		o.logger = logger
+	}
+}
+
+func WithLogOptions(logOptions *LogOptions) Option {
+	return func(o *clientOptions) {
+		o.logOptions = logOptions
+	}
+}
+
+// WithTelemetry traces and measures every API call, see SetTelemetry.
+func WithTelemetry(opts *TelemetryOptions) Option {
+	return func(o *clientOptions) {
+		o.telemetry = newTelemetry(opts)
+	}
+}
+
+// WithRateLimit limits the rate of every API call of the client. A zero Rate
+// removes the limit.
+func WithRateLimit(limit RateLimit) Option {
+	return withRateLimit(func(rl *rateLimiter) {
+		rl.setGlobal(limit)
+	})
+}
+
+// WithPathRateLimit limits the rate of the API calls whose path starts with
+// prefix, on top of the client-wide limit, see SetPathRateLimit.
+func WithPathRateLimit(prefix string, limit RateLimit) Option {
+	return withRateLimit(func(rl *rateLimiter) {
+		rl.setPrefix(prefix, limit)
+	})
+}
+
+// WithIngestRateLimit limits the rate of the events sent with the ingest
+// client, on top of the client-wide limit.
+func WithIngestRateLimit(limit RateLimit) Option {
+	return withRateLimit(func(rl *rateLimiter) {
+		rl.setIngest(limit)
+	})
+}
+
+func WithWebhookHmacKey(hmacKey string) Option {
+	return func(o *clientOptions) {
+		o.webhookHmacKey = hmacKey
+	}
+}
+
+// WithWebhookPublicKey pins the key webhook signatures are verified with, see
+// SetWebhookPublicKey.
+func WithWebhookPublicKey(publicKey *rsa.PublicKey) Option {
+	return withWebhookKeys(func(kc *webhookKeyCache) {
+		kc.pin(publicKey)
+	})
+}
+
+func WithWebhookPublicKeyTTL(ttl time.Duration) Option {
+	return withWebhookKeys(func(kc *webhookKeyCache) {
+		kc.setTTL(ttl)
+	})
+}
+
+func withRateLimit(update func(*rateLimiter)) Option {
+	return func(o *clientOptions) {
+		o.rateLimits = append(o.rateLimits, update)
+	}
+}
+
+func withWebhookKeys(update func(*webhookKeyCache)) Option {
+	return func(o *clientOptions) {
+		o.webhookKeys = append(o.webhookKeys, update)
+	}
+}
+
+func withTransport(update func(*transportOptions)) Option {
+	return func(o *clientOptions) {
+		transport := transportOptions{}
+		if o.transport != nil {
+			transport = *o.transport
+		}
+		transport.client = nil
+		update(&transport)
+
+		o.transport = &transport
+	}
+}
+
+// NewClient returns a Client configured by opts. A Client built this way is
+// safe for concurrent use as long as it is not modified with its Set
+// methods: derive differently configured clients from it with With instead.
+func NewClient(opts ...Option) *Client {
+	options := clientOptions{baseURL: baseURL}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return newClient(options, newWebhookKeyCache(), newRateLimiter())
+}
+
+// With returns a copy of c with opts applied on top of its configuration.
+// The copy shares the rate limiter, webhook public key cache and telemetry
+// of c unless opts change them, as well as its HTTP connections unless opts
+// change the transport. c is left untouched, so With is safe to call while c
+// is in use.
+func (c *Client) With(opts ...Option) *Client {
+	options := c.options
+	options.baseURL = c.BaseUrl
+	options.useIngestService = c.UseIngestService
+	options.debug = c.Debug
+	options.retryPolicy = c.RetryPolicy
+	options.autoIdempotencyKey = c.AutoIdempotencyKey
+	options.logger = c.Logger
+	options.logOptions = c.LogOptions
+	options.webhookHmacKey = c.webhookHmacKey
+	options.telemetry = c.telemetry
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return newClient(options, c.webhookKeys, c.rateLimiter)
+}
+
+func newClient(options clientOptions, webhookKeys *webhookKeyCache, rateLimiter *rateLimiter) *Client {
+	if options.transport == nil {
+		options.transport = &transportOptions{}
+	}
+	if options.transport.client == nil {
+		options.transport.client = options.transport.build()
+	}
+	if len(options.rateLimits) > 0 {
+		rateLimiter = rateLimiter.clone()
+		for _, update := range options.rateLimits {
+			update(rateLimiter)
+		}
+		options.rateLimits = nil
+	}
+	if len(options.webhookKeys) > 0 {
+		webhookKeys = webhookKeys.clone()
+		for _, update := range options.webhookKeys {
+			update(webhookKeys)
+		}
+		options.webhookKeys = nil
+	}
+
+	c := &Client{
+		BaseUrl:            options.baseURL,
+		UseIngestService:   options.useIngestService,
+		Debug:              options.debug,
+		RetryPolicy:        options.retryPolicy,
+		AutoIdempotencyKey: options.autoIdempotencyKey,
+		Logger:             options.logger,
+		LogOptions:         options.logOptions,
+		options:            options,
+		webhookKeys:        webhookKeys,
+		webhookHmacKey:     options.webhookHmacKey,
+		telemetry:          options.telemetry,
+		rateLimiter:        rateLimiter,
+	}
+	c.BaseIngestUrl = c.ingestURL()
+	c.HttpClient = c.newRestyClient(c.BaseUrl)
+	c.IngestHttpClient = c.newRestyClient(c.BaseIngestUrl)
+
+	return c
+}
+
+// ingestURL is the URL events are sent to: the one set explicitly, else the
+// ingest service when enabled, else the API.
+func (c *Client) ingestURL() string {
+	switch {
+	case c.options.baseIngestURL != "":
+		return c.options.baseIngestURL
+	case c.UseIngestService:
+		return baseIngestURL
+	}
+
+	return c.BaseUrl
+}
+
+func (c *Client) newRestyClient(url string) *resty.Client {
+	// Each resty client gets its own copy of the HTTP client, as resty
+	// modifies it in place, e.g. in SetTransport.
+	httpClient := *c.options.transport.client
+
+	agent := userAgent
+	if c.options.userAgentSuffix != "" {
+		agent += " " + c.options.userAgentSuffix
+	}
+
+	restyClient := resty.NewWithClient(&httpClient).
+		SetBaseURL(fmt.Sprintf("%s%s", url, apiPath)).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("User-Agent", agent)
+	if c.options.apiKey != "" {
+		restyClient.SetAuthToken(c.options.apiKey)
+	}
+
+	return restyClient
+}
+
+func (t *transportOptions) build() *http.Client {
+	client := &http.Client{}
+	if t.httpClient != nil {
+		*client = *t.httpClient
+	}
+	if t.roundTrip != nil {
+		client.Transport = t.roundTrip
+	}
+	if client.Transport == nil {
+		client.Transport = http.DefaultTransport
+	}
+	if t.timeout > 0 {
+		client.Timeout = t.timeout
+	}
+
+	if t.proxy != nil || t.tlsConfig != nil {
+		if transport, ok := client.Transport.(*http.Transport); ok {
+			transport = transport.Clone()
+			if t.proxy != nil {
+				transport.Proxy = http.ProxyURL(t.proxy)
+			}
+			if t.tlsConfig != nil {
+				transport.TLSClientConfig = t.tlsConfig.Clone()
+			}
+			client.Transport = transport
+		}
+	}
+
+	return client
+}
@@ -0,0 +1,261 @@
+package subrow
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+type seenRequest struct {
+	Host          string
+	Path          string
+	Authorization string
+	UserAgent     string
+}
+
+func recordingServer(c *qt.C) (*httptest.Server, func() []seenRequest) {
+	var mu sync.Mutex
+	var seen []seenRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, seenRequest{
+			Host:          r.Host,
+			Path:          r.URL.Path,
+			Authorization: r.Header.Get("Authorization"),
+			UserAgent:     r.Header.Get("User-Agent"),
+		})
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tax":{"code":"vat","name":"VAT"}}`))
+	}))
+	c.Cleanup(server.Close)
+
+	return server, func() []seenRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]seenRequest(nil), seen...)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	t.Run("When configured with options", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, seen := recordingServer(c)
+		client := NewClient(
+			WithApiKey("test_api_key"),
+			WithBaseURL(server.URL),
+			WithUserAgentSuffix("billing-service/1.2"),
+		)
+
+		_, err := client.Tax().Get(context.Background(), "vat")
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(seen(), qt.DeepEquals, []seenRequest{{
+			Host:          server.Listener.Addr().String(),
+			Path:          "/api/v1/taxes/vat",
+			Authorization: "Bearer test_api_key",
+			UserAgent:     userAgent + " billing-service/1.2",
+		}})
+		c.Assert(client.BaseIngestUrl, qt.Equals, server.URL)
+	})
+
+	t.Run("When using the ingest service", func(t *testing.T) {
+		c := qt.New(t)
+
+		client := NewClient(WithIngestService(true))
+		c.Assert(client.BaseUrl, qt.Equals, baseURL)
+		c.Assert(client.BaseIngestUrl, qt.Equals, baseIngestURL)
+		c.Assert(client.IngestHttpClient.BaseURL, qt.Equals, baseIngestURL+"/api/v1")
+
+		client = NewClient(WithIngestService(true), WithBaseIngestURL("http://ingest.local"))
+		c.Assert(client.IngestHttpClient.BaseURL, qt.Equals, "http://ingest.local/api/v1")
+	})
+
+	t.Run("When given an HTTP client", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, seen := recordingServer(c)
+		httpClient := &http.Client{Timeout: time.Minute}
+		client := NewClient(WithBaseURL(server.URL), WithHTTPClient(httpClient), WithTimeout(time.Second))
+
+		_, err := client.Tax().Get(context.Background(), "vat")
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(seen(), qt.HasLen, 1)
+		c.Assert(client.HttpClient.GetClient().Timeout, qt.Equals, time.Second)
+		c.Assert(httpClient.Timeout, qt.Equals, time.Minute)
+		c.Assert(httpClient.Transport, qt.IsNil)
+	})
+
+	t.Run("When given a proxy", func(t *testing.T) {
+		c := qt.New(t)
+
+		proxy, seen := recordingServer(c)
+		proxyURL, _ := url.Parse(proxy.URL)
+		client := NewClient(WithBaseURL("http://api.subrow.test"), WithProxy(proxyURL))
+
+		_, err := client.Tax().Get(context.Background(), "vat")
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(seen(), qt.HasLen, 1)
+		c.Assert(seen()[0].Host, qt.Equals, "api.subrow.test")
+		c.Assert(client.HttpClient.GetClient().Transport, qt.Not(qt.Equals), http.DefaultTransport)
+	})
+
+	t.Run("When given rate limit, telemetry and webhook options", func(t *testing.T) {
+		c := qt.New(t)
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		c.Assert(err, qt.IsNil)
+
+		client := NewClient(
+			WithRateLimit(RateLimit{Rate: 10}),
+			WithPathRateLimit("customers", RateLimit{Rate: 1}),
+			WithIngestRateLimit(RateLimit{Rate: 100}),
+			WithTelemetry(nil),
+			WithWebhookHmacKey("hmac_key"),
+			WithWebhookPublicKey(&key.PublicKey),
+			WithWebhookPublicKeyTTL(time.Minute),
+		)
+
+		c.Assert(client.rateLimiter.global, qt.IsNotNil)
+		c.Assert(client.rateLimiter.prefixes["customers"], qt.IsNotNil)
+		c.Assert(client.rateLimiter.ingest, qt.IsNotNil)
+		c.Assert(client.telemetry, qt.IsNotNil)
+		c.Assert(client.webhookHmacKey, qt.Equals, "hmac_key")
+		c.Assert(client.webhookKeys.key, qt.Equals, &key.PublicKey)
+		c.Assert(client.webhookKeys.pinned, qt.IsTrue)
+		c.Assert(client.webhookKeys.ttl, qt.Equals, time.Minute)
+	})
+}
+
+func TestClientWith(t *testing.T) {
+	t.Run("When deriving a client", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, seen := recordingServer(c)
+		other, otherSeen := recordingServer(c)
+		retryPolicy := DefaultRetryPolicy()
+		client := NewClient(WithApiKey("test_api_key"), WithBaseURL(server.URL), WithRetryPolicy(retryPolicy))
+
+		derived := client.With(WithBaseURL(other.URL), WithUserAgentSuffix("worker"))
+		c.Assert(derived.RetryPolicy, qt.Equals, retryPolicy)
+		c.Assert(derived.rateLimiter, qt.Equals, client.rateLimiter)
+		c.Assert(derived.HttpClient.GetClient().Transport, qt.Equals, client.HttpClient.GetClient().Transport)
+
+		_, err := derived.Tax().Get(context.Background(), "vat")
+		c.Assert(err == nil, qt.IsTrue)
+		_, err = client.Tax().Get(context.Background(), "vat")
+		c.Assert(err == nil, qt.IsTrue)
+
+		c.Assert(otherSeen(), qt.HasLen, 1)
+		c.Assert(otherSeen()[0].Authorization, qt.Equals, "Bearer test_api_key")
+		c.Assert(otherSeen()[0].UserAgent, qt.Equals, userAgent+" worker")
+		c.Assert(seen(), qt.HasLen, 1)
+		c.Assert(seen()[0].UserAgent, qt.Equals, userAgent)
+	})
+
+	t.Run("When deriving clients concurrently", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, seen := recordingServer(c)
+		client := NewClient(WithApiKey("test_api_key"), WithBaseURL(server.URL))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				derived := client.With(WithIngestService(i%2 == 0), WithTimeout(time.Second))
+				_, _ = derived.Tax().Get(context.Background(), "vat")
+			}()
+		}
+		wg.Wait()
+
+		c.Assert(seen(), qt.HasLen, 10)
+		c.Assert(client.BaseIngestUrl, qt.Equals, server.URL)
+	})
+
+	t.Run("When deriving a client with its own limits and webhook key", func(t *testing.T) {
+		c := qt.New(t)
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		c.Assert(err, qt.IsNil)
+
+		client := NewClient(
+			WithRateLimit(RateLimit{Rate: 10}),
+			WithPathRateLimit("customers", RateLimit{Rate: 1}),
+			WithTelemetry(nil),
+			WithWebhookHmacKey("hmac_key"),
+		)
+
+		shared := client.With()
+		c.Assert(shared.rateLimiter, qt.Equals, client.rateLimiter)
+		c.Assert(shared.webhookKeys, qt.Equals, client.webhookKeys)
+		c.Assert(shared.telemetry, qt.Equals, client.telemetry)
+		c.Assert(shared.webhookHmacKey, qt.Equals, "hmac_key")
+
+		derived := client.With(WithRateLimit(RateLimit{}), WithWebhookPublicKey(&key.PublicKey))
+		c.Assert(derived.rateLimiter, qt.Not(qt.Equals), client.rateLimiter)
+		c.Assert(derived.rateLimiter.global, qt.IsNil)
+		c.Assert(derived.rateLimiter.prefixes["customers"], qt.IsNotNil)
+		c.Assert(derived.webhookKeys.key, qt.Equals, &key.PublicKey)
+
+		// The client it derives from is left untouched.
+		c.Assert(client.rateLimiter.global, qt.IsNotNil)
+		c.Assert(client.webhookKeys.key, qt.IsNil)
+		c.Assert(client.webhookKeys.pinned, qt.IsFalse)
+	})
+
+	t.Run("When the client was configured with setters", func(t *testing.T) {
+		c := qt.New(t)
+
+		client := New().SetApiKey("test_api_key").SetBaseURL("http://api.local").SetDebug(true)
+
+		derived := client.With(WithIngestService(true))
+		c.Assert(derived.Debug, qt.IsTrue)
+		c.Assert(derived.HttpClient.BaseURL, qt.Equals, "http://api.local/api/v1")
+		c.Assert(derived.HttpClient.Token, qt.Equals, "test_api_key")
+	})
+}
+
+func TestSetUseIngestService(t *testing.T) {
+	c := qt.New(t)
+
+	client := New().SetUseIngestService(true)
+	c.Assert(client.IngestHttpClient.BaseURL, qt.Equals, baseIngestURL+"/api/v1")
+
+	client.SetUseIngestService(false)
+	c.Assert(client.BaseIngestUrl, qt.Equals, baseURL)
+	c.Assert(client.IngestHttpClient.BaseURL, qt.Equals, baseURL+"/api/v1")
+	c.Assert(client.HttpClient.BaseURL, qt.Equals, baseURL+"/api/v1")
+}
+
+func TestIngestURL(t *testing.T) {
+	c := qt.New(t)
+
+	client := New().SetBaseIngestUrl("http://ingest.local").SetUseIngestService(false)
+	c.Assert(client.BaseIngestUrl, qt.Equals, "http://ingest.local")
+	c.Assert(client.IngestHttpClient.BaseURL, qt.Equals, "http://ingest.local/api/v1")
+
+	c.Run("When the base URL of the parent changes", func(c *qt.C) {
+		derived := New().SetBaseURL("http://a.local").With(WithBaseURL("http://b.local"))
+		c.Assert(derived.BaseIngestUrl, qt.Equals, "http://b.local")
+		c.Assert(derived.IngestHttpClient.BaseURL, qt.Equals, "http://b.local/api/v1")
+	})
+
+	c.Run("When a derived client uses the ingest service", func(c *qt.C) {
+		derived := New().SetBaseURL("http://a.local").With(WithIngestService(true))
+		c.Assert(derived.BaseIngestUrl, qt.Equals, baseIngestURL)
+		c.Assert(derived.IngestHttpClient.BaseURL, qt.Equals, baseIngestURL+"/api/v1")
+		c.Assert(derived.HttpClient.BaseURL, qt.Equals, "http://a.local/api/v1")
+	})
+}
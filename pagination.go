@@ -0,0 +1,139 @@
+package subrow
+
+import (
+	"context"
+	"iter"
+)
+
+type IteratorOption func(*iteratorOptions)
+
+type iteratorOptions struct {
+	maxItems int
+	prefetch int
+}
+
+// WithMaxItems stops the iteration after n items. Zero means no limit.
+func WithMaxItems(n int) IteratorOption {
+	return func(o *iteratorOptions) {
+		o.maxItems = n
+	}
+}
+
+// WithPrefetch sets how many pages are fetched ahead of the consumer.
+// Zero fetches every page synchronously. Defaults to 1.
+func WithPrefetch(pages int) IteratorOption {
+	return func(o *iteratorOptions) {
+		o.prefetch = max(pages, 0)
+	}
+}
+
+type pageFetcher[T any] func(ctx context.Context, page int) ([]T, Metadata, *Error)
+
+type fetchedPage[T any] struct {
+	items []T
+	err   error
+}
+
+// paginate walks a list endpoint page by page, following Metadata.NextPage,
+// and yields every item. A failed page is yielded as a single error and ends
+// the iteration.
+func paginate[T any](ctx context.Context, startPage int, fetch pageFetcher[T], opts []IteratorOption) iter.Seq2[T, error] {
+	options := iteratorOptions{prefetch: 1}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if startPage <= 0 {
+		startPage = 1
+	}
+
+	return func(yield func(T, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var zero T
+		count := 0
+
+		for page := range fetchPages(ctx, startPage, fetch, options.prefetch) {
+			if page.err != nil {
+				yield(zero, page.err)
+				return
+			}
+
+			for _, item := range page.items {
+				if err := ctx.Err(); err != nil {
+					yield(zero, err)
+					return
+				}
+
+				if !yield(item, nil) {
+					return
+				}
+
+				count++
+				if options.maxItems > 0 && count >= options.maxItems {
+					return
+				}
+			}
+		}
+
+		// A canceled context can end the pages early, possibly dropping the
+		// page that carried the error.
+		if err := ctx.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+func fetchPages[T any](ctx context.Context, startPage int, fetch pageFetcher[T], prefetch int) iter.Seq[fetchedPage[T]] {
+	next := func(page int) (fetchedPage[T], int) {
+		items, meta, err := fetch(ctx, page)
+		if err != nil {
+			return fetchedPage[T]{err: err}, 0
+		}
+
+		if meta.NextPage <= page {
+			return fetchedPage[T]{items: items}, 0
+		}
+
+		return fetchedPage[T]{items: items}, meta.NextPage
+	}
+
+	if prefetch == 0 {
+		return func(yield func(fetchedPage[T]) bool) {
+			for page := startPage; page != 0; {
+				var fetched fetchedPage[T]
+				fetched, page = next(page)
+				if !yield(fetched) {
+					return
+				}
+			}
+		}
+	}
+
+	return func(yield func(fetchedPage[T]) bool) {
+		// One page is held by the blocked sender, the rest wait in the buffer.
+		pages := make(chan fetchedPage[T], prefetch-1)
+
+		go func() {
+			defer close(pages)
+
+			for page := startPage; page != 0; {
+				var fetched fetchedPage[T]
+				fetched, page = next(page)
+
+				select {
+				case pages <- fetched:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		for fetched := range pages {
+			if !yield(fetched) {
+				return
+			}
+		}
+	}
+}
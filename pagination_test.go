@@ -0,0 +1,159 @@
+package subrow
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func pagedCustomersServer(c *qt.C, totalPages int, failingPage int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, qt.Equals, "/api/v1/customers")
+		c.Check(r.URL.Query().Get("per_page"), qt.Equals, "2")
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		w.Header().Set("Content-Type", "application/json")
+
+		if page == failingPage {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"status":500,"error":"Internal Server Error"}`))
+			return
+		}
+
+		nextPage := page + 1
+		if page == totalPages {
+			nextPage = 0
+		}
+
+		_, _ = fmt.Fprintf(w, `{"customers":[{"external_id":"customer_%d_1"},{"external_id":"customer_%d_2"}],"meta":{"current_page":%d,"next_page":%d,"total_pages":%d}}`,
+			page, page, page, nextPage, totalPages)
+	}))
+	c.Cleanup(server.Close)
+
+	return server
+}
+
+func collectCustomerIDs(seq iter.Seq2[Customer, error]) ([]string, error) {
+	var ids []string
+	for customer, err := range seq {
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, customer.ExternalID)
+	}
+
+	return ids, nil
+}
+
+func TestCustomerAll(t *testing.T) {
+	t.Run("When every page succeeds", func(t *testing.T) {
+		c := qt.New(t)
+
+		for _, prefetch := range []int{0, 1, 3} {
+			server := pagedCustomersServer(c, 3, 0)
+			client := New().SetBaseURL(server.URL).SetApiKey("test_api_key")
+
+			ids, err := collectCustomerIDs(client.Customer().All(context.Background(), &CustomerListInput{PerPage: 2}, WithPrefetch(prefetch)))
+			c.Assert(err, qt.IsNil)
+			c.Assert(ids, qt.DeepEquals, []string{
+				"customer_1_1", "customer_1_2",
+				"customer_2_1", "customer_2_2",
+				"customer_3_1", "customer_3_2",
+			})
+		}
+	})
+
+	t.Run("When the number of items is capped", func(t *testing.T) {
+		c := qt.New(t)
+
+		server := pagedCustomersServer(c, 3, 0)
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key")
+
+		ids, err := collectCustomerIDs(client.Customer().All(context.Background(), &CustomerListInput{PerPage: 2}, WithMaxItems(3)))
+		c.Assert(err, qt.IsNil)
+		c.Assert(ids, qt.DeepEquals, []string{"customer_1_1", "customer_1_2", "customer_2_1"})
+	})
+
+	t.Run("When a page fails", func(t *testing.T) {
+		c := qt.New(t)
+
+		server := pagedCustomersServer(c, 3, 2)
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key")
+
+		ids, err := collectCustomerIDs(client.Customer().All(context.Background(), &CustomerListInput{PerPage: 2}))
+		c.Assert(ids, qt.DeepEquals, []string{"customer_1_1", "customer_1_2"})
+		c.Assert(err, qt.ErrorMatches, `.*Internal Server Error.*`)
+	})
+
+	t.Run("When the context is canceled", func(t *testing.T) {
+		c := qt.New(t)
+
+		server := pagedCustomersServer(c, 3, 0)
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var ids []string
+		var lastErr error
+		for customer, err := range client.Customer().All(ctx, &CustomerListInput{PerPage: 2}) {
+			if err != nil {
+				lastErr = err
+				break
+			}
+			ids = append(ids, customer.ExternalID)
+			cancel()
+		}
+
+		c.Assert(ids, qt.DeepEquals, []string{"customer_1_1"})
+		c.Assert(lastErr, qt.ErrorIs, context.Canceled)
+	})
+
+	t.Run("When the context is canceled while a page is prefetched", func(t *testing.T) {
+		c := qt.New(t)
+
+		fetching := make(chan struct{})
+		fetched := make(chan struct{})
+		fetch := func(ctx context.Context, page int) ([]string, Metadata, *Error) {
+			if page == 1 {
+				return []string{"a", "b"}, Metadata{CurrentPage: 1, NextPage: 2}, nil
+			}
+
+			defer close(fetched)
+			close(fetching)
+			<-ctx.Done()
+			return nil, Metadata{}, &Error{Err: ctx.Err(), Message: ctx.Err().Error()}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var items []string
+		var lastErr error
+		for item, err := range paginate(ctx, 1, fetch, nil) {
+			if err != nil {
+				lastErr = err
+				break
+			}
+			items = append(items, item)
+			if item == "b" {
+				<-fetching
+				cancel()
+				<-fetched
+				// Let the prefetcher see the cancellation before the next page
+				// is received.
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+
+		c.Assert(items, qt.DeepEquals, []string{"a", "b"})
+		c.Assert(lastErr, qt.ErrorIs, context.Canceled)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -118,6 +119,26 @@ func (ir *ManualPaymentRequest) GetList(ctx context.Context, paymentListInput *P
 	return paymentResult, nil
 }
 
+// All iterates over every payment matching the input, fetching pages as needed.
+func (ir *ManualPaymentRequest) All(ctx context.Context, paymentListInput *PaymentListInput, opts ...IteratorOption) iter.Seq2[Payment, error] {
+	input := PaymentListInput{}
+	if paymentListInput != nil {
+		input = *paymentListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]Payment, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := ir.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.Payments, result.Meta, nil
+	}, opts)
+}
+
 func (cr *ManualPaymentRequest) Create(ctx context.Context, paymentInput *PaymentInput) (*Payment, *Error) {
 	paymentParams := &PaymentParams{
 		Payment: paymentInput,
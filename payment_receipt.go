@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -88,3 +89,23 @@ func (ir *PaymentReceiptRequest) GetList(ctx context.Context, paymentReceiptList
 
 	return paymentReceiptResult, nil
 }
+
+// All iterates over every payment receipt matching the input, fetching pages as needed.
+func (ir *PaymentReceiptRequest) All(ctx context.Context, paymentReceiptListInput *PaymentReceiptListInput, opts ...IteratorOption) iter.Seq2[PaymentReceipt, error] {
+	input := PaymentReceiptListInput{}
+	if paymentReceiptListInput != nil {
+		input = *paymentReceiptListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]PaymentReceipt, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := ir.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.PaymentReceipts, result.Meta, nil
+	}, opts)
+}
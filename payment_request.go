@@ -3,6 +3,7 @@ package subrow
 import (
 	"context"
 	"encoding/json"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -83,6 +84,26 @@ func (ir *PaymentRequestRequest) GetList(ctx context.Context, paymentRequestList
 	return paymentRequestResult, nil
 }
 
+// All iterates over every payment request matching the input, fetching pages as needed.
+func (ir *PaymentRequestRequest) All(ctx context.Context, paymentRequestListInput *PaymentRequestListInput, opts ...IteratorOption) iter.Seq2[PaymentRequest, error] {
+	input := PaymentRequestListInput{}
+	if paymentRequestListInput != nil {
+		input = *paymentRequestListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]PaymentRequest, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := ir.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.PaymentRequests, result.Meta, nil
+	}, opts)
+}
+
 func (cr *PaymentRequestRequest) Create(ctx context.Context, paymentRequestInput *PaymentRequestInput) (*PaymentRequest, *Error) {
 	paymentRequestParams := &PaymentRequestParams{
 		PaymentRequest: paymentRequestInput,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -45,9 +46,29 @@ type PlanChargeInput struct {
 	Properties       map[string]interface{} `json:"properties"`
 	Filters          []ChargeFilter         `json:"filters,omitempty"`
 
+	// TypedProperties replaces Properties when set, and sets ChargeModel when
+	// it is empty. They are validated before the plan is sent.
+	TypedProperties ChargeProperties `json:"-"`
+
 	TaxCodes []string `json:"tax_codes,omitempty"`
 }
 
+func (pci PlanChargeInput) MarshalJSON() ([]byte, error) {
+	type alias PlanChargeInput
+	if pci.TypedProperties == nil {
+		return json.Marshal(alias(pci))
+	}
+
+	if pci.ChargeModel == "" {
+		pci.ChargeModel = pci.TypedProperties.ChargeModel()
+	}
+
+	return json.Marshal(struct {
+		alias
+		Properties ChargeProperties `json:"properties"`
+	}{alias(pci), pci.TypedProperties})
+}
+
 type MinimumCommitmentInput struct {
 	AmountCents        int      `json:"amount_cents,omitempty"`
 	InvoiceDisplayName string   `json:"invoice_display_name,omitempty"`
@@ -100,6 +121,7 @@ type Plan struct {
 	AmountCurrency     Currency           `json:"amount_currency,omitempty"`
 	PayInAdvance       bool               `json:"pay_in_advance,omitempty"`
 	BillChargesMonthly bool               `json:"bill_charges_monthly,omitempty"`
+	TrialPeriod        float32            `json:"trial_period,omitempty"`
 	Charges            []Charge           `json:"charges,omitempty"`
 	MinimumCommitment  *MinimumCommitment `json:"minimum_commitment"`
 
@@ -164,7 +186,45 @@ func (pr *PlanRequest) GetList(ctx context.Context, planListInput *PlanListInput
 	return planResult, nil
 }
 
+// All iterates over every plan matching the input, fetching pages as needed.
+func (pr *PlanRequest) All(ctx context.Context, planListInput *PlanListInput, opts ...IteratorOption) iter.Seq2[Plan, error] {
+	input := PlanListInput{}
+	if planListInput != nil {
+		input = *planListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]Plan, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := pr.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.Plans, result.Meta, nil
+	}, opts)
+}
+
+// validate checks the typed properties of the charges of the plan.
+func (pi *PlanInput) validate() *Error {
+	if pi == nil {
+		return nil
+	}
+
+	errs := fieldErrors{}
+	for i, charge := range pi.Charges {
+		validateChargeProperties(errs, fmt.Sprintf("charges[%d].", i), charge.ChargeModel, charge.TypedProperties, charge.Filters)
+	}
+
+	return errs.err()
+}
+
 func (pr *PlanRequest) Create(ctx context.Context, planInput *PlanInput) (*Plan, *Error) {
+	if err := planInput.validate(); err != nil {
+		return nil, err
+	}
+
 	planParams := &PlanParams{
 		Plan: planInput,
 	}
@@ -189,6 +249,10 @@ func (pr *PlanRequest) Create(ctx context.Context, planInput *PlanInput) (*Plan,
 }
 
 func (pr *PlanRequest) Update(ctx context.Context, planInput *PlanInput) (*Plan, *Error) {
+	if err := planInput.validate(); err != nil {
+		return nil, err
+	}
+
 	subPath := fmt.Sprintf("%s/%s", "plans", planInput.Code)
 	planParams := &PlanParams{
 		Plan: planInput,
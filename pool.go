@@ -0,0 +1,188 @@
+package subrow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrNoTenant = errors.New("subrow: no tenant in context")
+
+type tenantContextKey struct{}
+
+// WithTenant makes calls to ClientPool.Client with the returned context use
+// the client of tenant, e.g.
+//
+//	ctx = subrow.WithTenant(ctx, organizationID)
+//	client, err := pool.Client(ctx)
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// TenantKeyFunc returns the API key of a tenant, e.g. from a secret store.
+type TenantKeyFunc func(ctx context.Context, tenant string) (string, error)
+
+// ClientPool holds one Client per tenant, each with its own API key, created
+// on first use from a base client. Tenant clients share the HTTP connections
+// of the base client, and have their own rate limiter, configured like the
+// base one, and webhook public key cache. They have no webhook HMAC key, as
+// HMAC keys belong to an organization. A ClientPool is safe for concurrent
+// use.
+type ClientPool struct {
+	base   *Client
+	keys   TenantKeyFunc
+	keyTTL time.Duration
+
+	mu      sync.Mutex
+	tenants map[string]*tenantClient
+}
+
+type tenantClient struct {
+	mu          sync.Mutex
+	client      *Client
+	apiKey      string
+	resolvedAt  time.Time
+	stale       bool
+	rateLimiter *rateLimiter
+	webhookKeys *webhookKeyCache
+}
+
+// NewClientPool returns a pool of clients derived from base, which must not
+// be modified afterwards, with the API keys returned by keys.
+func NewClientPool(base *Client, keys TenantKeyFunc) *ClientPool {
+	return &ClientPool{
+		base:    base,
+		keys:    keys,
+		tenants: map[string]*tenantClient{},
+	}
+}
+
+// SetKeyTTL makes the pool resolve the API key of a tenant again when it was
+// resolved more than ttl ago, so rotated keys are picked up. Zero, the
+// default, keeps keys until Invalidate is called.
+func (p *ClientPool) SetKeyTTL(ttl time.Duration) *ClientPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.keyTTL = ttl
+
+	return p
+}
+
+// Client returns the client of the tenant set on ctx with WithTenant.
+func (p *ClientPool) Client(ctx context.Context) (*Client, error) {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	return p.ForTenant(ctx, tenant)
+}
+
+// ForTenant returns the client of tenant, resolving its API key if it is
+// missing, expired or invalidated. Calls in flight keep the client they
+// started with when a key is rotated.
+func (p *ClientPool) ForTenant(ctx context.Context, tenant string) (*Client, error) {
+	if tenant == "" {
+		return nil, ErrNoTenant
+	}
+
+	p.mu.Lock()
+	keyTTL := p.keyTTL
+	entry, ok := p.tenants[tenant]
+	if !ok {
+		webhookKeys := newWebhookKeyCache()
+		p.base.webhookKeys.mu.Lock()
+		webhookKeys.ttl = p.base.webhookKeys.ttl
+		p.base.webhookKeys.mu.Unlock()
+
+		entry = &tenantClient{rateLimiter: p.base.rateLimiter.clone(), webhookKeys: webhookKeys}
+		p.tenants[tenant] = entry
+	}
+	p.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	expired := keyTTL > 0 && time.Since(entry.resolvedAt) > keyTTL
+	if entry.client != nil && !entry.stale && !expired {
+		return entry.client, nil
+	}
+
+	apiKey, err := p.keys(ctx, tenant)
+	if err != nil {
+		if entry.client == nil {
+			p.forget(tenant, entry)
+		}
+		return nil, err
+	}
+
+	if entry.client == nil {
+		p.keep(tenant, entry)
+	}
+	if entry.client == nil || apiKey != entry.apiKey {
+		client := p.base.With(WithApiKey(apiKey))
+		client.rateLimiter = entry.rateLimiter
+		client.webhookKeys = entry.webhookKeys
+		client.webhookHmacKey = ""
+
+		entry.client = client
+		entry.apiKey = apiKey
+	}
+	entry.resolvedAt = time.Now()
+	entry.stale = false
+
+	return entry.client, nil
+}
+
+// forget drops entry, which has no client yet, so that tenants whose key
+// cannot be resolved do not pile up in the pool.
+func (p *ClientPool) forget(tenant string, entry *tenantClient) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.tenants[tenant] == entry {
+		delete(p.tenants, tenant)
+	}
+}
+
+// keep stores entry again when a concurrent call forgot it before its key
+// could be resolved.
+func (p *ClientPool) keep(tenant string, entry *tenantClient) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.tenants[tenant]; !ok {
+		p.tenants[tenant] = entry
+	}
+}
+
+// Invalidate makes the next call for tenant resolve its API key again, e.g.
+// after the key was rotated.
+func (p *ClientPool) Invalidate(tenant string) {
+	p.mu.Lock()
+	entry, ok := p.tenants[tenant]
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	entry.stale = true
+}
+
+// Remove drops the client of tenant from the pool.
+func (p *ClientPool) Remove(tenant string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.tenants, tenant)
+}
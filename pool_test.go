@@ -0,0 +1,153 @@
+package subrow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func tenantKeys(keys map[string]string, calls *atomic.Int32) TenantKeyFunc {
+	var mu sync.Mutex
+
+	return func(ctx context.Context, tenant string) (string, error) {
+		calls.Add(1)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		key, ok := keys[tenant]
+		if !ok {
+			return "", errors.New("unknown tenant")
+		}
+		return key, nil
+	}
+}
+
+func TestClientPool(t *testing.T) {
+	t.Run("When calling the API for several tenants", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, seen := recordingServer(c)
+		var calls atomic.Int32
+		base := NewClient(WithBaseURL(server.URL))
+		pool := NewClientPool(base, tenantKeys(map[string]string{"acme": "acme_key", "globex": "globex_key"}, &calls))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				tenant := "acme"
+				if i%2 == 0 {
+					tenant = "globex"
+				}
+				client, err := pool.Client(WithTenant(context.Background(), tenant))
+				c.Check(err, qt.IsNil)
+				_, clientErr := client.Tax().Get(context.Background(), "vat")
+				c.Check(clientErr == nil, qt.IsTrue)
+			}()
+		}
+		wg.Wait()
+
+		authorizations := map[string]int{}
+		for _, request := range seen() {
+			authorizations[request.Authorization]++
+		}
+		c.Assert(authorizations, qt.DeepEquals, map[string]int{"Bearer acme_key": 10, "Bearer globex_key": 10})
+		c.Assert(calls.Load(), qt.Equals, int32(2))
+
+		acme, _ := pool.ForTenant(context.Background(), "acme")
+		globex, _ := pool.ForTenant(context.Background(), "globex")
+		c.Assert(acme.HttpClient.GetClient().Transport, qt.Equals, base.HttpClient.GetClient().Transport)
+		c.Assert(acme.rateLimiter, qt.Not(qt.Equals), globex.rateLimiter)
+	})
+
+	t.Run("When the context has no tenant", func(t *testing.T) {
+		c := qt.New(t)
+
+		var calls atomic.Int32
+		pool := NewClientPool(NewClient(), tenantKeys(nil, &calls))
+
+		_, err := pool.Client(context.Background())
+		c.Assert(err, qt.Equals, ErrNoTenant)
+	})
+
+	t.Run("When the key of a tenant cannot be resolved", func(t *testing.T) {
+		c := qt.New(t)
+
+		var calls atomic.Int32
+		pool := NewClientPool(NewClient(), tenantKeys(nil, &calls))
+
+		_, err := pool.ForTenant(context.Background(), "initech")
+		c.Assert(err, qt.ErrorMatches, "unknown tenant")
+		c.Assert(pool.tenants, qt.HasLen, 0)
+	})
+
+	t.Run("When the base client has a webhook HMAC key", func(t *testing.T) {
+		c := qt.New(t)
+
+		var calls atomic.Int32
+		base := NewClient().SetWebhookHmacKey("base_hmac_key")
+		pool := NewClientPool(base, tenantKeys(map[string]string{"acme": "acme_key"}, &calls))
+
+		client, err := pool.ForTenant(context.Background(), "acme")
+		c.Assert(err, qt.IsNil)
+		c.Assert(client.webhookHmacKey, qt.Equals, "")
+		c.Assert(base.webhookHmacKey, qt.Equals, "base_hmac_key")
+	})
+
+	t.Run("When a key is rotated", func(t *testing.T) {
+		c := qt.New(t)
+
+		var calls atomic.Int32
+		keys := map[string]string{"acme": "old_key"}
+		pool := NewClientPool(NewClient(), tenantKeys(keys, &calls))
+
+		client, err := pool.ForTenant(context.Background(), "acme")
+		c.Assert(err, qt.IsNil)
+		c.Assert(client.HttpClient.Token, qt.Equals, "old_key")
+
+		keys["acme"] = "new_key"
+		pool.Invalidate("acme")
+
+		rotated, err := pool.ForTenant(context.Background(), "acme")
+		c.Assert(err, qt.IsNil)
+		c.Assert(rotated.HttpClient.Token, qt.Equals, "new_key")
+		c.Assert(rotated.rateLimiter, qt.Equals, client.rateLimiter)
+		c.Assert(client.HttpClient.Token, qt.Equals, "old_key")
+		c.Assert(calls.Load(), qt.Equals, int32(2))
+	})
+
+	t.Run("When keys expire", func(t *testing.T) {
+		c := qt.New(t)
+
+		var calls atomic.Int32
+		pool := NewClientPool(NewClient(), tenantKeys(map[string]string{"acme": "acme_key"}, &calls)).
+			SetKeyTTL(time.Millisecond)
+
+		client, _ := pool.ForTenant(context.Background(), "acme")
+		time.Sleep(5 * time.Millisecond)
+		again, _ := pool.ForTenant(context.Background(), "acme")
+
+		c.Assert(calls.Load(), qt.Equals, int32(2))
+		c.Assert(again, qt.Equals, client)
+	})
+
+	t.Run("When the base client is rate limited", func(t *testing.T) {
+		c := qt.New(t)
+
+		var calls atomic.Int32
+		base := NewClient().SetRateLimit(RateLimit{Rate: 5, Burst: 2})
+		pool := NewClientPool(base, tenantKeys(map[string]string{"acme": "acme_key"}, &calls))
+
+		client, _ := pool.ForTenant(context.Background(), "acme")
+		c.Assert(client.rateLimiter.global.rate, qt.Equals, 5.0)
+		c.Assert(client.rateLimiter.global, qt.Not(qt.Equals), base.rateLimiter.global)
+	})
+}
@@ -0,0 +1,336 @@
+package subrow
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	RateLimitRemainingHeader string = "X-RateLimit-Remaining"
+	RateLimitResetHeader     string = "X-RateLimit-Reset"
+)
+
+// RateLimit is the rate of a token bucket: Rate requests per second, with
+// bursts of up to Burst requests.
+type RateLimit struct {
+	Rate float64
+	// Burst defaults to 1.
+	Burst int
+}
+
+type RateLimitStats struct {
+	// Waits is the number of calls delayed by the rate limiter.
+	Waits int64
+	// TotalWait is the time calls spent waiting, summed.
+	TotalWait time.Duration
+}
+
+// rateLimiter holds the buckets of a Client, shared by every goroutine
+// using it. A call takes a token from the global bucket, and from the ingest
+// bucket when sent with the ingest client or else from the bucket of the
+// longest path prefix it matches. Calls to the API and to the ingest service
+// wait while the rate limit of their side is exhausted, buckets or not.
+type rateLimiter struct {
+	mu           sync.RWMutex
+	global       *tokenBucket
+	prefixes     map[string]*tokenBucket
+	ingest       *tokenBucket
+	paused       time.Time
+	ingestPaused time.Time
+
+	waits     atomic.Int64
+	totalWait atomic.Int64
+}
+
+// SetRateLimit limits the rate of every API call of the client. A zero Rate
+// removes the limit.
+func (c *Client) SetRateLimit(limit RateLimit) *Client {
+	c.limiter().setGlobal(limit)
+
+	return c
+}
+
+// SetPathRateLimit limits the rate of the API calls whose path starts with
+// prefix, such as "customers", on top of the client-wide limit. A zero Rate
+// removes the limit.
+func (c *Client) SetPathRateLimit(prefix string, limit RateLimit) *Client {
+	c.limiter().setPrefix(prefix, limit)
+
+	return c
+}
+
+// SetIngestRateLimit limits the rate of the events sent with the ingest
+// client, on top of the client-wide limit. A zero Rate removes the limit.
+func (c *Client) SetIngestRateLimit(limit RateLimit) *Client {
+	c.limiter().setIngest(limit)
+
+	return c
+}
+
+func (c *Client) limiter() *rateLimiter {
+	if c.rateLimiter == nil {
+		c.rateLimiter = newRateLimiter()
+	}
+
+	return c.rateLimiter
+}
+
+func (c *Client) RateLimitStats() RateLimitStats {
+	if c.rateLimiter == nil {
+		return RateLimitStats{}
+	}
+
+	return RateLimitStats{
+		Waits:     c.rateLimiter.waits.Load(),
+		TotalWait: time.Duration(c.rateLimiter.totalWait.Load()),
+	}
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{prefixes: map[string]*tokenBucket{}}
+}
+
+func (rl *rateLimiter) setGlobal(limit RateLimit) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.global = newTokenBucket(limit)
+}
+
+func (rl *rateLimiter) setPrefix(prefix string, limit RateLimit) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	prefix = strings.Trim(prefix, "/")
+	if bucket := newTokenBucket(limit); bucket != nil {
+		rl.prefixes[prefix] = bucket
+	} else {
+		delete(rl.prefixes, prefix)
+	}
+}
+
+func (rl *rateLimiter) setIngest(limit RateLimit) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.ingest = newTokenBucket(limit)
+}
+
+// clone returns a rate limiter with the limits of rl and full buckets.
+func (rl *rateLimiter) clone() *rateLimiter {
+	clone := newRateLimiter()
+	if rl == nil {
+		return clone
+	}
+
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	if rl.global != nil {
+		clone.global = rl.global.clone()
+	}
+	if rl.ingest != nil {
+		clone.ingest = rl.ingest.clone()
+	}
+	for prefix, bucket := range rl.prefixes {
+		clone.prefixes[prefix] = bucket.clone()
+	}
+
+	return clone
+}
+
+// buckets returns the global bucket, the bucket of the call, either nil,
+// and until when calls to its side are paused.
+func (rl *rateLimiter) buckets(ingest bool, path string) (*tokenBucket, *tokenBucket, time.Time) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	if ingest {
+		return rl.global, rl.ingest, rl.ingestPaused
+	}
+
+	path = strings.Trim(path, "/")
+	longest := ""
+	var prefixBucket *tokenBucket
+	for prefix, bucket := range rl.prefixes {
+		matches := path == prefix || strings.HasPrefix(path, prefix+"/")
+		if matches && len(prefix) >= len(longest) {
+			longest, prefixBucket = prefix, bucket
+		}
+	}
+
+	return rl.global, prefixBucket, rl.paused
+}
+
+// wait blocks until every bucket of the call has a token for it, and returns
+// how long it waited.
+func (rl *rateLimiter) wait(ctx context.Context, ingest bool, path string) (time.Duration, error) {
+	if rl == nil {
+		return 0, nil
+	}
+
+	global, bucket, paused := rl.buckets(ingest, path)
+	var buckets []*tokenBucket
+	for _, b := range []*tokenBucket{global, bucket} {
+		if b != nil {
+			buckets = append(buckets, b)
+		}
+	}
+
+	now := time.Now()
+	delay := paused.Sub(now)
+	for _, bucket := range buckets {
+		delay = max(delay, bucket.reserve(now))
+	}
+	if delay <= 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		for _, bucket := range buckets {
+			bucket.cancel()
+		}
+		return 0, ctx.Err()
+	case <-timer.C:
+	}
+
+	rl.waits.Add(1)
+	rl.totalWait.Add(int64(delay))
+
+	return delay, nil
+}
+
+// observe pauses the calls to the API, or to the ingest service, when a
+// response says its rate limit is exhausted, until the limit resets.
+func (rl *rateLimiter) observe(ingest bool, path string, resp *resty.Response) {
+	if rl == nil || resp == nil {
+		return
+	}
+
+	now := time.Now()
+	until, ok := rateLimitReset(resp.StatusCode(), resp.Header(), now)
+	if !ok {
+		return
+	}
+
+	rl.mu.Lock()
+	paused := &rl.paused
+	if ingest {
+		paused = &rl.ingestPaused
+	}
+	if until.After(*paused) {
+		*paused = until
+	}
+	rl.mu.Unlock()
+
+	// The global bucket is left alone, as it also paces the other side.
+	if _, bucket, _ := rl.buckets(ingest, path); bucket != nil {
+		bucket.pause(until)
+	}
+}
+
+// rateLimitReset returns when the API accepts calls again, if a response
+// says its rate limit is exhausted: a 429 with Retry-After, or no remaining
+// calls before X-RateLimit-Reset.
+func rateLimitReset(statusCode int, header http.Header, now time.Time) (time.Time, bool) {
+	if statusCode == http.StatusTooManyRequests {
+		if retryAfter, ok := parseRetryAfter(header.Get("Retry-After"), now); ok {
+			return now.Add(retryAfter), true
+		}
+	}
+
+	remaining, err := strconv.Atoi(header.Get(RateLimitRemainingHeader))
+	if err != nil || remaining > 0 {
+		return time.Time{}, false
+	}
+
+	reset, err := strconv.ParseInt(header.Get(RateLimitResetHeader), 10, 64)
+	if err != nil || reset < 0 {
+		return time.Time{}, false
+	}
+
+	// The reset is either a Unix time or a number of seconds from now.
+	if reset > now.Unix()/2 {
+		return time.Unix(reset, 0), true
+	}
+
+	return now.Add(time.Duration(reset) * time.Second), true
+}
+
+// tokenBucket is a token bucket whose tokens go negative while calls queue
+// up: a call reserving token -n waits until n tokens have been refilled.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	// last is when tokens was last refilled. It is in the future while the
+	// bucket is paused.
+	last time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.Rate <= 0 {
+		return nil
+	}
+
+	burst := float64(max(limit.Burst, 1))
+
+	return &tokenBucket{rate: limit.Rate, burst: burst, tokens: burst}
+}
+
+func (tb *tokenBucket) clone() *tokenBucket {
+	return &tokenBucket{rate: tb.rate, burst: tb.burst, tokens: tb.burst}
+}
+
+// reserve takes a token and returns how long to wait before it can be used.
+func (tb *tokenBucket) reserve(now time.Time) time.Duration {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	if now.After(tb.last) {
+		tb.tokens = min(tb.burst, tb.tokens+now.Sub(tb.last).Seconds()*tb.rate)
+		tb.last = now
+	}
+	tb.tokens--
+
+	ready := tb.last
+	if tb.tokens < 0 {
+		ready = ready.Add(time.Duration(-tb.tokens / tb.rate * float64(time.Second)))
+	}
+
+	return max(ready.Sub(now), 0)
+}
+
+// cancel gives back the token of a call that gave up waiting.
+func (tb *tokenBucket) cancel() {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.tokens = min(tb.burst, tb.tokens+1)
+}
+
+// pause holds the calls reserving a token from now on until until, then
+// lets them through at the bucket rate.
+func (tb *tokenBucket) pause(until time.Time) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	if !until.After(tb.last) {
+		return
+	}
+
+	tb.tokens = min(tb.tokens, 0)
+	tb.last = until
+}
@@ -0,0 +1,213 @@
+package subrow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func rateLimitServer(c *qt.C, header http.Header) (*httptest.Server, *atomic.Int32) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, values := range header {
+			w.Header()[name] = values
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if hits.Add(1) == 1 && header.Get("Retry-After") != "" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"status":429,"error":"Too Many Requests"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	c.Cleanup(server.Close)
+
+	return server, &hits
+}
+
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("When calls exceed the client rate", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, _ := rateLimitServer(c, nil)
+		client := New().SetBaseURL(server.URL).SetRateLimit(RateLimit{Rate: 20, Burst: 2})
+
+		start := time.Now()
+		var wg sync.WaitGroup
+		for range 6 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.Customer().Get(ctx, "cus_1")
+				c.Check(err == nil, qt.IsTrue)
+			}()
+		}
+		wg.Wait()
+
+		// 2 calls go through at once, the 4 others every 50ms.
+		c.Assert(time.Since(start) >= 190*time.Millisecond, qt.IsTrue)
+		c.Assert(client.RateLimitStats().Waits, qt.Equals, int64(4))
+	})
+
+	t.Run("When a path prefix is limited", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, _ := rateLimitServer(c, nil)
+		client := New().SetBaseURL(server.URL).SetPathRateLimit("customers", RateLimit{Rate: 10})
+
+		for range 3 {
+			_, err := client.Tax().Get(ctx, "vat")
+			c.Assert(err == nil, qt.IsTrue)
+		}
+		c.Assert(client.RateLimitStats().Waits, qt.Equals, int64(0))
+
+		for range 2 {
+			_, err := client.Customer().CurrentUsage(ctx, "cus_1", &CustomerUsageInput{})
+			c.Assert(err == nil, qt.IsTrue)
+		}
+		c.Assert(client.RateLimitStats().Waits, qt.Equals, int64(1))
+	})
+
+	t.Run("When the API rate limit is exhausted", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, hits := rateLimitServer(c, http.Header{"Retry-After": {"1"}})
+		client := New().SetBaseURL(server.URL)
+
+		_, err := client.Customer().Get(ctx, "cus_1")
+		c.Assert(err.HTTPStatusCode, qt.Equals, http.StatusTooManyRequests)
+
+		start := time.Now()
+		_, err = client.Customer().Get(ctx, "cus_1")
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(time.Since(start) >= 900*time.Millisecond, qt.IsTrue)
+		c.Assert(hits.Load(), qt.Equals, int32(2))
+	})
+
+	t.Run("When the ingest service is limited", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, _ := rateLimitServer(c, nil)
+		client := New().SetBaseURL(server.URL).SetIngestRateLimit(RateLimit{Rate: 10})
+
+		for range 3 {
+			_, err := client.Event().Get(ctx, "evt_1")
+			c.Assert(err == nil, qt.IsTrue)
+		}
+		c.Assert(client.RateLimitStats().Waits, qt.Equals, int64(0))
+
+		for range 2 {
+			_, err := client.Event().Create(ctx, &EventInput{TransactionID: "tr_1", Code: "api_calls"})
+			c.Assert(err == nil, qt.IsTrue)
+		}
+		c.Assert(client.RateLimitStats().Waits, qt.Equals, int64(1))
+	})
+
+	t.Run("When the ingest service rate limit is exhausted", func(t *testing.T) {
+		c := qt.New(t)
+
+		var ingested atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == http.MethodPost && r.URL.Path == "/api/v1/events" && ingested.Add(1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"status":429,"error":"Too Many Requests"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		c.Cleanup(server.Close)
+		client := New().SetBaseURL(server.URL)
+
+		_, err := client.Event().Create(ctx, &EventInput{TransactionID: "tr_1", Code: "api_calls"})
+		c.Assert(err.HTTPStatusCode, qt.Equals, http.StatusTooManyRequests)
+
+		// Management calls, events included, are not held by the ingest limit.
+		start := time.Now()
+		_, err = client.Event().Get(ctx, "evt_1")
+		c.Assert(err == nil, qt.IsTrue)
+		_, err = client.Customer().Get(ctx, "cus_1")
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(time.Since(start) < 500*time.Millisecond, qt.IsTrue)
+
+		_, err = client.Event().Create(ctx, &EventInput{TransactionID: "tr_1", Code: "api_calls"})
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(time.Since(start) >= 900*time.Millisecond, qt.IsTrue)
+	})
+
+	t.Run("When the context is done while waiting", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, hits := rateLimitServer(c, nil)
+		client := New().SetBaseURL(server.URL).SetRateLimit(RateLimit{Rate: 0.1})
+
+		_, err := client.Customer().Get(ctx, "cus_1")
+		c.Assert(err == nil, qt.IsTrue)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = client.Customer().Get(timeoutCtx, "cus_1")
+		c.Assert(errors.Is(err.Err, context.DeadlineExceeded), qt.IsTrue)
+		c.Assert(hits.Load(), qt.Equals, int32(1))
+	})
+}
+
+func TestRateLimitReset(t *testing.T) {
+	c := qt.New(t)
+	now := time.Unix(1_700_000_000, 0)
+
+	for _, test := range []struct {
+		status    int
+		remaining string
+		reset     string
+		want      time.Time
+		ok        bool
+	}{
+		{http.StatusOK, "", "", time.Time{}, false},
+		{http.StatusOK, "3", "10", time.Time{}, false},
+		{http.StatusOK, "0", "10", now.Add(10 * time.Second), true},
+		{http.StatusOK, "0", "1700000030", now.Add(30 * time.Second), true},
+		{http.StatusTooManyRequests, "", "", time.Time{}, false},
+	} {
+		header := http.Header{}
+		header.Set(RateLimitRemainingHeader, test.remaining)
+		header.Set(RateLimitResetHeader, test.reset)
+
+		until, ok := rateLimitReset(test.status, header, now)
+		c.Check(ok, qt.Equals, test.ok)
+		c.Check(until.Equal(test.want), qt.IsTrue, qt.Commentf("%v", header))
+	}
+
+	header := http.Header{}
+	header.Set("Retry-After", "5")
+	until, ok := rateLimitReset(http.StatusTooManyRequests, header, now)
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(until, qt.Equals, now.Add(5*time.Second))
+}
+
+func TestTokenBucket(t *testing.T) {
+	c := qt.New(t)
+	now := time.Unix(1_700_000_000, 0)
+
+	bucket := newTokenBucket(RateLimit{Rate: 2, Burst: 2})
+	c.Assert(bucket.reserve(now), qt.Equals, time.Duration(0))
+	c.Assert(bucket.reserve(now), qt.Equals, time.Duration(0))
+	c.Assert(bucket.reserve(now), qt.Equals, 500*time.Millisecond)
+	c.Assert(bucket.reserve(now), qt.Equals, time.Second)
+
+	bucket.cancel()
+	c.Assert(bucket.reserve(now.Add(time.Second)), qt.Equals, time.Duration(0))
+
+	bucket.pause(now.Add(10 * time.Second))
+	c.Assert(bucket.reserve(now.Add(time.Second)), qt.Equals, 9500*time.Millisecond)
+}
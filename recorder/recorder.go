@@ -0,0 +1,371 @@
+// Package recorder records the HTTP interactions of a subrow.Client to a
+// cassette file and replays them offline, so tests can pin the behavior of
+// real API endpoints.
+//
+//	rec, err := recorder.New("testdata/invoice_preview.yaml", &recorder.Options{
+//		Mode: recorder.ModeReplayOrRecord,
+//	})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Stop()
+//
+//	client := rec.Wrap(subrow.New().SetApiKey(os.Getenv("SUBROW_API_KEY")))
+//
+// Cassettes are YAML, or JSON when their name ends with ".json". The
+// Authorization header is redacted before a cassette is saved.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	subrow "github.com/subrowio/subrow-go-client"
+)
+
+type Mode int
+
+const (
+	// ModeReplay answers requests from the cassette and never hits the network.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the API and saves them to the cassette,
+	// replacing its content.
+	ModeRecord
+	// ModeReplayOrRecord replays the cassette if it exists and records it
+	// otherwise.
+	ModeReplayOrRecord
+)
+
+// MatchOn selects the parts of a request compared with the recorded ones.
+type MatchOn uint
+
+const (
+	MatchMethod MatchOn = 1 << iota
+	MatchPath
+	MatchQuery
+	// MatchBody compares bodies, ignoring key order and whitespace in JSON.
+	MatchBody
+
+	DefaultMatch = MatchMethod | MatchPath | MatchQuery
+)
+
+const redacted string = "[REDACTED]"
+
+var ErrInteractionNotFound = errors.New("recorder: no recorded interaction matches the request")
+
+type Options struct {
+	Mode Mode
+	// Match defaults to DefaultMatch.
+	Match MatchOn
+	// RedactHeaders lists request headers to redact in addition to
+	// Authorization.
+	RedactHeaders []string
+	// Transport sends requests while recording. Defaults to the transport of
+	// the wrapped client, or http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+type Cassette struct {
+	Interactions []Interaction `yaml:"interactions" json:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `yaml:"request" json:"request"`
+	Response Response `yaml:"response" json:"response"`
+}
+
+type Request struct {
+	Method  string      `yaml:"method" json:"method"`
+	URL     string      `yaml:"url" json:"url"`
+	Headers http.Header `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Body    string      `yaml:"body,omitempty" json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `yaml:"status_code" json:"status_code"`
+	Headers    http.Header `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Body       string      `yaml:"body,omitempty" json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper recording to, or replaying from, a
+// cassette.
+type Recorder struct {
+	path      string
+	recording bool
+	options   Options
+
+	mu       sync.Mutex
+	cassette Cassette
+	replayed []bool
+}
+
+// New loads the cassette at path, unless the recorder is recording it.
+func New(path string, opts *Options) (*Recorder, error) {
+	options := Options{}
+	if opts != nil {
+		options = *opts
+	}
+	if options.Match == 0 {
+		options.Match = DefaultMatch
+	}
+
+	r := &Recorder{path: path, options: options}
+
+	switch options.Mode {
+	case ModeRecord:
+		r.recording = true
+	case ModeReplayOrRecord:
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			r.recording = true
+		}
+	}
+
+	if r.recording {
+		return r, nil
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Recording reports whether requests are sent to the API.
+func (r *Recorder) Recording() bool {
+	return r.recording
+}
+
+// Wrap makes every request of client, including event ingestion, go through
+// the recorder.
+func (r *Recorder) Wrap(client *subrow.Client) *subrow.Client {
+	client.HttpClient.SetTransport(r.transport(client.HttpClient.GetClient().Transport))
+	client.IngestHttpClient.SetTransport(r.transport(client.IngestHttpClient.GetClient().Transport))
+
+	return client
+}
+
+func (r *Recorder) transport(real http.RoundTripper) http.RoundTripper {
+	if r.options.Transport != nil {
+		real = r.options.Transport
+	}
+	if real == nil {
+		real = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return r.roundTrip(req, real)
+	})
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	return r.transport(nil).RoundTrip(req)
+}
+
+// Stop saves the cassette when recording.
+func (r *Recorder) Stop() error {
+	if !r.recording {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.save()
+}
+
+func (r *Recorder) roundTrip(req *http.Request, real http.RoundTripper) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.recording {
+		return r.record(req, body, real)
+	}
+
+	return r.replay(req, body)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte, real http.RoundTripper) (*http.Response, error) {
+	resp, err := real.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: r.redact(req.Header),
+			Body:    string(body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Header.Clone(),
+			Body:       string(respBody),
+		},
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// replay answers with the first interaction matching req that has not been
+// replayed yet, so repeated requests get the responses in recorded order.
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.replayed[i] || !r.matches(req, body, interaction.Request) {
+			continue
+		}
+		r.replayed[i] = true
+
+		respBody := []byte(interaction.Response.Body)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Headers.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, req.URL)
+}
+
+func (r *Recorder) matches(req *http.Request, body []byte, recorded Request) bool {
+	if r.options.Match&MatchMethod != 0 && req.Method != recorded.Method {
+		return false
+	}
+
+	if r.options.Match&(MatchPath|MatchQuery) != 0 {
+		recordedURL, err := req.URL.Parse(recorded.URL)
+		if err != nil {
+			return false
+		}
+		if r.options.Match&MatchPath != 0 && req.URL.Path != recordedURL.Path {
+			return false
+		}
+		if r.options.Match&MatchQuery != 0 && !reflect.DeepEqual(req.URL.Query(), recordedURL.Query()) {
+			return false
+		}
+	}
+
+	if r.options.Match&MatchBody != 0 && !bodiesEqual(body, []byte(recorded.Body)) {
+		return false
+	}
+
+	return true
+}
+
+func (r *Recorder) redact(header http.Header) http.Header {
+	redactedHeader := header.Clone()
+	for _, name := range append([]string{"Authorization"}, r.options.RedactHeaders...) {
+		if redactedHeader.Get(name) != "" {
+			redactedHeader.Set(name, redacted)
+		}
+	}
+
+	return redactedHeader
+}
+
+func (r *Recorder) load() error {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+
+	if isJSON(r.path) {
+		err = json.Unmarshal(data, &r.cassette)
+	} else {
+		err = yaml.Unmarshal(data, &r.cassette)
+	}
+	if err != nil {
+		return fmt.Errorf("recorder: decoding %s: %w", r.path, err)
+	}
+
+	r.replayed = make([]bool, len(r.cassette.Interactions))
+
+	return nil
+}
+
+func (r *Recorder) save() error {
+	var data []byte
+	var err error
+	if isJSON(r.path) {
+		data, err = json.MarshalIndent(&r.cassette, "", "  ")
+	} else {
+		data, err = yaml.Marshal(&r.cassette)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.path, data, 0o644)
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+func bodiesEqual(a, b []byte) bool {
+	var jsonA, jsonB interface{}
+	if json.Unmarshal(a, &jsonA) == nil && json.Unmarshal(b, &jsonB) == nil {
+		return reflect.DeepEqual(jsonA, jsonB)
+	}
+
+	return bytes.Equal(a, b)
+}
+
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,168 @@
+package recorder_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	subrow "github.com/subrowio/subrow-go-client"
+	"github.com/subrowio/subrow-go-client/recorder"
+)
+
+// newAPI answers invoice previews with the plan code they were sent and
+// current usage with the subscription they were asked for.
+func newAPI(c *qt.C) (*httptest.Server, *atomic.Int32) {
+	var hits atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/api/v1/invoices/preview":
+			var input struct {
+				PlanCode string `json:"plan_code"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&input)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"invoice": map[string]interface{}{"number": "preview-" + input.PlanCode},
+			})
+		case "/api/v1/customers/cus_1/current_usage":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"customer_usage": map[string]interface{}{"total_amount_cents": len(r.URL.Query().Get("external_subscription_id"))},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	c.Cleanup(server.Close)
+
+	return server, &hits
+}
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("When a cassette is recorded then replayed", func(t *testing.T) {
+		c := qt.New(t)
+		server, hits := newAPI(c)
+		path := filepath.Join(c.TempDir(), "testdata", "preview.yaml")
+
+		rec, err := recorder.New(path, &recorder.Options{Mode: recorder.ModeReplayOrRecord})
+		c.Assert(err, qt.IsNil)
+		c.Assert(rec.Recording(), qt.IsTrue)
+
+		client := rec.Wrap(subrow.New().SetBaseURL(server.URL).SetApiKey("secret_api_key"))
+		invoice, apiErr := client.Invoice().Preview(ctx, &subrow.InvoicePreviewInput{PlanCode: "startup"})
+		c.Assert(apiErr == nil, qt.IsTrue)
+		c.Assert(invoice.Number, qt.Equals, "preview-startup")
+		c.Assert(rec.Stop(), qt.IsNil)
+
+		data, err := os.ReadFile(path)
+		c.Assert(err, qt.IsNil)
+		c.Assert(strings.Contains(string(data), "secret_api_key"), qt.IsFalse)
+		c.Assert(strings.Contains(string(data), "[REDACTED]"), qt.IsTrue)
+
+		server.Close()
+		rec, err = recorder.New(path, &recorder.Options{Mode: recorder.ModeReplayOrRecord, Match: recorder.DefaultMatch | recorder.MatchBody})
+		c.Assert(err, qt.IsNil)
+		c.Assert(rec.Recording(), qt.IsFalse)
+
+		client = rec.Wrap(subrow.New().SetBaseURL(server.URL).SetApiKey("other_api_key"))
+		invoice, apiErr = client.Invoice().Preview(ctx, &subrow.InvoicePreviewInput{PlanCode: "startup"})
+		c.Assert(apiErr == nil, qt.IsTrue)
+		c.Assert(invoice.Number, qt.Equals, "preview-startup")
+		c.Assert(hits.Load(), qt.Equals, int32(1))
+
+		_, apiErr = client.Invoice().Preview(ctx, &subrow.InvoicePreviewInput{PlanCode: "startup"})
+		c.Assert(errors.Is(apiErr.Err, recorder.ErrInteractionNotFound), qt.IsTrue)
+	})
+
+	t.Run("When the body is matched", func(t *testing.T) {
+		c := qt.New(t)
+		server, _ := newAPI(c)
+		path := filepath.Join(c.TempDir(), "preview.json")
+
+		rec, err := recorder.New(path, &recorder.Options{Mode: recorder.ModeRecord})
+		c.Assert(err, qt.IsNil)
+		client := rec.Wrap(subrow.New().SetBaseURL(server.URL).SetApiKey("secret_api_key"))
+		for _, planCode := range []string{"startup", "premium"} {
+			_, apiErr := client.Invoice().Preview(ctx, &subrow.InvoicePreviewInput{PlanCode: planCode})
+			c.Assert(apiErr == nil, qt.IsTrue)
+		}
+		c.Assert(rec.Stop(), qt.IsNil)
+
+		var cassette recorder.Cassette
+		data, err := os.ReadFile(path)
+		c.Assert(err, qt.IsNil)
+		c.Assert(json.Unmarshal(data, &cassette), qt.IsNil)
+		c.Assert(cassette.Interactions, qt.HasLen, 2)
+		c.Assert(cassette.Interactions[0].Request.Headers.Get("Authorization"), qt.Equals, "[REDACTED]")
+
+		rec, err = recorder.New(path, &recorder.Options{Match: recorder.MatchMethod | recorder.MatchPath | recorder.MatchBody})
+		c.Assert(err, qt.IsNil)
+		client = rec.Wrap(subrow.New().SetBaseURL("http://localhost:1").SetApiKey("secret_api_key"))
+
+		invoice, apiErr := client.Invoice().Preview(ctx, &subrow.InvoicePreviewInput{PlanCode: "premium"})
+		c.Assert(apiErr == nil, qt.IsTrue)
+		c.Assert(invoice.Number, qt.Equals, "preview-premium")
+
+		_, apiErr = client.Invoice().Preview(ctx, &subrow.InvoicePreviewInput{PlanCode: "enterprise"})
+		c.Assert(errors.Is(apiErr.Err, recorder.ErrInteractionNotFound), qt.IsTrue)
+	})
+
+	t.Run("When the query is matched", func(t *testing.T) {
+		c := qt.New(t)
+		server, _ := newAPI(c)
+		path := filepath.Join(c.TempDir(), "usage.yaml")
+
+		rec, err := recorder.New(path, &recorder.Options{Mode: recorder.ModeRecord})
+		c.Assert(err, qt.IsNil)
+		client := rec.Wrap(subrow.New().SetBaseURL(server.URL))
+		_, apiErr := client.Customer().CurrentUsage(ctx, "cus_1", &subrow.CustomerUsageInput{ExternalSubscriptionID: "sub_1"})
+		c.Assert(apiErr == nil, qt.IsTrue)
+		c.Assert(rec.Stop(), qt.IsNil)
+
+		rec, err = recorder.New(path, nil)
+		c.Assert(err, qt.IsNil)
+		client = rec.Wrap(subrow.New().SetBaseURL(server.URL))
+
+		_, apiErr = client.Customer().CurrentUsage(ctx, "cus_1", &subrow.CustomerUsageInput{ExternalSubscriptionID: "sub_22"})
+		c.Assert(errors.Is(apiErr.Err, recorder.ErrInteractionNotFound), qt.IsTrue)
+
+		usage, apiErr := client.Customer().CurrentUsage(ctx, "cus_1", &subrow.CustomerUsageInput{ExternalSubscriptionID: "sub_1"})
+		c.Assert(apiErr == nil, qt.IsTrue)
+		c.Assert(usage.TotalAmountCents, qt.Equals, 5)
+	})
+
+	t.Run("When the cassette does not exist", func(t *testing.T) {
+		c := qt.New(t)
+
+		_, err := recorder.New(filepath.Join(c.TempDir(), "missing.yaml"), &recorder.Options{Mode: recorder.ModeReplay})
+		c.Assert(errors.Is(err, os.ErrNotExist), qt.IsTrue)
+	})
+
+	t.Run("When used as a transport", func(t *testing.T) {
+		c := qt.New(t)
+		server, _ := newAPI(c)
+		path := filepath.Join(c.TempDir(), "usage.yaml")
+
+		rec, err := recorder.New(path, &recorder.Options{Mode: recorder.ModeRecord})
+		c.Assert(err, qt.IsNil)
+		resp, err := (&http.Client{Transport: rec}).Get(server.URL + "/api/v1/customers/cus_1/current_usage")
+		c.Assert(err, qt.IsNil)
+		body, _ := io.ReadAll(resp.Body)
+		c.Assert(string(body), qt.Equals, "{\"customer_usage\":{\"total_amount_cents\":0}}\n")
+		c.Assert(rec.Stop(), qt.IsNil)
+	})
+}
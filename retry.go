@@ -0,0 +1,146 @@
+package subrow
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const IdempotencyKeyHeader string = "Idempotency-Key"
+
+// RetryPolicy configures how the Client retries failed API calls.
+// A nil policy disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. It doubles on
+	// every subsequent retry, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter is the fraction (0 to 1) of each backoff that is randomized.
+	Jitter float64
+	// RespectRetryAfter makes the client wait at least as long as the
+	// Retry-After header of a 429 or 503 response asks for.
+	RespectRetryAfter bool
+	// RetryNonIdempotent allows POST requests without an Idempotency-Key
+	// header to be retried. Leave it off unless duplicates are harmless.
+	RetryNonIdempotent bool
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:       4,
+		InitialBackoff:    250 * time.Millisecond,
+		MaxBackoff:        10 * time.Second,
+		Jitter:            0.5,
+		RespectRetryAfter: true,
+	}
+}
+
+var retryableStatusCodes = map[int]bool{
+	http.StatusRequestTimeout:      true,
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// isRetryableError reports whether the failure is transient: a transport
+// error or a status code that is worth trying again.
+func isRetryableError(err *Error) bool {
+	if err == nil {
+		return false
+	}
+
+	if err.HTTPStatusCode == 0 {
+		if err.Err == nil {
+			return false
+		}
+
+		return !errors.Is(err.Err, context.Canceled) && !errors.Is(err.Err, context.DeadlineExceeded)
+	}
+
+	return retryableStatusCodes[err.HTTPStatusCode]
+}
+
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+func (rp *RetryPolicy) shouldRetry(ctx context.Context, method string, httpClient *resty.Client, request *resty.Request, attempt int, err *Error) bool {
+	if rp == nil || attempt >= rp.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	if !isRetryableError(err) {
+		return false
+	}
+
+	if isIdempotentMethod(method) || rp.RetryNonIdempotent {
+		return true
+	}
+
+	return request.Header.Get(IdempotencyKeyHeader) != "" || httpClient.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+func (rp *RetryPolicy) delay(attempt int, resp *resty.Response) time.Duration {
+	backoff := rp.InitialBackoff
+	for i := 1; i < attempt && (rp.MaxBackoff <= 0 || backoff < rp.MaxBackoff); i++ {
+		backoff *= 2
+	}
+
+	if rp.MaxBackoff > 0 && backoff > rp.MaxBackoff {
+		backoff = rp.MaxBackoff
+	}
+
+	if rp.Jitter > 0 && backoff > 0 {
+		jitter := time.Duration(float64(backoff) * min(rp.Jitter, 1))
+		backoff = backoff - jitter + rand.N(jitter+1)
+	}
+
+	if rp.RespectRetryAfter && resp != nil {
+		if retryAfter, ok := parseRetryAfter(resp.Header().Get("Retry-After"), time.Now()); ok && retryAfter > backoff {
+			backoff = retryAfter
+		}
+	}
+
+	return backoff
+}
+
+// parseRetryAfter accepts both forms allowed by RFC 9110: a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+
+	return 0, true
+}
@@ -0,0 +1,158 @@
+package subrow
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func flakyServer(c *qt.C, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			_, _ = fmt.Fprintf(w, `{"status":%d,"error":%q}`, status, http.StatusText(status))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"tax":{"code":"vat","name":"VAT"}}`))
+	}))
+	c.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func testRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:       3,
+		InitialBackoff:    time.Millisecond,
+		MaxBackoff:        5 * time.Millisecond,
+		RespectRetryAfter: true,
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Run("When a GET fails with a retryable status", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, calls := flakyServer(c, 2, http.StatusBadGateway, nil)
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key").SetRetryPolicy(testRetryPolicy())
+
+		tax, err := client.Tax().Get(context.Background(), "vat")
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(tax.Code, qt.Equals, "vat")
+		c.Assert(calls.Load(), qt.Equals, int32(3))
+	})
+
+	t.Run("When attempts are exhausted", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, calls := flakyServer(c, 5, http.StatusServiceUnavailable, nil)
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key").SetRetryPolicy(testRetryPolicy())
+
+		_, err := client.Tax().Get(context.Background(), "vat")
+		c.Assert(err, qt.IsNotNil)
+		c.Assert(err.HTTPStatusCode, qt.Equals, http.StatusServiceUnavailable)
+		c.Assert(calls.Load(), qt.Equals, int32(3))
+	})
+
+	t.Run("When the error is terminal", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, calls := flakyServer(c, 1, http.StatusUnprocessableEntity, nil)
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key").SetRetryPolicy(testRetryPolicy())
+
+		_, err := client.Tax().Get(context.Background(), "vat")
+		c.Assert(err, qt.IsNotNil)
+		c.Assert(calls.Load(), qt.Equals, int32(1))
+	})
+
+	t.Run("When a POST has no idempotency key", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, calls := flakyServer(c, 1, http.StatusBadGateway, nil)
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key").SetRetryPolicy(testRetryPolicy())
+
+		_, err := client.Tax().Create(context.Background(), &TaxInput{Code: "vat"})
+		c.Assert(err, qt.IsNotNil)
+		c.Assert(calls.Load(), qt.Equals, int32(1))
+	})
+
+	t.Run("When the server sends Retry-After", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, calls := flakyServer(c, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key").SetRetryPolicy(testRetryPolicy())
+
+		start := time.Now()
+		_, err := client.Tax().Get(context.Background(), "vat")
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(calls.Load(), qt.Equals, int32(2))
+		c.Assert(time.Since(start) >= time.Second, qt.IsTrue)
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	c := qt.New(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	wait, ok := parseRetryAfter("120", now)
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(wait, qt.Equals, 2*time.Minute)
+
+	wait, ok = parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now)
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(wait, qt.Equals, 30*time.Second)
+
+	_, ok = parseRetryAfter("soon", now)
+	c.Assert(ok, qt.IsFalse)
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	t.Run("When the key comes from the context", func(t *testing.T) {
+		c := qt.New(t)
+
+		var keys []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"status":502,"error":"Bad Gateway"}`))
+		}))
+		defer server.Close()
+
+		client := New().SetBaseURL(server.URL).SetApiKey("test_api_key").SetRetryPolicy(testRetryPolicy())
+		ctx := WithIdempotencyKey(context.Background(), "payment-42")
+
+		_, err := client.Payment().Create(ctx, &PaymentInput{InvoiceId: "invoice"})
+		c.Assert(err, qt.IsNotNil)
+		c.Assert(err.IdempotencyKey, qt.Equals, "payment-42")
+		c.Assert(keys, qt.DeepEquals, []string{"payment-42", "payment-42", "payment-42"})
+	})
+
+	t.Run("When keys are generated automatically", func(t *testing.T) {
+		c := qt.New(t)
+
+		server, calls := flakyServer(c, 1, http.StatusServiceUnavailable, nil)
+		client := New().
+			SetBaseURL(server.URL).
+			SetApiKey("test_api_key").
+			SetRetryPolicy(testRetryPolicy()).
+			SetAutoIdempotencyKey(true)
+
+		_, err := client.Tax().Create(context.Background(), &TaxInput{Code: "vat"})
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(calls.Load(), qt.Equals, int32(2))
+	})
+}
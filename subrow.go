@@ -3,7 +3,10 @@ package subrow
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 )
@@ -13,12 +16,22 @@ const baseIngestURL string = "https://ingest.subrow.com"
 const apiPath string = "/api/v1/"
 
 type Client struct {
-	BaseUrl          string
-	BaseIngestUrl    string
-	UseIngestService bool
-	Debug            bool
-	HttpClient       *resty.Client
-	IngestHttpClient *resty.Client
+	BaseUrl            string
+	BaseIngestUrl      string
+	UseIngestService   bool
+	Debug              bool
+	RetryPolicy        *RetryPolicy
+	AutoIdempotencyKey bool
+	Logger             *slog.Logger
+	LogOptions         *LogOptions
+	HttpClient         *resty.Client
+	IngestHttpClient   *resty.Client
+
+	options        clientOptions
+	webhookKeys    *webhookKeyCache
+	webhookHmacKey string
+	telemetry      *telemetry
+	rateLimiter    *rateLimiter
 }
 
 type ClientRequest struct {
@@ -28,6 +41,8 @@ type ClientRequest struct {
 	UrlValues        url.Values
 	Result           interface{}
 	Body             interface{}
+	Headers          map[string]string
+	IdempotencyKey   string
 }
 
 type Metadata struct {
@@ -38,43 +53,26 @@ type Metadata struct {
 	TotalCount  int `json:"total_count,omitempty"`
 }
 
+// New returns a Client with the default configuration, to be configured with
+// its Set methods. See NewClient for a client safe to share.
 func New() *Client {
-	url := fmt.Sprintf("%s%s", baseURL, apiPath)
-
-	restyClient := resty.New().
-		SetBaseURL(url).
-		SetHeader("Content-Type", "application/json").
-		SetHeader("User-Agent", "subrow-go-client github.com/subrowio/subrow-go-client/v1")
-
-	ingestRestyClient := resty.New().
-		SetBaseURL(url).
-		SetHeader("Content-Type", "application/json").
-		SetHeader("User-Agent", "subrow-go-client github.com/subrowio/subrow-go-client/v1")
-
-	return &Client{
-		BaseUrl:          url,
-		BaseIngestUrl:    url,
-		HttpClient:       restyClient,
-		IngestHttpClient: ingestRestyClient,
-	}
+	return NewClient()
 }
 
 func (c *Client) SetApiKey(apiKey string) *Client {
+	c.options.apiKey = apiKey
 	c.HttpClient = c.HttpClient.SetAuthToken(apiKey)
 	c.IngestHttpClient = c.IngestHttpClient.SetAuthToken(apiKey)
 
 	return c
 }
 
+// SetBaseURL sets the URL of the API. Events are sent to it too unless an
+// ingest URL is set or the ingest service is used.
 func (c *Client) SetBaseURL(url string) *Client {
 	c.BaseUrl = url
-	c.BaseIngestUrl = url
-
-	customURL := fmt.Sprintf("%s%s", url, apiPath)
-	c.HttpClient = c.HttpClient.SetBaseURL(customURL)
-	c.IngestHttpClient = c.IngestHttpClient.SetBaseURL(customURL)
 
-	return c
+	return c.setBaseURLs()
 }
 
 func (c *Client) SetDebug(debug bool) *Client {
@@ -83,23 +81,38 @@ func (c *Client) SetDebug(debug bool) *Client {
 	return c
 }
 
-func (c *Client) SetUseIngestService(useIngestService bool) *Client {
-	c.UseIngestService = useIngestService
+func (c *Client) SetRetryPolicy(retryPolicy *RetryPolicy) *Client {
+	c.RetryPolicy = retryPolicy
 
-	if useIngestService {
-		c = c.SetBaseIngestUrl(baseIngestURL)
-	} else {
-		c = c.SetBaseURL(c.BaseUrl)
-	}
+	return c
+}
+
+// SetAutoIdempotencyKey makes the client generate an Idempotency-Key for every
+// mutating request that does not carry one. The key is reused across retries.
+func (c *Client) SetAutoIdempotencyKey(autoIdempotencyKey bool) *Client {
+	c.AutoIdempotencyKey = autoIdempotencyKey
 
 	return c
 }
 
+// SetUseIngestService sends events to the ingest service rather than to the
+// API, unless an ingest URL is set with SetBaseIngestUrl.
+func (c *Client) SetUseIngestService(useIngestService bool) *Client {
+	c.UseIngestService = useIngestService
+
+	return c.setBaseURLs()
+}
+
 func (c *Client) SetBaseIngestUrl(url string) *Client {
-	c.BaseIngestUrl = url
+	c.options.baseIngestURL = url
+
+	return c.setBaseURLs()
+}
 
-	customURL := fmt.Sprintf("%s%s", url, apiPath)
-	c.IngestHttpClient = c.IngestHttpClient.SetBaseURL(customURL)
+func (c *Client) setBaseURLs() *Client {
+	c.BaseIngestUrl = c.ingestURL()
+	c.HttpClient = c.HttpClient.SetBaseURL(fmt.Sprintf("%s%s", c.BaseUrl, apiPath))
+	c.IngestHttpClient = c.IngestHttpClient.SetBaseURL(fmt.Sprintf("%s%s", c.BaseIngestUrl, apiPath))
 
 	return c
 }
@@ -107,33 +120,16 @@ func (c *Client) SetBaseIngestUrl(url string) *Client {
 func (c *Client) Get(ctx context.Context, cr *ClientRequest) (interface{}, *Error) {
 	hasResult := cr.Result != nil
 
-	request := c.HttpClient.R().
-		SetContext(ctx).
-		SetError(&Error{}).
-		SetQueryParams(cr.QueryParams).
-		SetQueryParamsFromValues(cr.UrlValues)
-
-	if hasResult {
-		request.SetResult(cr.Result)
-	}
-
-	resp, err := request.
-		Get(cr.Path)
-	if err != nil {
-		return nil, &Error{Err: err}
-	}
-
-	if c.Debug {
-		fmt.Println("REQUEST: ", resp.Request.RawRequest)
-		fmt.Println("RESPONSE: ", resp.String())
-	}
+	resp, err := c.do(ctx, http.MethodGet, c.HttpClient, cr, func(request *resty.Request) {
+		request.
+			SetQueryParams(cr.QueryParams).
+			SetQueryParamsFromValues(cr.UrlValues)
 
-	if resp.IsError() {
-		err, ok := resp.Error().(*Error)
-		if !ok {
-			return nil, &ErrorTypeAssert
+		if hasResult {
+			request.SetResult(cr.Result)
 		}
-
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -150,28 +146,12 @@ func (c *Client) Post(ctx context.Context, cr *ClientRequest) (interface{}, *Err
 		httpClient = c.IngestHttpClient
 	}
 
-	resp, err := httpClient.R().
-		SetContext(ctx).
-		SetError(&Error{}).
-		SetResult(cr.Result).
-		SetBody(cr.Body).
-		Post(cr.Path)
-
+	resp, err := c.do(ctx, http.MethodPost, httpClient, cr, func(request *resty.Request) {
+		request.
+			SetResult(cr.Result).
+			SetBody(cr.Body)
+	})
 	if err != nil {
-		return nil, &Error{Err: err}
-	}
-
-	if c.Debug {
-		fmt.Println("REQUEST: ", resp.Request.RawRequest)
-		fmt.Println("RESPONSE: ", resp.String())
-	}
-
-	if resp.IsError() {
-		err, ok := resp.Error().(*Error)
-		if !ok {
-			return nil, &ErrorTypeAssert
-		}
-
 		return nil, err
 	}
 
@@ -179,26 +159,10 @@ func (c *Client) Post(ctx context.Context, cr *ClientRequest) (interface{}, *Err
 }
 
 func (c *Client) PostWithoutResult(ctx context.Context, cr *ClientRequest) *Error {
-	resp, err := c.HttpClient.R().
-		SetContext(ctx).
-		SetError(&Error{}).
-		SetBody(cr.Body).
-		Post(cr.Path)
+	_, err := c.do(ctx, http.MethodPost, c.HttpClient, cr, func(request *resty.Request) {
+		request.SetBody(cr.Body)
+	})
 	if err != nil {
-		return &Error{Err: err}
-	}
-
-	if c.Debug {
-		fmt.Println("REQUEST: ", resp.Request.RawRequest)
-		fmt.Println("RESPONSE: ", resp.String())
-	}
-
-	if resp.IsError() {
-		err, ok := resp.Error().(*Error)
-		if !ok {
-			return &ErrorTypeAssert
-		}
-
 		return err
 	}
 
@@ -206,84 +170,114 @@ func (c *Client) PostWithoutResult(ctx context.Context, cr *ClientRequest) *Erro
 }
 
 func (c *Client) PostWithoutBody(ctx context.Context, cr *ClientRequest) (interface{}, *Error) {
-	resp, err := c.HttpClient.R().
-		SetContext(ctx).
-		SetError(&Error{}).
-		Post(cr.Path)
+	resp, err := c.do(ctx, http.MethodPost, c.HttpClient, cr, func(request *resty.Request) {})
 	if err != nil {
-		return nil, &Error{Err: err}
-	}
-
-	if c.Debug {
-		fmt.Println("REQUEST: ", resp.Request.RawRequest)
-		fmt.Println("RESPONSE: ", resp.String())
+		return nil, err
 	}
 
-	if resp.IsError() {
-		err, ok := resp.Error().(*Error)
-		if !ok {
-			return nil, &ErrorTypeAssert
-		}
+	return resp.Result(), nil
+}
 
+func (c *Client) Put(ctx context.Context, cr *ClientRequest) (interface{}, *Error) {
+	resp, err := c.do(ctx, http.MethodPut, c.HttpClient, cr, func(request *resty.Request) {
+		request.
+			SetResult(cr.Result).
+			SetBody(cr.Body)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return resp.Result(), nil
 }
 
-func (c *Client) Put(ctx context.Context, cr *ClientRequest) (interface{}, *Error) {
-	resp, err := c.HttpClient.R().
-		SetContext(ctx).
-		SetError(&Error{}).
-		SetResult(cr.Result).
-		SetBody(cr.Body).
-		Put(cr.Path)
+func (c *Client) Delete(ctx context.Context, cr *ClientRequest) (interface{}, *Error) {
+	resp, err := c.do(ctx, http.MethodDelete, c.HttpClient, cr, func(request *resty.Request) {
+		request.
+			SetResult(cr.Result).
+			SetBody(cr.Body).
+			SetQueryParams(cr.QueryParams)
+	})
 	if err != nil {
-		return nil, &Error{Err: err}
+		return nil, err
 	}
 
-	if c.Debug {
-		fmt.Println("REQUEST: ", resp.Request.RawRequest)
-		fmt.Println("RESPONSE: ", resp.String())
-	}
+	return resp.Result(), nil
+}
 
-	if resp.IsError() {
-		err, ok := resp.Error().(*Error)
-		if !ok {
-			return nil, &ErrorTypeAssert
+// do runs a single logical API call, retrying it according to the client's
+// RetryPolicy. prepare is applied to a fresh request on every attempt.
+func (c *Client) do(ctx context.Context, method string, httpClient *resty.Client, cr *ClientRequest, prepare func(*resty.Request)) (*resty.Response, *Error) {
+	idempotencyKey := c.idempotencyKey(ctx, method, cr)
+
+	ctx, call := c.telemetry.startCall(ctx, method, cr.Path)
+	ingest := httpClient == c.IngestHttpClient
+
+	for attempt := 1; ; attempt++ {
+		request := httpClient.R().
+			SetContext(ctx).
+			SetError(&Error{}).
+			SetHeaders(cr.Headers)
+		if idempotencyKey != "" {
+			request.SetHeader(IdempotencyKeyHeader, idempotencyKey)
+		}
+		prepare(request)
+		call.inject(ctx, request)
+
+		waited, err := c.rateLimiter.wait(ctx, ingest, cr.Path)
+		if err != nil {
+			clientErr := &Error{Err: err, IdempotencyKey: idempotencyKey}
+			call.end(ctx, nil, attempt, clientErr)
+			return nil, clientErr
+		}
+		call.rateLimited(ctx, waited)
+
+		start := time.Now()
+		resp, clientErr := c.execute(request, method, cr.Path)
+		c.rateLimiter.observe(ingest, cr.Path, resp)
+		c.logAttempt(ctx, method, cr.Path, request, resp, attempt, time.Since(start), clientErr)
+		if clientErr == nil {
+			call.end(ctx, resp, attempt, nil)
+			return resp, nil
 		}
+		clientErr.IdempotencyKey = idempotencyKey
 
-		return nil, err
+		if !c.RetryPolicy.shouldRetry(ctx, method, httpClient, request, attempt, clientErr) {
+			call.end(ctx, resp, attempt, clientErr)
+			return nil, clientErr
+		}
+		call.retry(ctx, attempt)
+
+		timer := time.NewTimer(c.RetryPolicy.delay(attempt, resp))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			call.end(ctx, resp, attempt, clientErr)
+			return nil, clientErr
+		case <-timer.C:
+		}
 	}
-
-	return resp.Result(), nil
 }
 
-func (c *Client) Delete(ctx context.Context, cr *ClientRequest) (interface{}, *Error) {
-	resp, err := c.HttpClient.R().
-		SetContext(ctx).
-		SetError(&Error{}).
-		SetResult(cr.Result).
-		SetBody(cr.Body).
-		SetQueryParams(cr.QueryParams).
-		Delete(cr.Path)
+func (c *Client) execute(request *resty.Request, method string, path string) (*resty.Response, *Error) {
+	resp, err := request.Execute(method, path)
 	if err != nil {
 		return nil, &Error{Err: err}
 	}
 
-	if c.Debug {
-		fmt.Println("REQUEST: ", resp.Request.RawRequest)
-		fmt.Println("RESPONSE: ", resp.String())
-	}
-
 	if resp.IsError() {
 		err, ok := resp.Error().(*Error)
 		if !ok {
-			return nil, &ErrorTypeAssert
+			typeAssertErr := ErrorTypeAssert
+			return resp, &typeAssertErr
 		}
 
-		return nil, err
+		if err.HTTPStatusCode == 0 {
+			err.HTTPStatusCode = resp.StatusCode()
+		}
+
+		return resp, err
 	}
 
-	return resp.Result(), nil
+	return resp, nil
 }
@@ -0,0 +1,547 @@
+package subrowtest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	subrow "github.com/subrowio/subrow-go-client"
+)
+
+const (
+	appliedCoupons string = "applied_coupons"
+	events         string = "events"
+
+	creditNoteVoided string = "voided"
+)
+
+// resource describes a collection served with the usual create, get, list,
+// update and delete endpoints, keyed by the key field in URLs.
+type resource struct {
+	plural   string
+	singular string
+	key      string
+	required []string
+	filters  []string
+	// upsert makes a create with an existing key update the object instead
+	// of failing, like customers do.
+	upsert bool
+	// terminate makes delete flag the object as terminated instead of
+	// removing it.
+	terminate bool
+
+	validate func(s *Server, item object) *subrow.Error
+	build    func(s *Server, item object)
+	actions  map[string]func(s *Server, item object) *subrow.Error
+}
+
+func resources() []*resource {
+	return []*resource{
+		{
+			plural:   "customers",
+			singular: "customer",
+			key:      "external_id",
+			required: []string{"external_id"},
+			upsert:   true,
+		},
+		{
+			plural:   "billable_metrics",
+			singular: "billable_metric",
+			key:      "code",
+			required: []string{"name", "code", "aggregation_type"},
+			validate: validateBillableMetric,
+		},
+		{
+			plural:   "plans",
+			singular: "plan",
+			key:      "code",
+			required: []string{"name", "code", "interval", "amount_currency"},
+			validate: validatePlan,
+			build:    buildPlan,
+		},
+		{
+			plural:    "subscriptions",
+			singular:  "subscription",
+			key:       "external_id",
+			required:  []string{"external_customer_id", "plan_code", "external_id"},
+			filters:   []string{"external_customer_id", "plan_code", "status"},
+			terminate: true,
+			validate:  validateSubscription,
+			build:     buildSubscription,
+		},
+		{
+			plural:   "invoices",
+			singular: "invoice",
+			key:      "subrow_id",
+			required: []string{"external_customer_id", "fees"},
+			filters:  []string{"external_customer_id", "status", "payment_status"},
+			validate: validateCustomerExists,
+			build:    buildInvoice,
+			actions: map[string]func(s *Server, item object) *subrow.Error{
+				"finalize": finalizeInvoice,
+				"refresh":  refreshInvoice,
+				"void":     voidInvoice,
+			},
+		},
+		{
+			plural:    "wallets",
+			singular:  "wallet",
+			key:       "subrow_id",
+			required:  []string{"external_customer_id", "currency", "rate_amount"},
+			filters:   []string{"external_customer_id"},
+			terminate: true,
+			validate:  validateCustomerExists,
+			build:     buildWallet,
+		},
+		{
+			plural:   "coupons",
+			singular: "coupon",
+			key:      "code",
+			required: []string{"name", "code", "coupon_type", "frequency", "expiration"},
+			validate: validateCoupon,
+		},
+		{
+			plural:   "taxes",
+			singular: "tax",
+			key:      "code",
+			required: []string{"name", "code", "rate"},
+		},
+		{
+			plural:   "credit_notes",
+			singular: "credit_note",
+			key:      "subrow_id",
+			required: []string{"invoice_id", "reason"},
+			filters:  []string{"external_customer_id"},
+			validate: validateCreditNote,
+			build:    buildCreditNote,
+			actions: map[string]func(s *Server, item object) *subrow.Error{
+				"void": voidCreditNote,
+			},
+		},
+	}
+}
+
+func validateBillableMetric(s *Server, item object) *subrow.Error {
+	switch subrow.AggregationType(fmt.Sprint(item["aggregation_type"])) {
+	case subrow.CountAggregation:
+		return nil
+	case subrow.SumAggregation, subrow.MaxAggregation, subrow.UniqueCountAggregation,
+		subrow.RecurringCountAggregation, subrow.WeightedSumAggregation, "latest_agg", "custom_agg":
+		if isBlank(item["field_name"]) {
+			return validationError(map[string][]string{"field_name": {"value_is_mandatory"}})
+		}
+		return nil
+	}
+
+	return validationError(map[string][]string{"aggregation_type": {"value_is_invalid"}})
+}
+
+func validatePlan(s *Server, item object) *subrow.Error {
+	switch item["interval"] {
+	case "weekly", "monthly", "quarterly", "semiannual", "yearly":
+	default:
+		return validationError(map[string][]string{"interval": {"value_is_invalid"}})
+	}
+
+	charges, _ := item["charges"].([]interface{})
+	for _, charge := range charges {
+		charge, _ := charge.(map[string]interface{})
+		if metric, _ := s.collections["billable_metrics"].find("subrow_id", charge["billable_metric_id"]); metric == nil {
+			return notFound("billable_metric")
+		}
+	}
+
+	return nil
+}
+
+func buildPlan(s *Server, item object) {
+	charges, _ := item["charges"].([]interface{})
+	for _, charge := range charges {
+		charge, _ := charge.(map[string]interface{})
+		metric, _ := s.collections["billable_metrics"].find("subrow_id", charge["billable_metric_id"])
+
+		charge["subrow_id"] = uuid.NewString()
+		charge["subrow_billable_metric_id"] = charge["billable_metric_id"]
+		charge["billable_metric_code"] = metric["code"]
+		charge["created_at"] = item["created_at"]
+	}
+}
+
+func validateSubscription(s *Server, item object) *subrow.Error {
+	if plan, _ := s.collections["plans"].find("code", item["plan_code"]); plan == nil {
+		return notFound("plan")
+	}
+
+	return nil
+}
+
+// buildSubscription creates the customer when it does not exist yet, as the
+// API does.
+func buildSubscription(s *Server, item object) {
+	customers := s.collections["customers"]
+	customer, _ := customers.find("external_id", item["external_customer_id"])
+	if customer == nil {
+		customer = object{
+			"subrow_id":   uuid.NewString(),
+			"external_id": item["external_customer_id"],
+			"created_at":  item["created_at"],
+		}
+		customers.items = append(customers.items, customer)
+	}
+
+	item["subrow_customer_id"] = customer["subrow_id"]
+	item["status"] = string(subrow.SubscriptionStatusActive)
+	item["started_at"] = item["created_at"]
+	if isBlank(item["billing_time"]) {
+		item["billing_time"] = "calendar"
+	}
+}
+
+func validateCustomerExists(s *Server, item object) *subrow.Error {
+	if customer, _ := s.collections["customers"].find("external_id", item["external_customer_id"]); customer == nil {
+		return notFound("customer")
+	}
+
+	return nil
+}
+
+// buildInvoice turns the fees of a one-off invoice into finalized fees.
+func buildInvoice(s *Server, item object) {
+	customer, _ := s.collections["customers"].find("external_id", item["external_customer_id"])
+	currency := item["currency"]
+	if isBlank(currency) {
+		currency = customer["currency"]
+	}
+
+	total := 0
+	var fees []interface{}
+	inputs, _ := item["fees"].([]interface{})
+	for _, input := range inputs {
+		input, _ := input.(map[string]interface{})
+		units, _ := input["units"].(float64)
+		unitAmountCents, _ := input["unit_amount_cents"].(float64)
+		amountCents := int(units * unitAmountCents)
+		total += amountCents
+
+		fees = append(fees, object{
+			"subrow_id":             uuid.NewString(),
+			"subrow_invoice_id":     item["subrow_id"],
+			"amount_cents":          amountCents,
+			"amount_currency":       currency,
+			"total_amount_cents":    amountCents,
+			"total_amount_currency": currency,
+			"units":                 strconv.FormatFloat(units, 'f', -1, 64),
+			"description":           input["description"],
+			"invoice_display_name":  input["invoice_display_name"],
+			"item": object{
+				"type": "add_on",
+				"code": input["add_on_code"],
+			},
+		})
+	}
+
+	sequence := s.nextSequence()
+	item["fees"] = fees
+	item["customer"] = customer
+	item["currency"] = currency
+	item["sequential_id"] = sequence
+	item["number"] = fmt.Sprintf("INV-%03d", sequence)
+	item["invoice_type"] = string(subrow.OneOffInvoiceType)
+	item["status"] = string(subrow.InvoiceStatusFinalized)
+	item["payment_status"] = string(subrow.InvoicePaymentStatusPending)
+	item["issuing_date"] = s.now().UTC().Format("2006-01-02")
+	item["fees_amount_cents"] = total
+	item["sub_total_excluding_taxes_amount_cents"] = total
+	item["sub_total_including_taxes_amount_cents"] = total
+	item["total_amount_cents"] = total
+}
+
+func finalizeInvoice(s *Server, item object) *subrow.Error {
+	if item["status"] != string(subrow.InvoiceStatusDraft) {
+		return notAllowed("invoice_not_draft")
+	}
+	item["status"] = string(subrow.InvoiceStatusFinalized)
+
+	return nil
+}
+
+func refreshInvoice(s *Server, item object) *subrow.Error {
+	if item["status"] != string(subrow.InvoiceStatusDraft) {
+		return notAllowed("invoice_not_draft")
+	}
+	item["updated_at"] = s.timestamp()
+
+	return nil
+}
+
+func voidInvoice(s *Server, item object) *subrow.Error {
+	if item["status"] != string(subrow.InvoiceStatusFinalized) || item["payment_status"] == string(subrow.InvoicePaymentStatusSucceeded) {
+		return notAllowed("not_voidable")
+	}
+	item["status"] = string(subrow.InvoiceStatusVoided)
+
+	return nil
+}
+
+func buildWallet(s *Server, item object) {
+	customer, _ := s.collections["customers"].find("external_id", item["external_customer_id"])
+	paid, _ := strconv.ParseFloat(fmt.Sprint(item["paid_credits"]), 64)
+	granted, _ := strconv.ParseFloat(fmt.Sprint(item["granted_credits"]), 64)
+	rate, _ := strconv.ParseFloat(fmt.Sprint(item["rate_amount"]), 64)
+	credits := paid + granted
+
+	item["subrow_customer_id"] = customer["subrow_id"]
+	item["status"] = string(subrow.Active)
+	item["credits_balance"] = strconv.FormatFloat(credits, 'f', -1, 64)
+	item["balance_cents"] = int(credits * rate * 100)
+	item["consumed_credits"] = "0"
+	delete(item, "paid_credits")
+	delete(item, "granted_credits")
+	delete(item, "recurring_transaction_rules")
+	delete(item, "transaction_metadata")
+}
+
+func validateCoupon(s *Server, item object) *subrow.Error {
+	switch subrow.CouponCalculationType(fmt.Sprint(item["coupon_type"])) {
+	case subrow.CouponTypeFixedAmount:
+		return validateRequired(item, []string{"amount_cents", "amount_currency"})
+	case subrow.CouponTypePercentage:
+		return validateRequired(item, []string{"percentage_rate"})
+	}
+
+	return validationError(map[string][]string{"coupon_type": {"value_is_invalid"}})
+}
+
+func validateCreditNote(s *Server, item object) *subrow.Error {
+	invoice, _ := s.collections["invoices"].find("subrow_id", item["invoice_id"])
+	if invoice == nil {
+		return notFound("invoice")
+	}
+
+	if invoice["status"] != string(subrow.InvoiceStatusFinalized) {
+		return validationError(map[string][]string{"invoice": {"invalid_type_or_status"}})
+	}
+
+	return nil
+}
+
+func buildCreditNote(s *Server, item object) {
+	invoice, _ := s.collections["invoices"].find("subrow_id", item["invoice_id"])
+	fees, _ := invoice["fees"].([]interface{})
+
+	total := 0
+	var items []interface{}
+	inputs, _ := item["items"].([]interface{})
+	for _, input := range inputs {
+		input, _ := input.(map[string]interface{})
+		amountCents, _ := input["amount_cents"].(float64)
+		total += int(amountCents)
+
+		creditNoteItem := object{
+			"subrow_id":       uuid.NewString(),
+			"amount_cents":    int(amountCents),
+			"amount_currency": invoice["currency"],
+		}
+		for _, fee := range fees {
+			if fee, _ := fee.(object); fmt.Sprint(fee["subrow_id"]) == fmt.Sprint(input["fee_id"]) {
+				creditNoteItem["fee"] = fee
+			}
+		}
+		items = append(items, creditNoteItem)
+	}
+
+	sequence := s.nextSequence()
+	customer, _ := invoice["customer"].(object)
+	item["items"] = items
+	item["sequential_id"] = sequence
+	item["number"] = fmt.Sprintf("%s-CN%03d", invoice["number"], sequence)
+	item["subrow_invoice_id"] = invoice["subrow_id"]
+	item["invoice_number"] = invoice["number"]
+	item["external_customer_id"] = customer["external_id"]
+	item["currency"] = invoice["currency"]
+	item["credit_status"] = string(subrow.CreditNoteCreditStatusAvailable)
+	item["total_amount_cents"] = total
+	item["credit_amount_cents"] = total
+	item["balance_amount_cents"] = total
+	delete(item, "invoice_id")
+}
+
+func voidCreditNote(s *Server, item object) *subrow.Error {
+	if item["credit_status"] == creditNoteVoided {
+		return notAllowed("no_voidable_amount")
+	}
+	item["credit_status"] = creditNoteVoided
+	item["balance_amount_cents"] = 0
+
+	return nil
+}
+
+func (s *Server) routeAppliedCoupons(r *http.Request, segments []string, body object) (interface{}, *subrow.Error) {
+	c := s.collections[appliedCoupons]
+
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		var matching []object
+		for _, item := range c.items {
+			if matchesFilters(item, r.URL.Query(), []string{"external_customer_id", "status", "coupon_code"}) {
+				matching = append(matching, item)
+			}
+		}
+		items, meta := paginate(matching, r.URL.Query())
+		return object{appliedCoupons: items, "meta": meta}, nil
+
+	case len(segments) == 1 && r.Method == http.MethodPost:
+		input, apiErr := rootParam(body, "applied_coupon")
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if apiErr := validateRequired(input, []string{"external_customer_id", "coupon_code"}); apiErr != nil {
+			return nil, apiErr
+		}
+
+		customer, _ := s.collections["customers"].find("external_id", input["external_customer_id"])
+		if customer == nil {
+			return nil, notFound("customer")
+		}
+		coupon, _ := s.collections["coupons"].find("code", input["coupon_code"])
+		if coupon == nil {
+			return nil, notFound("coupon")
+		}
+
+		item := object{
+			"subrow_id":            uuid.NewString(),
+			"subrow_coupon_id":     coupon["subrow_id"],
+			"coupon_code":          coupon["code"],
+			"coupon_name":          coupon["name"],
+			"subrow_customer_id":   customer["subrow_id"],
+			"external_customer_id": customer["external_id"],
+			"status":               string(subrow.AppliedCouponStatusActive),
+			"amount_cents":         coupon["amount_cents"],
+			"amount_currency":      coupon["amount_currency"],
+			"percentage_rate":      coupon["percentage_rate"],
+			"frequency":            coupon["frequency"],
+			"frequency_duration":   coupon["frequency_duration"],
+			"created_at":           s.timestamp(),
+		}
+		for key, value := range input {
+			if !isBlank(value) {
+				item[key] = value
+			}
+		}
+		c.items = append(c.items, item)
+
+		return object{"applied_coupon": item}, nil
+	}
+
+	return nil, notFound("route")
+}
+
+func (s *Server) deleteAppliedCoupon(externalCustomerID string, appliedCouponID string) (interface{}, *subrow.Error) {
+	item, _ := s.collections[appliedCoupons].find("subrow_id", appliedCouponID)
+	if item == nil || item["external_customer_id"] != externalCustomerID {
+		return nil, notFound("applied_coupon")
+	}
+
+	item["status"] = string(subrow.AppliedCouponStatusTerminated)
+	item["terminated_at"] = s.timestamp()
+
+	return object{"applied_coupon": item}, nil
+}
+
+func (s *Server) routeEvents(r *http.Request, segments []string, body object) (interface{}, *subrow.Error) {
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodPost:
+		input, apiErr := rootParam(body, "event")
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if details := s.validateEvent(input, nil); details != nil {
+			return nil, validationError(details)
+		}
+		return object{"event": s.storeEvent(input)}, nil
+
+	case len(segments) == 2 && segments[1] == "batch" && r.Method == http.MethodPost:
+		inputs, ok := body[events].([]interface{})
+		if !ok || len(inputs) == 0 {
+			return nil, badRequest("param is missing or the value is empty: events")
+		}
+
+		seen := map[string]bool{}
+		rows := map[int]map[string][]string{}
+		for i, input := range inputs {
+			input, _ := input.(map[string]interface{})
+			if details := s.validateEvent(input, seen); details != nil {
+				rows[i] = details
+			}
+			seen[fmt.Sprint(input["transaction_id"])] = true
+		}
+		if len(rows) > 0 {
+			return nil, &subrow.Error{
+				HTTPStatusCode: http.StatusUnprocessableEntity,
+				Message:        http.StatusText(http.StatusUnprocessableEntity),
+				ErrorCode:      "validation_errors",
+				ErrorDetail:    &subrow.ErrorDetail{Multiple: true, Errors: rows},
+			}
+		}
+
+		stored := make([]object, 0, len(inputs))
+		for _, input := range inputs {
+			stored = append(stored, s.storeEvent(input.(map[string]interface{})))
+		}
+		return object{events: stored}, nil
+
+	case len(segments) == 2 && r.Method == http.MethodGet:
+		item, _ := s.collections[events].find("transaction_id", segments[1])
+		if item == nil {
+			return nil, notFound("event")
+		}
+		return object{"event": item}, nil
+	}
+
+	return nil, notFound("route")
+}
+
+func (s *Server) validateEvent(input object, batch map[string]bool) map[string][]string {
+	details := map[string][]string{}
+	for _, field := range []string{"transaction_id", "code"} {
+		if isBlank(input[field]) {
+			details[field] = []string{"value_is_mandatory"}
+		}
+	}
+	if isBlank(input["external_subscription_id"]) && isBlank(input["external_customer_id"]) {
+		details["external_subscription_id"] = []string{"value_is_mandatory"}
+	}
+
+	if existing, _ := s.collections[events].find("transaction_id", input["transaction_id"]); existing != nil || batch[fmt.Sprint(input["transaction_id"])] {
+		details["transaction_id"] = []string{string(subrow.ErrorCodeAlreadyExist)}
+	}
+
+	if len(details) == 0 {
+		return nil
+	}
+
+	return details
+}
+
+func (s *Server) storeEvent(input object) object {
+	item := object{}
+	for key, value := range input {
+		item[key] = value
+	}
+	item["subrow_id"] = uuid.NewString()
+	item["created_at"] = s.timestamp()
+	// Events are sent with a Unix timestamp but returned in RFC 3339.
+	timestamp := s.now().UTC()
+	if seconds, err := strconv.ParseFloat(fmt.Sprint(item["timestamp"]), 64); err == nil {
+		timestamp = time.Unix(0, int64(seconds*float64(time.Second))).UTC()
+	}
+	item["timestamp"] = timestamp.Format(time.RFC3339)
+
+	c := s.collections[events]
+	c.items = append(c.items, item)
+
+	return item
+}
@@ -0,0 +1,499 @@
+// Package subrowtest provides an in-memory fake of the Subrow API for
+// integration tests.
+//
+//	fake := subrowtest.NewServer()
+//	defer fake.Close()
+//
+//	client := subrow.New().SetBaseURL(fake.URL).SetApiKey("test_api_key")
+//
+// The fake keeps customers, plans, billable metrics, subscriptions, events,
+// invoices, wallets, coupons, taxes and credit notes in memory. It answers
+// with the same payloads, validation errors and pagination metadata as the
+// API, so subrow.Error and subrow.ErrorDetail can be asserted on as usual.
+package subrowtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	subrow "github.com/subrowio/subrow-go-client"
+)
+
+const (
+	apiPath        string = "/api/v1/"
+	defaultPerPage int    = 20
+	maxPerPage     int    = 100
+)
+
+type object = map[string]interface{}
+
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	now         func() time.Time
+	collections map[string]*collection
+	resources   map[string]*resource
+	sequence    int
+}
+
+// NewServer starts a fake with no data. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		now:         time.Now,
+		collections: map[string]*collection{},
+		resources:   map[string]*resource{},
+	}
+
+	for _, r := range resources() {
+		s.resources[r.plural] = r
+		s.collections[r.plural] = &collection{}
+	}
+	s.collections[appliedCoupons] = &collection{}
+	s.collections[events] = &collection{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Insert stores object, typically one of the subrow structs, as is in the
+// collection of the given path (e.g. "invoices"). It allows seeding data the
+// API cannot create directly, like draft invoices.
+func (s *Server) Insert(path string, v interface{}) error {
+	item, err := toObject(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.collections[path]
+	if !ok {
+		return fmt.Errorf("subrowtest: unknown collection %q", path)
+	}
+	if isBlank(item["subrow_id"]) {
+		item["subrow_id"] = uuid.NewString()
+	}
+	c.items = append(c.items, item)
+
+	return nil
+}
+
+// List decodes every object of the collection at path into out, which must
+// be a pointer to a slice, e.g. *[]subrow.Event for "events".
+func (s *Server) List(path string, out interface{}) error {
+	s.mu.Lock()
+	c, ok := s.collections[path]
+	var data []byte
+	var err error
+	if ok {
+		data, err = json.Marshal(c.items)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("subrowtest: unknown collection %q", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, apiPath) {
+		writeError(w, notFound("route"))
+		return
+	}
+
+	if r.Header.Get("Authorization") == "" {
+		writeError(w, &subrow.Error{
+			HTTPStatusCode: http.StatusUnauthorized,
+			Message:        http.StatusText(http.StatusUnauthorized),
+		})
+		return
+	}
+
+	var body object
+	if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, badRequest(err.Error()))
+			return
+		}
+	}
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, apiPath), "/"), "/")
+
+	s.mu.Lock()
+	result, apiErr := s.route(r, segments, body)
+	s.mu.Unlock()
+
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func (s *Server) route(r *http.Request, segments []string, body object) (interface{}, *subrow.Error) {
+	switch segments[0] {
+	case events:
+		return s.routeEvents(r, segments, body)
+	case appliedCoupons:
+		return s.routeAppliedCoupons(r, segments, body)
+	case "customers":
+		if len(segments) == 4 && segments[2] == appliedCoupons && r.Method == http.MethodDelete {
+			return s.deleteAppliedCoupon(segments[1], segments[3])
+		}
+	}
+
+	res, ok := s.resources[segments[0]]
+	if !ok {
+		return nil, notFound("route")
+	}
+
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		return s.list(res, r)
+	case len(segments) == 1 && r.Method == http.MethodPost:
+		return s.create(res, body)
+	case len(segments) == 2 && r.Method == http.MethodGet:
+		item, apiErr := s.find(res, segments[1])
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		return object{res.singular: item}, nil
+	case len(segments) == 2 && r.Method == http.MethodPut:
+		return s.update(res, segments[1], body)
+	case len(segments) == 2 && r.Method == http.MethodDelete:
+		return s.delete(res, segments[1])
+	case len(segments) == 3 && (r.Method == http.MethodPost || r.Method == http.MethodPut):
+		return s.action(res, segments[1], segments[2])
+	}
+
+	return nil, notFound("route")
+}
+
+func (s *Server) list(res *resource, r *http.Request) (interface{}, *subrow.Error) {
+	query := r.URL.Query()
+
+	var matching []object
+	for _, item := range s.collections[res.plural].items {
+		if matchesFilters(item, query, res.filters) {
+			matching = append(matching, item)
+		}
+	}
+
+	items, meta := paginate(matching, query)
+	return object{res.plural: items, "meta": meta}, nil
+}
+
+func (s *Server) create(res *resource, body object) (interface{}, *subrow.Error) {
+	input, apiErr := rootParam(body, res.singular)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := validateRequired(input, res.required); apiErr != nil {
+		return nil, apiErr
+	}
+
+	c := s.collections[res.plural]
+	if res.key != "subrow_id" {
+		if existing, _ := c.find(res.key, input[res.key]); existing != nil {
+			if !res.upsert {
+				return nil, validationError(map[string][]string{res.key: {string(subrow.ErrorCodeAlreadyExist)}})
+			}
+			return s.update(res, fmt.Sprint(input[res.key]), body)
+		}
+	}
+
+	if res.validate != nil {
+		if apiErr := res.validate(s, input); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	item := object{}
+	for key, value := range input {
+		item[key] = value
+	}
+	item["subrow_id"] = uuid.NewString()
+	item["created_at"] = s.timestamp()
+	if res.build != nil {
+		res.build(s, item)
+	}
+	c.items = append(c.items, item)
+
+	return object{res.singular: item}, nil
+}
+
+func (s *Server) update(res *resource, key string, body object) (interface{}, *subrow.Error) {
+	item, apiErr := s.find(res, key)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	input, apiErr := rootParam(body, res.singular)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	for k, v := range input {
+		if k == "subrow_id" || k == res.key || isBlank(v) {
+			continue
+		}
+		item[k] = v
+	}
+	item["updated_at"] = s.timestamp()
+
+	return object{res.singular: item}, nil
+}
+
+func (s *Server) delete(res *resource, key string) (interface{}, *subrow.Error) {
+	item, apiErr := s.find(res, key)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if res.terminate {
+		if item["status"] == "terminated" {
+			return nil, notAllowed("already_terminated")
+		}
+		item["status"] = "terminated"
+		item["terminated_at"] = s.timestamp()
+		return object{res.singular: item}, nil
+	}
+
+	s.collections[res.plural].remove(item)
+	return object{res.singular: item}, nil
+}
+
+func (s *Server) action(res *resource, key string, name string) (interface{}, *subrow.Error) {
+	act, ok := res.actions[name]
+	if !ok {
+		return nil, notFound("route")
+	}
+
+	item, apiErr := s.find(res, key)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := act(s, item); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return object{res.singular: item}, nil
+}
+
+func (s *Server) find(res *resource, key string) (object, *subrow.Error) {
+	item, _ := s.collections[res.plural].find(res.key, key)
+	if item == nil {
+		return nil, notFound(res.singular)
+	}
+
+	return item, nil
+}
+
+func (s *Server) timestamp() string {
+	return s.now().UTC().Format(time.RFC3339)
+}
+
+func (s *Server) nextSequence() int {
+	s.sequence++
+	return s.sequence
+}
+
+type collection struct {
+	items []object
+}
+
+func (c *collection) find(key string, value interface{}) (object, int) {
+	if isBlank(value) {
+		return nil, -1
+	}
+
+	for i, item := range c.items {
+		if fmt.Sprint(item[key]) == fmt.Sprint(value) {
+			return item, i
+		}
+	}
+
+	return nil, -1
+}
+
+func (c *collection) remove(item object) {
+	for i, candidate := range c.items {
+		if fmt.Sprint(candidate["subrow_id"]) == fmt.Sprint(item["subrow_id"]) {
+			c.items = append(c.items[:i], c.items[i+1:]...)
+			return
+		}
+	}
+}
+
+func rootParam(body object, name string) (object, *subrow.Error) {
+	input, ok := body[name].(map[string]interface{})
+	if !ok {
+		return nil, badRequest(fmt.Sprintf("param is missing or the value is empty: %s", name))
+	}
+
+	return input, nil
+}
+
+func validateRequired(input object, fields []string) *subrow.Error {
+	details := map[string][]string{}
+	for _, field := range fields {
+		if isBlank(input[field]) {
+			details[field] = []string{"value_is_mandatory"}
+		}
+	}
+
+	if len(details) > 0 {
+		return validationError(details)
+	}
+
+	return nil
+}
+
+func matchesFilters(item object, query map[string][]string, filters []string) bool {
+	for _, filter := range filters {
+		values := query[filter]
+		if len(values) == 0 {
+			values = query[filter+"[]"]
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		matched := false
+		for _, value := range values {
+			if fmt.Sprint(item[filter]) == value {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+func paginate(items []object, query map[string][]string) ([]object, subrow.Metadata) {
+	page := queryInt(query, "page", 1)
+	perPage := min(queryInt(query, "per_page", defaultPerPage), maxPerPage)
+
+	totalPages := (len(items) + perPage - 1) / perPage
+	meta := subrow.Metadata{
+		CurrentPage: page,
+		TotalPages:  totalPages,
+		TotalCount:  len(items),
+	}
+	if page < totalPages {
+		meta.NextPage = page + 1
+	}
+	if page > 1 {
+		meta.PrevPage = page - 1
+	}
+
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+
+	return append([]object{}, items[start:end]...), meta
+}
+
+func queryInt(query map[string][]string, name string, fallback int) int {
+	values := query[name]
+	if len(values) == 0 {
+		return fallback
+	}
+
+	value, err := strconv.Atoi(values[0])
+	if err != nil || value <= 0 {
+		return fallback
+	}
+
+	return value
+}
+
+func isBlank(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == "" || v == uuid.Nil.String()
+	case []interface{}:
+		return len(v) == 0
+	}
+
+	return false
+}
+
+func toObject(v interface{}) (object, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var item object
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+func writeError(w http.ResponseWriter, apiErr *subrow.Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.HTTPStatusCode)
+	_ = json.NewEncoder(w).Encode(apiErr)
+}
+
+func badRequest(message string) *subrow.Error {
+	return &subrow.Error{
+		HTTPStatusCode: http.StatusBadRequest,
+		Message:        fmt.Sprintf("BadRequest: %s", message),
+	}
+}
+
+func notFound(name string) *subrow.Error {
+	return &subrow.Error{
+		HTTPStatusCode: http.StatusNotFound,
+		Message:        http.StatusText(http.StatusNotFound),
+		ErrorCode:      fmt.Sprintf("%s_not_found", name),
+	}
+}
+
+func notAllowed(code string) *subrow.Error {
+	return &subrow.Error{
+		HTTPStatusCode: http.StatusMethodNotAllowed,
+		Message:        http.StatusText(http.StatusMethodNotAllowed),
+		ErrorCode:      code,
+	}
+}
+
+func validationError(details map[string][]string) *subrow.Error {
+	return &subrow.Error{
+		HTTPStatusCode: http.StatusUnprocessableEntity,
+		Message:        http.StatusText(http.StatusUnprocessableEntity),
+		ErrorCode:      "validation_errors",
+		ErrorDetail:    &subrow.ErrorDetail{Errors: map[int]map[string][]string{0: details}},
+	}
+}
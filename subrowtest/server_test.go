@@ -0,0 +1,170 @@
+package subrowtest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	subrow "github.com/subrowio/subrow-go-client"
+	"github.com/subrowio/subrow-go-client/subrowtest"
+)
+
+func newClient(c *qt.C) (*subrowtest.Server, *subrow.Client) {
+	fake := subrowtest.NewServer()
+	c.Cleanup(fake.Close)
+
+	return fake, subrow.New().SetBaseURL(fake.URL).SetApiKey("test_api_key")
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("When a customer subscribes and is invoiced", func(t *testing.T) {
+		c := qt.New(t)
+		fake, client := newClient(c)
+
+		customer, err := client.Customer().Create(ctx, &subrow.CustomerInput{ExternalID: "cus_1", Name: "Acme", Currency: subrow.EUR})
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(customer.ExternalID, qt.Equals, "cus_1")
+
+		metric, err := client.BillableMetric().Create(ctx, &subrow.BillableMetricInput{
+			Name:            "API calls",
+			Code:            "calls",
+			AggregationType: subrow.CountAggregation,
+		})
+		c.Assert(err == nil, qt.IsTrue)
+
+		plan, err := client.Plan().Create(ctx, &subrow.PlanInput{
+			Name:           "Startup",
+			Code:           "startup",
+			Interval:       subrow.PlanMonthly,
+			AmountCents:    1000,
+			AmountCurrency: subrow.EUR,
+			Charges:        []subrow.PlanChargeInput{{BillableMetricID: metric.SubrowID, ChargeModel: subrow.StandardChargeModel}},
+		})
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(plan.Charges, qt.HasLen, 1)
+
+		subscription, err := client.Subscription().Create(ctx, &subrow.SubscriptionInput{
+			ExternalCustomerID: "cus_1",
+			PlanCode:           "startup",
+			ExternalID:         "sub_1",
+		})
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(subscription.Status, qt.Equals, subrow.SubscriptionStatusActive)
+		c.Assert(subscription.SubrowCustomerID, qt.Equals, customer.SubrowID)
+
+		_, err = client.Event().Create(ctx, &subrow.EventInput{TransactionID: "tr_1", ExternalSubscriptionID: "sub_1", Code: "calls"})
+		c.Assert(err == nil, qt.IsTrue)
+
+		var events []subrow.EventInput
+		c.Assert(fake.List("events", &events), qt.IsNil)
+		c.Assert(events, qt.HasLen, 1)
+
+		invoice, err := client.Invoice().Create(ctx, &subrow.InvoiceOneOffInput{
+			ExternalCustomerId: "cus_1",
+			Currency:           "EUR",
+			Fees:               []subrow.InvoiceFeesInput{{AddOnCode: "setup", Units: 2, UnitAmountCents: 500}},
+		})
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(invoice.Status, qt.Equals, subrow.InvoiceStatusFinalized)
+		c.Assert(invoice.TotalAmountCents, qt.Equals, 1000)
+		c.Assert(invoice.Fees, qt.HasLen, 1)
+
+		creditNote, err := client.CreditNote().Create(ctx, &subrow.CreditNoteInput{
+			SubrowInvoiceID: invoice.SubrowID,
+			Reason:          subrow.CreditNoteReasonOther,
+			Items:           []subrow.CreditNoteItemInput{{SubrowFeeID: invoice.Fees[0].SubrowID, AmountCents: 400}},
+		})
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(creditNote.TotalAmountCents, qt.Equals, 400)
+		c.Assert(creditNote.InvoiceNumber, qt.Equals, invoice.Number)
+
+		voided, err := client.Invoice().Void(ctx, invoice.SubrowID.String(), nil)
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(voided.Status, qt.Equals, subrow.InvoiceStatusVoided)
+
+		terminated, err := client.Subscription().Terminate(ctx, subrow.SubscriptionTerminateInput{ExternalID: "sub_1"})
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(terminated.Status, qt.Equals, subrow.SubscriptionStatusTerminated)
+	})
+
+	t.Run("When input is invalid", func(t *testing.T) {
+		c := qt.New(t)
+		_, client := newClient(c)
+
+		_, err := client.BillableMetric().Create(ctx, &subrow.BillableMetricInput{
+			Name:            "Storage",
+			Code:            "storage",
+			AggregationType: subrow.SumAggregation,
+		})
+		c.Assert(err.HTTPStatusCode, qt.Equals, http.StatusUnprocessableEntity)
+		c.Assert(err.ErrorCode, qt.Equals, "validation_errors")
+		details, _ := err.ErrorDetail.Details()
+		c.Assert(details, qt.DeepEquals, map[string][]string{"field_name": {"value_is_mandatory"}})
+
+		rate := float32(20)
+		_, err = client.Tax().Create(ctx, &subrow.TaxInput{Name: "VAT", Code: "vat", Rate: &rate})
+		c.Assert(err == nil, qt.IsTrue)
+		_, err = client.Tax().Create(ctx, &subrow.TaxInput{Name: "VAT", Code: "vat", Rate: &rate})
+		details, _ = err.ErrorDetail.Details()
+		c.Assert(details, qt.DeepEquals, map[string][]string{"code": {"value_already_exist"}})
+
+		_, err = client.Wallet().Create(ctx, &subrow.WalletInput{ExternalCustomerID: "unknown", Currency: subrow.EUR, RateAmount: "1"})
+		c.Assert(err.HTTPStatusCode, qt.Equals, http.StatusNotFound)
+		c.Assert(err.ErrorCode, qt.Equals, "customer_not_found")
+
+		_, err = client.Coupon().Get(ctx, "missing")
+		c.Assert(err.ErrorCode, qt.Equals, "coupon_not_found")
+
+		_, err = client.Event().Batch(ctx, &[]subrow.EventInput{
+			{TransactionID: "tr_1", ExternalSubscriptionID: "sub_1", Code: "calls"},
+			{TransactionID: "tr_2", ExternalSubscriptionID: "sub_1"},
+		})
+		c.Assert(err.ErrorDetail.Multiple, qt.IsTrue)
+		row, _ := err.ErrorDetail.DetailsForRow(1)
+		c.Assert(row, qt.DeepEquals, map[string][]string{"code": {"value_is_mandatory"}})
+	})
+
+	t.Run("When results span several pages", func(t *testing.T) {
+		c := qt.New(t)
+		_, client := newClient(c)
+
+		for _, id := range []string{"cus_1", "cus_2", "cus_3"} {
+			_, err := client.Customer().Create(ctx, &subrow.CustomerInput{ExternalID: id})
+			c.Assert(err == nil, qt.IsTrue)
+		}
+
+		result, err := client.Customer().GetList(ctx, &subrow.CustomerListInput{PerPage: 2, Page: 1})
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(result.Customers, qt.HasLen, 2)
+		c.Assert(result.Meta, qt.DeepEquals, subrow.Metadata{CurrentPage: 1, NextPage: 2, TotalPages: 2, TotalCount: 3})
+
+		var ids []string
+		for customer, err := range client.Customer().All(ctx, &subrow.CustomerListInput{PerPage: 2}) {
+			c.Assert(err, qt.IsNil)
+			ids = append(ids, customer.ExternalID)
+		}
+		c.Assert(ids, qt.DeepEquals, []string{"cus_1", "cus_2", "cus_3"})
+	})
+
+	t.Run("When data is seeded", func(t *testing.T) {
+		c := qt.New(t)
+		fake, client := newClient(c)
+
+		c.Assert(fake.Insert("invoices", subrow.Invoice{Number: "INV-DRAFT", Status: subrow.InvoiceStatusDraft}), qt.IsNil)
+
+		list, err := client.Invoice().GetList(ctx, &subrow.InvoiceListInput{Status: subrow.InvoiceStatusDraft})
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(list.Invoices, qt.HasLen, 1)
+
+		finalized, err := client.Invoice().Finalize(ctx, list.Invoices[0].SubrowID.String())
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(finalized.Status, qt.Equals, subrow.InvoiceStatusFinalized)
+
+		_, err = client.Invoice().Finalize(ctx, list.Invoices[0].SubrowID.String())
+		c.Assert(err.HTTPStatusCode, qt.Equals, http.StatusMethodNotAllowed)
+	})
+}
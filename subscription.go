@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/go-querystring/query"
@@ -48,6 +49,22 @@ type ChargeOverridesInput struct {
 	Properties         map[string]interface{} `json:"properties"`
 	Filters            []ChargeFilter         `json:"filters,omitempty"`
 	TaxCodes           []string               `json:"tax_codes,omitempty"`
+
+	// TypedProperties replaces Properties when set. They are validated
+	// before the subscription is sent.
+	TypedProperties ChargeProperties `json:"-"`
+}
+
+func (coi ChargeOverridesInput) MarshalJSON() ([]byte, error) {
+	type alias ChargeOverridesInput
+	if coi.TypedProperties == nil {
+		return json.Marshal(alias(coi))
+	}
+
+	return json.Marshal(struct {
+		alias
+		Properties ChargeProperties `json:"properties"`
+	}{alias(coi), coi.TypedProperties})
 }
 
 type MinimumCommitmentOverridesInput struct {
@@ -137,7 +154,26 @@ func (c *Client) Subscription() *SubscriptionRequest {
 	}
 }
 
+// validate checks the typed properties of the charge overrides of the
+// subscription.
+func (si *SubscriptionInput) validate() *Error {
+	if si == nil || si.PlanOverrides == nil {
+		return nil
+	}
+
+	errs := fieldErrors{}
+	for i, charge := range si.PlanOverrides.Charges {
+		validateChargeProperties(errs, fmt.Sprintf("plan_overrides.charges[%d].", i), "", charge.TypedProperties, charge.Filters)
+	}
+
+	return errs.err()
+}
+
 func (sr *SubscriptionRequest) Create(ctx context.Context, subscriptionInput *SubscriptionInput) (*Subscription, *Error) {
+	if err := subscriptionInput.validate(); err != nil {
+		return nil, err
+	}
+
 	subscriptionParam := &SubscriptionParams{
 		Subscription: subscriptionInput,
 	}
@@ -239,7 +275,26 @@ func (sr *SubscriptionRequest) GetList(ctx context.Context, subscriptionListInpu
 	return subscriptionResult, nil
 }
 
+// All iterates over every subscription matching the input, fetching pages as needed.
+func (sr *SubscriptionRequest) All(ctx context.Context, subscriptionListInput SubscriptionListInput, opts ...IteratorOption) iter.Seq2[Subscription, error] {
+	return paginate(ctx, subscriptionListInput.Page, func(ctx context.Context, page int) ([]Subscription, Metadata, *Error) {
+		pageInput := subscriptionListInput
+		pageInput.Page = page
+
+		result, err := sr.GetList(ctx, pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.Subscriptions, result.Meta, nil
+	}, opts)
+}
+
 func (sr *SubscriptionRequest) Update(ctx context.Context, subscriptionInput *SubscriptionInput) (*Subscription, *Error) {
+	if err := subscriptionInput.validate(); err != nil {
+		return nil, err
+	}
+
 	subPath := fmt.Sprintf("%s/%s", "subscriptions", subscriptionInput.ExternalID)
 	subscriptionParam := &SubscriptionParams{
 		Subscription: subscriptionInput,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -104,6 +105,26 @@ func (adr *TaxRequest) GetList(ctx context.Context, taxListInput *TaxListInput)
 	return taxResult, nil
 }
 
+// All iterates over every tax matching the input, fetching pages as needed.
+func (adr *TaxRequest) All(ctx context.Context, taxListInput *TaxListInput, opts ...IteratorOption) iter.Seq2[Tax, error] {
+	input := TaxListInput{}
+	if taxListInput != nil {
+		input = *taxListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]Tax, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := adr.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.Taxes, result.Meta, nil
+	}, opts)
+}
+
 func (adr *TaxRequest) Create(ctx context.Context, taxInput *TaxInput) (*Tax, *Error) {
 	taxParams := &TaxParams{
 		Tax: taxInput,
@@ -0,0 +1,314 @@
+package subrow
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName string = "github.com/subrowio/subrow-go-client"
+
+const (
+	OperationAttribute  attribute.Key = "subrow.operation"
+	ErrorCodeAttribute  attribute.Key = "subrow.error_code"
+	DropReasonAttribute attribute.Key = "subrow.drop_reason"
+)
+
+const (
+	dropReasonQueueFull   string = "queue_full"
+	dropReasonRejected    string = "rejected"
+	dropReasonUndelivered string = "undelivered"
+)
+
+// TelemetryOptions configures the OpenTelemetry instrumentation of a Client.
+// Nil fields default to the global providers and propagator.
+type TelemetryOptions struct {
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+	// Propagator injects the trace context into the headers of every request.
+	Propagator propagation.TextMapPropagator
+}
+
+type telemetry struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	requests       metric.Int64Counter
+	duration       metric.Float64Histogram
+	retries        metric.Int64Counter
+	rateLimitWait  metric.Float64Histogram
+	eventsIngested metric.Int64Counter
+	eventsDropped  metric.Int64Counter
+}
+
+// SetTelemetry traces every API call with a span named after its resource
+// and operation, such as subrow.invoice.finalize, and records request,
+// retry and event ingestion metrics.
+func (c *Client) SetTelemetry(opts *TelemetryOptions) *Client {
+	c.telemetry = newTelemetry(opts)
+
+	return c
+}
+
+func newTelemetry(opts *TelemetryOptions) *telemetry {
+	options := TelemetryOptions{}
+	if opts != nil {
+		options = *opts
+	}
+	if options.TracerProvider == nil {
+		options.TracerProvider = otel.GetTracerProvider()
+	}
+	if options.MeterProvider == nil {
+		options.MeterProvider = otel.GetMeterProvider()
+	}
+	if options.Propagator == nil {
+		options.Propagator = otel.GetTextMapPropagator()
+	}
+
+	meter := options.MeterProvider.Meter(instrumentationName)
+	t := &telemetry{
+		tracer:     options.TracerProvider.Tracer(instrumentationName),
+		propagator: options.Propagator,
+	}
+
+	// Instruments that fail to be created are no-ops: report the error and
+	// carry on.
+	var err error
+	if t.requests, err = meter.Int64Counter("subrow.client.requests",
+		metric.WithDescription("Number of API calls, retries excluded."),
+		metric.WithUnit("{request}")); err != nil {
+		otel.Handle(err)
+	}
+	if t.duration, err = meter.Float64Histogram("subrow.client.request.duration",
+		metric.WithDescription("Duration of API calls, retries included."),
+		metric.WithUnit("s")); err != nil {
+		otel.Handle(err)
+	}
+	if t.retries, err = meter.Int64Counter("subrow.client.retries",
+		metric.WithDescription("Number of API call attempts that were retried."),
+		metric.WithUnit("{retry}")); err != nil {
+		otel.Handle(err)
+	}
+	if t.rateLimitWait, err = meter.Float64Histogram("subrow.client.rate_limit.wait",
+		metric.WithDescription("Time API calls were delayed by the client rate limiter."),
+		metric.WithUnit("s")); err != nil {
+		otel.Handle(err)
+	}
+	if t.eventsIngested, err = meter.Int64Counter("subrow.client.events.ingested",
+		metric.WithDescription("Number of events delivered by an EventIngestor."),
+		metric.WithUnit("{event}")); err != nil {
+		otel.Handle(err)
+	}
+	if t.eventsDropped, err = meter.Int64Counter("subrow.client.events.dropped",
+		metric.WithDescription("Number of events an EventIngestor gave up on."),
+		metric.WithUnit("{event}")); err != nil {
+		otel.Handle(err)
+	}
+
+	return t
+}
+
+// telemetryCall instruments a single API call. A nil call, returned when
+// telemetry is disabled, does nothing.
+type telemetryCall struct {
+	telemetry *telemetry
+	span      trace.Span
+	start     time.Time
+	attrs     []attribute.KeyValue
+}
+
+func (t *telemetry) startCall(ctx context.Context, method string, path string) (context.Context, *telemetryCall) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	operation := operationName(method, path)
+	attrs := []attribute.KeyValue{
+		OperationAttribute.String(operation),
+		semconv.HTTPRequestMethodKey.String(method),
+	}
+
+	ctx, span := t.tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(semconv.URLPath(path)))
+
+	return ctx, &telemetryCall{telemetry: t, span: span, start: time.Now(), attrs: attrs}
+}
+
+// inject propagates the trace context of the call to request.
+func (tc *telemetryCall) inject(ctx context.Context, request *resty.Request) {
+	if tc == nil {
+		return
+	}
+
+	tc.telemetry.propagator.Inject(ctx, propagation.HeaderCarrier(request.Header))
+}
+
+func (tc *telemetryCall) retry(ctx context.Context, attempt int) {
+	if tc == nil {
+		return
+	}
+
+	tc.span.AddEvent("retry", trace.WithAttributes(attribute.Int("subrow.attempt", attempt)))
+	tc.telemetry.retries.Add(ctx, 1, metric.WithAttributes(tc.attrs...))
+}
+
+func (tc *telemetryCall) rateLimited(ctx context.Context, waited time.Duration) {
+	if tc == nil || waited <= 0 {
+		return
+	}
+
+	tc.span.AddEvent("rate_limited", trace.WithAttributes(attribute.Float64("subrow.wait_seconds", waited.Seconds())))
+	tc.telemetry.rateLimitWait.Record(ctx, waited.Seconds(), metric.WithAttributes(tc.attrs...))
+}
+
+func (tc *telemetryCall) end(ctx context.Context, resp *resty.Response, attempts int, err *Error) {
+	if tc == nil {
+		return
+	}
+	defer tc.span.End()
+
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode()
+	}
+	if err != nil && err.HTTPStatusCode != 0 {
+		statusCode = err.HTTPStatusCode
+	}
+
+	attrs := append([]attribute.KeyValue(nil), tc.attrs...)
+	if statusCode != 0 {
+		attrs = append(attrs, semconv.HTTPResponseStatusCode(statusCode))
+	}
+	if err != nil {
+		errorType := http.StatusText(statusCode)
+		if err.ErrorCode != "" {
+			errorType = err.ErrorCode
+		}
+		if errorType == "" {
+			errorType = "transport"
+		}
+		attrs = append(attrs, semconv.ErrorTypeKey.String(errorType))
+	}
+
+	tc.telemetry.requests.Add(ctx, 1, metric.WithAttributes(attrs...))
+	tc.telemetry.duration.Record(ctx, time.Since(tc.start).Seconds(), metric.WithAttributes(attrs...))
+
+	tc.span.SetAttributes(attrs...)
+	tc.span.SetAttributes(attribute.Int("subrow.attempts", attempts))
+	if err == nil {
+		return
+	}
+
+	if err.ErrorCode != "" {
+		tc.span.SetAttributes(ErrorCodeAttribute.String(err.ErrorCode))
+	}
+	tc.span.RecordError(err)
+	tc.span.SetStatus(codes.Error, err.Message)
+}
+
+func (t *telemetry) eventsIngestedAdd(ctx context.Context, count int) {
+	if t == nil || count == 0 {
+		return
+	}
+
+	t.eventsIngested.Add(ctx, int64(count))
+}
+
+func (t *telemetry) eventsDroppedAdd(ctx context.Context, count int, reason string) {
+	if t == nil || count == 0 {
+		return
+	}
+
+	t.eventsDropped.Add(ctx, int64(count), metric.WithAttributes(DropReasonAttribute.String(reason)))
+}
+
+// subresources are the path segments naming a collection nested under
+// another resource, as in subscriptions/{id}/alerts.
+var subresources = map[string]bool{
+	"alerts":              true,
+	"applied_coupons":     true,
+	"wallet_transactions": true,
+}
+
+// operationName names the operation of a call from its method and path:
+// invoices/{id}/finalize is subrow.invoice.finalize, a GET of customers is
+// subrow.customer.list.
+func operationName(method string, path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	resource := segments[0]
+	operation := ""
+	hasID := false
+	// position counts the segments following the current resource: the
+	// first one is an identifier, unless it is a collection-wide action.
+	position := 0
+	for _, segment := range segments[1:] {
+		switch {
+		case subresources[segment]:
+			resource, hasID, position = segment, false, 0
+			continue
+		case resource == "analytics":
+			operation = segment
+		case position == 0 && !collectionActions[segment]:
+			hasID = true
+		default:
+			operation = segment
+		}
+		position++
+	}
+
+	if operation == "" {
+		switch {
+		case method == http.MethodGet && hasID:
+			operation = "get"
+		case method == http.MethodGet:
+			operation = "list"
+		case method == http.MethodPost:
+			operation = "create"
+		case method == http.MethodPut:
+			operation = "update"
+		case method == http.MethodDelete:
+			operation = "delete"
+		default:
+			operation = strings.ToLower(method)
+		}
+	}
+
+	return "subrow." + singular(resource) + "." + operation
+}
+
+// collectionActions are the actions applying to a whole collection, as in
+// invoices/preview.
+var collectionActions = map[string]bool{
+	"batch":               true,
+	"estimate":            true,
+	"estimate_fees":       true,
+	"evaluate_expression": true,
+	"preview":             true,
+	"public_key":          true,
+}
+
+func singular(resource string) string {
+	switch {
+	case resource == "analytics":
+		return resource
+	case strings.HasSuffix(resource, "ies"):
+		return strings.TrimSuffix(resource, "ies") + "y"
+	case strings.HasSuffix(resource, "xes"):
+		return strings.TrimSuffix(resource, "es")
+	}
+
+	return strings.TrimSuffix(resource, "s")
+}
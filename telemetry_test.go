@@ -0,0 +1,184 @@
+package subrow
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type telemetryRecorder struct {
+	spans  *tracetest.InMemoryExporter
+	reader *sdkmetric.ManualReader
+}
+
+func newTelemetryRecorder() (*telemetryRecorder, *TelemetryOptions) {
+	spans := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+
+	return &telemetryRecorder{spans: spans, reader: reader}, &TelemetryOptions{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+		Propagator:     propagation.TraceContext{},
+	}
+}
+
+// sum returns the value of an Int64 counter, summed over the data points
+// having attrs.
+func (tr *telemetryRecorder) sum(c *qt.C, name string, attrs ...attribute.KeyValue) int64 {
+	var rm metricdata.ResourceMetrics
+	c.Assert(tr.reader.Collect(context.Background(), &rm), qt.IsNil)
+
+	var total int64
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name != name {
+				continue
+			}
+		points:
+			for _, point := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				for _, attr := range attrs {
+					if value, ok := point.Attributes.Value(attr.Key); !ok || value != attr.Value {
+						continue points
+					}
+				}
+				total += point.Value
+			}
+		}
+	}
+
+	return total
+}
+
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+
+	return attribute.Value{}
+}
+
+func TestTelemetry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("When a call succeeds after a retry", func(t *testing.T) {
+		c := qt.New(t)
+
+		var hits atomic.Int32
+		var traceparent atomic.Value
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			traceparent.Store(r.Header.Get("Traceparent"))
+			w.Header().Set("Content-Type", "application/json")
+			if hits.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`{"status":503,"error":"Service Unavailable"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"invoice":{"status":"finalized"}}`))
+		}))
+		defer server.Close()
+
+		recorder, options := newTelemetryRecorder()
+		client := New().
+			SetBaseURL(server.URL).
+			SetRetryPolicy(&RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}).
+			SetTelemetry(options)
+
+		_, err := client.Invoice().Finalize(ctx, "1a901a90-1a90-1a90-1a90-1a901a901a90")
+		c.Assert(err == nil, qt.IsTrue)
+
+		spans := recorder.spans.GetSpans()
+		c.Assert(spans, qt.HasLen, 1)
+		span := spans[0]
+		c.Assert(span.Name, qt.Equals, "subrow.invoice.finalize")
+		c.Assert(span.Status.Code, qt.Equals, codes.Unset)
+		c.Assert(spanAttribute(span, "http.response.status_code").AsInt64(), qt.Equals, int64(200))
+		c.Assert(spanAttribute(span, "subrow.attempts").AsInt64(), qt.Equals, int64(2))
+		c.Assert(traceparent.Load(), qt.Contains, span.SpanContext.TraceID().String())
+
+		operation := OperationAttribute.String("subrow.invoice.finalize")
+		c.Assert(recorder.sum(c, "subrow.client.requests", operation), qt.Equals, int64(1))
+		c.Assert(recorder.sum(c, "subrow.client.retries", operation), qt.Equals, int64(1))
+	})
+
+	t.Run("When a call fails", func(t *testing.T) {
+		c := qt.New(t)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status":404,"error":"Not Found","code":"customer_not_found"}`))
+		}))
+		defer server.Close()
+
+		recorder, options := newTelemetryRecorder()
+		client := New().SetBaseURL(server.URL).SetTelemetry(options)
+
+		_, err := client.Customer().Get(ctx, "cus_1")
+		c.Assert(err.ErrorCode, qt.Equals, "customer_not_found")
+
+		span := recorder.spans.GetSpans()[0]
+		c.Assert(span.Name, qt.Equals, "subrow.customer.get")
+		c.Assert(span.Status.Code, qt.Equals, codes.Error)
+		c.Assert(spanAttribute(span, ErrorCodeAttribute).AsString(), qt.Equals, "customer_not_found")
+		c.Assert(spanAttribute(span, "http.response.status_code").AsInt64(), qt.Equals, int64(404))
+		c.Assert(recorder.sum(c, "subrow.client.requests", attribute.String("error.type", "customer_not_found")), qt.Equals, int64(1))
+	})
+
+	t.Run("When events are ingested", func(t *testing.T) {
+		c := qt.New(t)
+
+		server := ingestServer(c, &batchRecorder{}, map[string]bool{"tr_invalid": true})
+		recorder, options := newTelemetryRecorder()
+		client := New().SetBaseURL(server.URL).SetTelemetry(options)
+
+		ingestor := NewEventIngestor(client, EventIngestorConfig{BatchSize: 10, FlushInterval: time.Hour})
+		for _, id := range []string{"tr_1", "tr_invalid", "tr_2"} {
+			c.Assert(ingestor.Enqueue(ctx, EventInput{TransactionID: id}), qt.IsNil)
+		}
+		c.Assert(ingestor.Close(ctx), qt.IsNil)
+
+		operation := OperationAttribute.String("subrow.event.batch")
+		c.Assert(recorder.sum(c, "subrow.client.requests", operation), qt.Equals, int64(2))
+		c.Assert(recorder.sum(c, "subrow.client.events.dropped", DropReasonAttribute.String(dropReasonRejected)), qt.Equals, int64(1))
+		c.Assert(recorder.sum(c, "subrow.client.events.ingested"), qt.Equals, int64(2))
+	})
+}
+
+func TestOperationName(t *testing.T) {
+	c := qt.New(t)
+
+	for _, test := range []struct {
+		method, path, want string
+	}{
+		{http.MethodGet, "customers", "subrow.customer.list"},
+		{http.MethodPost, "customers", "subrow.customer.create"},
+		{http.MethodGet, "customers/cus_1", "subrow.customer.get"},
+		{http.MethodGet, "customers/cus_1/current_usage", "subrow.customer.current_usage"},
+		{http.MethodDelete, "customers/cus_1/applied_coupons/1", "subrow.applied_coupon.delete"},
+		{http.MethodPut, "invoices/1/finalize", "subrow.invoice.finalize"},
+		{http.MethodPost, "invoices/preview", "subrow.invoice.preview"},
+		{http.MethodPost, "events/batch", "subrow.event.batch"},
+		{http.MethodGet, "wallets/1/wallet_transactions", "subrow.wallet_transaction.list"},
+		{http.MethodPut, "subscriptions/sub_1/alerts/alert_1", "subrow.alert.update"},
+		{http.MethodDelete, "taxes/vat", "subrow.tax.delete"},
+		{http.MethodGet, "billing_entities", "subrow.billing_entity.list"},
+		{http.MethodGet, "analytics/mrr", "subrow.analytics.mrr"},
+		{http.MethodGet, "webhooks/public_key", "subrow.webhook.public_key"},
+	} {
+		c.Check(operationName(test.method, test.path), qt.Equals, test.want, qt.Commentf("%s %s", test.method, test.path))
+	}
+}
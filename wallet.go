@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -167,6 +168,26 @@ func (bmr *WalletRequest) GetList(ctx context.Context, walletListInput *WalletLi
 	return walletResult, nil
 }
 
+// All iterates over every wallet matching the input, fetching pages as needed.
+func (bmr *WalletRequest) All(ctx context.Context, walletListInput *WalletListInput, opts ...IteratorOption) iter.Seq2[Wallet, error] {
+	input := WalletListInput{}
+	if walletListInput != nil {
+		input = *walletListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]Wallet, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := bmr.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.Wallets, result.Meta, nil
+	}, opts)
+}
+
 func (bmr *WalletRequest) Create(ctx context.Context, walletInput *WalletInput) (*Wallet, *Error) {
 	walletParams := &WalletParams{
 		WalletInput: walletInput,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -151,6 +152,26 @@ func (wtr *WalletTransactionRequest) GetList(ctx context.Context, walletTransact
 	return walletTransactionResult, nil
 }
 
+// All iterates over every wallet transaction matching the input, fetching pages as needed.
+func (wtr *WalletTransactionRequest) All(ctx context.Context, walletTransactionListInput *WalletTransactionListInput, opts ...IteratorOption) iter.Seq2[WalletTransaction, error] {
+	input := WalletTransactionListInput{}
+	if walletTransactionListInput != nil {
+		input = *walletTransactionListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]WalletTransaction, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := wtr.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.WalletTransactions, result.Meta, nil
+	}, opts)
+}
+
 func (wtr *WalletTransactionRequest) PaymentUrl(ctx context.Context, walletTransactionID string) (*WalletTransactionPaymentUrl, *Error) {
 	subPath := fmt.Sprintf("%s/%s/%s", "wallet_transactions", walletTransactionID, "payment_url")
 
@@ -52,8 +52,14 @@ func (wr *WebhookRequest) GetPublicKey(ctx context.Context) (*rsa.PublicKey, *Er
 		}
 	}
 
+	return ParseWebhookPublicKey(bytesResult)
+}
+
+// ParseWebhookPublicKey parses the PEM encoded RSA key Subrow signs webhooks
+// with, as returned by the webhooks/public_key endpoint once base64 decoded.
+func ParseWebhookPublicKey(pemKey []byte) (*rsa.PublicKey, *Error) {
 	// Parse the PEM block
-	block, _ := pem.Decode(bytesResult)
+	block, _ := pem.Decode(pemKey)
 	if block == nil || block.Type != "PUBLIC KEY" {
 		return nil, &Error{
 			Err:            errors.New("Failed to decode PEM block containing public key"),
@@ -85,11 +91,15 @@ func (wr *WebhookRequest) GetPublicKey(ctx context.Context) (*rsa.PublicKey, *Er
 }
 
 func (wr *WebhookRequest) parseSignature(ctx context.Context, signature string) (*jwt.Token, *Error) {
-	publicKey, err := wr.GetPublicKey(ctx)
+	publicKey, err := wr.publicKey(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	return wr.parseSignatureWithRotation(ctx, signature, publicKey)
+}
+
+func parseSignatureWithKey(signature string, publicKey *rsa.PublicKey) (*jwt.Token, *Error) {
 	token, parseErr := jwt.Parse(signature, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
 			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
@@ -117,17 +127,21 @@ func (wr *WebhookRequest) ValidateSignature(ctx context.Context, signature strin
 
 func (wr *WebhookRequest) ValidateBody(ctx context.Context, signature string, body string) (bool, *Error) {
 	if token, err := wr.parseSignature(ctx, signature); err == nil && token.Valid {
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			return false, &Error{
-				Err:            errors.New("error casting claims"),
-				HTTPStatusCode: http.StatusInternalServerError,
-				Message:        "cannot parse token",
-			}
-		}
-
-		return claims["data"] == body, nil
+		return tokenMatchesBody(token, body)
 	} else {
 		return false, err
 	}
 }
+
+func tokenMatchesBody(token *jwt.Token, body string) (bool, *Error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return false, &Error{
+			Err:            errors.New("error casting claims"),
+			HTTPStatusCode: http.StatusInternalServerError,
+			Message:        "cannot parse token",
+		}
+	}
+
+	return claims["data"] == body, nil
+}
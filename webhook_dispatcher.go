@@ -0,0 +1,226 @@
+package subrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrUnhandledWebhook = errors.New("subrow: no handler for webhook type")
+
+type WebhookHandler func(ctx context.Context, event *WebhookEvent) error
+
+// WebhookDispatcher routes decoded webhook events to the handler registered
+// for their type.
+type WebhookDispatcher struct {
+	mu       sync.RWMutex
+	handlers map[WebhookType]WebhookHandler
+	fallback WebhookHandler
+}
+
+func NewWebhookDispatcher() *WebhookDispatcher {
+	return &WebhookDispatcher{
+		handlers: map[WebhookType]WebhookHandler{},
+	}
+}
+
+// Handle registers handler for webhookType, replacing any previous one.
+func (wd *WebhookDispatcher) Handle(webhookType WebhookType, handler WebhookHandler) *WebhookDispatcher {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	wd.handlers[webhookType] = handler
+	return wd
+}
+
+// HandleDefault registers the handler used for events without a handler of
+// their own, including unknown webhook types.
+func (wd *WebhookDispatcher) HandleDefault(handler WebhookHandler) *WebhookDispatcher {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	wd.fallback = handler
+	return wd
+}
+
+// Dispatch decodes body and calls the matching handler. It returns
+// ErrUnhandledWebhook when no handler matches.
+func (wd *WebhookDispatcher) Dispatch(ctx context.Context, body []byte) error {
+	event, err := ParseWebhookEvent(body)
+	if err != nil {
+		return err
+	}
+
+	return wd.DispatchEvent(ctx, event)
+}
+
+func (wd *WebhookDispatcher) DispatchEvent(ctx context.Context, event *WebhookEvent) error {
+	wd.mu.RLock()
+	handler, ok := wd.handlers[event.WebhookType]
+	if !ok {
+		handler = wd.fallback
+	}
+	wd.mu.RUnlock()
+
+	if handler == nil {
+		return fmt.Errorf("%w: %s", ErrUnhandledWebhook, event.WebhookType)
+	}
+
+	return handler(ctx, event)
+}
+
+func typedWebhookHandler[T any](handler func(ctx context.Context, object *T) error) WebhookHandler {
+	return func(ctx context.Context, event *WebhookEvent) error {
+		object, ok := event.Object.(*T)
+		if !ok {
+			return fmt.Errorf("%w: %s does not hold a %T", ErrInvalidWebhookPayload, event.WebhookType, object)
+		}
+
+		return handler(ctx, object)
+	}
+}
+
+func (wd *WebhookDispatcher) OnAlertTriggered(handler func(ctx context.Context, alert *TriggeredAlert) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeAlertTriggered, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnCreditNoteCreated(handler func(ctx context.Context, creditNote *CreditNote) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeCreditNoteCreated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnCreditNoteGenerated(handler func(ctx context.Context, creditNote *CreditNote) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeCreditNoteGenerated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnCustomerCreated(handler func(ctx context.Context, customer *Customer) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeCustomerCreated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnCustomerUpdated(handler func(ctx context.Context, customer *Customer) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeCustomerUpdated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnCustomerAccountingProviderCreated(handler func(ctx context.Context, customer *Customer) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeCustomerAccountingProviderCreated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnCustomerCrmProviderCreated(handler func(ctx context.Context, customer *Customer) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeCustomerCrmProviderCreated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnCustomerPaymentProviderCreated(handler func(ctx context.Context, customer *Customer) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeCustomerPaymentProviderCreated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnCustomerViesCheck(handler func(ctx context.Context, customer *Customer) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeCustomerViesCheck, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnFeeCreated(handler func(ctx context.Context, fee *Fee) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeFeeCreated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnInvoiceCreated(handler func(ctx context.Context, invoice *Invoice) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeInvoiceCreated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnInvoiceOneOffCreated(handler func(ctx context.Context, invoice *Invoice) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeInvoiceOneOffCreated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnInvoiceAddOnAdded(handler func(ctx context.Context, invoice *Invoice) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeInvoiceAddOnAdded, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnInvoicePaidCreditAdded(handler func(ctx context.Context, invoice *Invoice) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeInvoicePaidCreditAdded, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnInvoiceGenerated(handler func(ctx context.Context, invoice *Invoice) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeInvoiceGenerated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnInvoiceDrafted(handler func(ctx context.Context, invoice *Invoice) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeInvoiceDrafted, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnInvoiceVoided(handler func(ctx context.Context, invoice *Invoice) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeInvoiceVoided, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnInvoicePaymentStatusUpdated(handler func(ctx context.Context, invoice *Invoice) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeInvoicePaymentStatusUpdated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnInvoicePaymentOverdue(handler func(ctx context.Context, invoice *Invoice) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeInvoicePaymentOverdue, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnInvoicePaymentDisputeLost(handler func(ctx context.Context, invoice *Invoice) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeInvoicePaymentDisputeLost, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnPaymentReceiptCreated(handler func(ctx context.Context, paymentReceipt *PaymentReceipt) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypePaymentReceiptCreated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnPaymentReceiptGenerated(handler func(ctx context.Context, paymentReceipt *PaymentReceipt) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypePaymentReceiptGenerated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnPaymentRequestCreated(handler func(ctx context.Context, paymentRequest *PaymentRequest) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypePaymentRequestCreated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnPaymentRequestPaymentStatusUpdated(handler func(ctx context.Context, paymentRequest *PaymentRequest) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypePaymentRequestPaymentStatusUpdated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnPlanCreated(handler func(ctx context.Context, plan *Plan) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypePlanCreated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnPlanUpdated(handler func(ctx context.Context, plan *Plan) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypePlanUpdated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnPlanDeleted(handler func(ctx context.Context, plan *Plan) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypePlanDeleted, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnSubscriptionStarted(handler func(ctx context.Context, subscription *Subscription) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeSubscriptionStarted, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnSubscriptionUpdated(handler func(ctx context.Context, subscription *Subscription) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeSubscriptionUpdated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnSubscriptionTerminated(handler func(ctx context.Context, subscription *Subscription) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeSubscriptionTerminated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnSubscriptionTerminationAlert(handler func(ctx context.Context, subscription *Subscription) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeSubscriptionTerminationAlert, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnSubscriptionTrialEnded(handler func(ctx context.Context, subscription *Subscription) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeSubscriptionTrialEnded, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnSubscriptionUsageThresholdReached(handler func(ctx context.Context, subscription *Subscription) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeSubscriptionUsageThresholdReached, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnWalletDepletedOngoingBalance(handler func(ctx context.Context, wallet *Wallet) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeWalletDepletedOngoingBalance, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnWalletTransactionCreated(handler func(ctx context.Context, walletTransaction *WalletTransaction) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeWalletTransactionCreated, typedWebhookHandler(handler))
+}
+
+func (wd *WebhookDispatcher) OnWalletTransactionUpdated(handler func(ctx context.Context, walletTransaction *WalletTransaction) error) *WebhookDispatcher {
+	return wd.Handle(WebhookTypeWalletTransactionUpdated, typedWebhookHandler(handler))
+}
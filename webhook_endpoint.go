@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
@@ -104,6 +105,26 @@ func (wer *WebhookEndpointRequest) GetList(ctx context.Context, webhookEndpointL
 	return webhookEndpointResult, nil
 }
 
+// All iterates over every webhook endpoint matching the input, fetching pages as needed.
+func (wer *WebhookEndpointRequest) All(ctx context.Context, webhookEndpointListInput *WebhookEndpointListInput, opts ...IteratorOption) iter.Seq2[WebhookEndpoint, error] {
+	input := WebhookEndpointListInput{}
+	if webhookEndpointListInput != nil {
+		input = *webhookEndpointListInput
+	}
+
+	return paginate(ctx, input.Page, func(ctx context.Context, page int) ([]WebhookEndpoint, Metadata, *Error) {
+		pageInput := input
+		pageInput.Page = page
+
+		result, err := wer.GetList(ctx, &pageInput)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		return result.WebhookEndpoints, result.Meta, nil
+	}, opts)
+}
+
 func (wer *WebhookEndpointRequest) Create(ctx context.Context, webhookEndpointInput *WebhookEndpointInput) (*WebhookEndpoint, *Error) {
 	webhookEndpointParams := &WebhookEndpointParams{
 		WebhookEndpointInput: webhookEndpointInput,
@@ -0,0 +1,205 @@
+package subrow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+type WebhookType string
+
+const (
+	WebhookTypeAlertTriggered WebhookType = "alert.triggered"
+
+	WebhookTypeCreditNoteCreated   WebhookType = "credit_note.created"
+	WebhookTypeCreditNoteGenerated WebhookType = "credit_note.generated"
+
+	WebhookTypeCustomerCreated                   WebhookType = "customer.created"
+	WebhookTypeCustomerUpdated                   WebhookType = "customer.updated"
+	WebhookTypeCustomerAccountingProviderCreated WebhookType = "customer.accounting_provider_created"
+	WebhookTypeCustomerCrmProviderCreated        WebhookType = "customer.crm_provider_created"
+	WebhookTypeCustomerPaymentProviderCreated    WebhookType = "customer.payment_provider_created"
+	WebhookTypeCustomerViesCheck                 WebhookType = "customer.vies_check"
+
+	WebhookTypeFeeCreated WebhookType = "fee.created"
+
+	WebhookTypeInvoiceCreated              WebhookType = "invoice.created"
+	WebhookTypeInvoiceOneOffCreated        WebhookType = "invoice.one_off_created"
+	WebhookTypeInvoiceAddOnAdded           WebhookType = "invoice.add_on_added"
+	WebhookTypeInvoicePaidCreditAdded      WebhookType = "invoice.paid_credit_added"
+	WebhookTypeInvoiceGenerated            WebhookType = "invoice.generated"
+	WebhookTypeInvoiceDrafted              WebhookType = "invoice.drafted"
+	WebhookTypeInvoiceVoided               WebhookType = "invoice.voided"
+	WebhookTypeInvoicePaymentStatusUpdated WebhookType = "invoice.payment_status_updated"
+	WebhookTypeInvoicePaymentOverdue       WebhookType = "invoice.payment_overdue"
+	WebhookTypeInvoicePaymentDisputeLost   WebhookType = "invoice.payment_dispute_lost"
+
+	WebhookTypePaymentReceiptCreated   WebhookType = "payment_receipt.created"
+	WebhookTypePaymentReceiptGenerated WebhookType = "payment_receipt.generated"
+
+	WebhookTypePaymentRequestCreated              WebhookType = "payment_request.created"
+	WebhookTypePaymentRequestPaymentStatusUpdated WebhookType = "payment_request.payment_status_updated"
+
+	WebhookTypePlanCreated WebhookType = "plan.created"
+	WebhookTypePlanUpdated WebhookType = "plan.updated"
+	WebhookTypePlanDeleted WebhookType = "plan.deleted"
+
+	WebhookTypeSubscriptionStarted               WebhookType = "subscription.started"
+	WebhookTypeSubscriptionUpdated               WebhookType = "subscription.updated"
+	WebhookTypeSubscriptionTerminated            WebhookType = "subscription.terminated"
+	WebhookTypeSubscriptionTerminationAlert      WebhookType = "subscription.termination_alert"
+	WebhookTypeSubscriptionTrialEnded            WebhookType = "subscription.trial_ended"
+	WebhookTypeSubscriptionUsageThresholdReached WebhookType = "subscription.usage_threshold_reached"
+
+	WebhookTypeWalletDepletedOngoingBalance WebhookType = "wallet.depleted_ongoing_balance"
+
+	WebhookTypeWalletTransactionCreated WebhookType = "wallet_transaction.created"
+	WebhookTypeWalletTransactionUpdated WebhookType = "wallet_transaction.updated"
+)
+
+var ErrInvalidWebhookPayload = errors.New("subrow: invalid webhook payload")
+
+// WebhookEvent is a decoded webhook payload. Object holds a pointer to the
+// struct registered for WebhookType, e.g. *Invoice for invoice.created, and
+// is nil for types that are not registered.
+type WebhookEvent struct {
+	WebhookType    WebhookType `json:"webhook_type"`
+	ObjectType     string      `json:"object_type"`
+	OrganizationID uuid.UUID   `json:"organization_id,omitempty"`
+
+	Object interface{}     `json:"-"`
+	Raw    json.RawMessage `json:"-"`
+}
+
+type webhookObject struct {
+	key       string
+	newObject func() interface{}
+}
+
+var (
+	webhookRegistryMu sync.RWMutex
+	webhookRegistry   = map[WebhookType]webhookObject{}
+)
+
+func init() {
+	registerWebhookTypes[TriggeredAlert]("triggered_alert", WebhookTypeAlertTriggered)
+	registerWebhookTypes[CreditNote]("credit_note",
+		WebhookTypeCreditNoteCreated,
+		WebhookTypeCreditNoteGenerated,
+	)
+	registerWebhookTypes[Customer]("customer",
+		WebhookTypeCustomerCreated,
+		WebhookTypeCustomerUpdated,
+		WebhookTypeCustomerAccountingProviderCreated,
+		WebhookTypeCustomerCrmProviderCreated,
+		WebhookTypeCustomerPaymentProviderCreated,
+		WebhookTypeCustomerViesCheck,
+	)
+	registerWebhookTypes[Fee]("fee", WebhookTypeFeeCreated)
+	registerWebhookTypes[Invoice]("invoice",
+		WebhookTypeInvoiceCreated,
+		WebhookTypeInvoiceOneOffCreated,
+		WebhookTypeInvoiceAddOnAdded,
+		WebhookTypeInvoicePaidCreditAdded,
+		WebhookTypeInvoiceGenerated,
+		WebhookTypeInvoiceDrafted,
+		WebhookTypeInvoiceVoided,
+		WebhookTypeInvoicePaymentStatusUpdated,
+		WebhookTypeInvoicePaymentOverdue,
+		WebhookTypeInvoicePaymentDisputeLost,
+	)
+	registerWebhookTypes[PaymentReceipt]("payment_receipt",
+		WebhookTypePaymentReceiptCreated,
+		WebhookTypePaymentReceiptGenerated,
+	)
+	registerWebhookTypes[PaymentRequest]("payment_request",
+		WebhookTypePaymentRequestCreated,
+		WebhookTypePaymentRequestPaymentStatusUpdated,
+	)
+	registerWebhookTypes[Plan]("plan",
+		WebhookTypePlanCreated,
+		WebhookTypePlanUpdated,
+		WebhookTypePlanDeleted,
+	)
+	registerWebhookTypes[Subscription]("subscription",
+		WebhookTypeSubscriptionStarted,
+		WebhookTypeSubscriptionUpdated,
+		WebhookTypeSubscriptionTerminated,
+		WebhookTypeSubscriptionTerminationAlert,
+		WebhookTypeSubscriptionTrialEnded,
+		WebhookTypeSubscriptionUsageThresholdReached,
+	)
+	registerWebhookTypes[Wallet]("wallet", WebhookTypeWalletDepletedOngoingBalance)
+	registerWebhookTypes[WalletTransaction]("wallet_transaction",
+		WebhookTypeWalletTransactionCreated,
+		WebhookTypeWalletTransactionUpdated,
+	)
+}
+
+func registerWebhookTypes[T any](key string, webhookTypes ...WebhookType) {
+	for _, webhookType := range webhookTypes {
+		RegisterWebhookType[T](webhookType, key)
+	}
+}
+
+// RegisterWebhookType makes ParseWebhookEvent decode the payload of
+// webhookType found under key into a *T. It replaces any previous
+// registration, which allows decoding types this package does not know yet.
+func RegisterWebhookType[T any](webhookType WebhookType, key string) {
+	webhookRegistryMu.Lock()
+	defer webhookRegistryMu.Unlock()
+
+	webhookRegistry[webhookType] = webhookObject{
+		key: key,
+		newObject: func() interface{} {
+			return new(T)
+		},
+	}
+}
+
+func lookupWebhookType(webhookType WebhookType) (webhookObject, bool) {
+	webhookRegistryMu.RLock()
+	defer webhookRegistryMu.RUnlock()
+
+	object, ok := webhookRegistry[webhookType]
+	return object, ok
+}
+
+// ParseWebhookEvent decodes a webhook body. Unknown webhook types are not an
+// error: the event is returned with a nil Object and the body in Raw.
+func ParseWebhookEvent(body []byte) (*WebhookEvent, error) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidWebhookPayload, err)
+	}
+
+	event := &WebhookEvent{Raw: json.RawMessage(body)}
+	if err := json.Unmarshal(body, event); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidWebhookPayload, err)
+	}
+
+	if event.WebhookType == "" {
+		return nil, fmt.Errorf("%w: missing webhook_type", ErrInvalidWebhookPayload)
+	}
+
+	registered, ok := lookupWebhookType(event.WebhookType)
+	if !ok {
+		return event, nil
+	}
+
+	data, ok := payload[registered.key]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s for %s", ErrInvalidWebhookPayload, registered.key, event.WebhookType)
+	}
+
+	object := registered.newObject()
+	if err := json.Unmarshal(data, object); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidWebhookPayload, event.WebhookType, err)
+	}
+	event.Object = object
+
+	return event, nil
+}
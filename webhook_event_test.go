@@ -0,0 +1,105 @@
+package subrow
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestParseWebhookEvent(t *testing.T) {
+	t.Run("When the webhook type is registered", func(t *testing.T) {
+		c := qt.New(t)
+
+		event, err := ParseWebhookEvent([]byte(`{
+			"webhook_type": "invoice.created",
+			"object_type": "invoice",
+			"organization_id": "1a901a90-1a90-1a90-1a90-1a901a901a90",
+			"invoice": {"number": "INV-001", "status": "finalized"}
+		}`))
+		c.Assert(err, qt.IsNil)
+		c.Assert(event.WebhookType, qt.Equals, WebhookTypeInvoiceCreated)
+		c.Assert(event.OrganizationID.String(), qt.Equals, "1a901a90-1a90-1a90-1a90-1a901a901a90")
+
+		invoice, ok := event.Object.(*Invoice)
+		c.Assert(ok, qt.IsTrue)
+		c.Assert(invoice.Number, qt.Equals, "INV-001")
+		c.Assert(invoice.Status, qt.Equals, InvoiceStatus("finalized"))
+	})
+
+	t.Run("When the webhook type is unknown", func(t *testing.T) {
+		c := qt.New(t)
+
+		event, err := ParseWebhookEvent([]byte(`{"webhook_type":"something.new","object_type":"thing","thing":{}}`))
+		c.Assert(err, qt.IsNil)
+		c.Assert(event.Object, qt.IsNil)
+		c.Assert(string(event.Raw), qt.Contains, `"thing":{}`)
+	})
+
+	t.Run("When the payload is invalid", func(t *testing.T) {
+		c := qt.New(t)
+
+		for _, body := range []string{
+			`not json`,
+			`{"object_type":"invoice"}`,
+			`{"webhook_type":"invoice.created","object_type":"invoice"}`,
+			`{"webhook_type":"invoice.created","object_type":"invoice","invoice":{"number":1}}`,
+		} {
+			_, err := ParseWebhookEvent([]byte(body))
+			c.Assert(err, qt.ErrorIs, ErrInvalidWebhookPayload, qt.Commentf(body))
+		}
+	})
+}
+
+func TestWebhookDispatcher(t *testing.T) {
+	t.Run("When a typed handler is registered", func(t *testing.T) {
+		c := qt.New(t)
+
+		var terminated *Subscription
+		dispatcher := NewWebhookDispatcher().
+			OnSubscriptionTerminated(func(ctx context.Context, subscription *Subscription) error {
+				terminated = subscription
+				return nil
+			}).
+			OnInvoiceCreated(func(ctx context.Context, invoice *Invoice) error {
+				return errors.New("unexpected invoice")
+			})
+
+		err := dispatcher.Dispatch(context.Background(), []byte(`{
+			"webhook_type": "subscription.terminated",
+			"object_type": "subscription",
+			"subscription": {"external_id": "sub_1", "status": "terminated"}
+		}`))
+		c.Assert(err, qt.IsNil)
+		c.Assert(terminated.ExternalID, qt.Equals, "sub_1")
+	})
+
+	t.Run("When the handler fails", func(t *testing.T) {
+		c := qt.New(t)
+
+		handlerErr := errors.New("boom")
+		dispatcher := NewWebhookDispatcher().OnAlertTriggered(func(ctx context.Context, alert *TriggeredAlert) error {
+			return handlerErr
+		})
+
+		err := dispatcher.Dispatch(context.Background(), []byte(`{"webhook_type":"alert.triggered","triggered_alert":{"alert_code":"usage"}}`))
+		c.Assert(err, qt.Equals, handlerErr)
+	})
+
+	t.Run("When no handler matches", func(t *testing.T) {
+		c := qt.New(t)
+
+		dispatcher := NewWebhookDispatcher()
+		err := dispatcher.Dispatch(context.Background(), []byte(`{"webhook_type":"customer.created","customer":{}}`))
+		c.Assert(err, qt.ErrorIs, ErrUnhandledWebhook)
+
+		var fallback WebhookType
+		dispatcher.HandleDefault(func(ctx context.Context, event *WebhookEvent) error {
+			fallback = event.WebhookType
+			return nil
+		})
+		c.Assert(dispatcher.Dispatch(context.Background(), []byte(`{"webhook_type":"something.new"}`)), qt.IsNil)
+		c.Assert(fallback, qt.Equals, WebhookType("something.new"))
+	})
+}
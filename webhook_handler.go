@@ -0,0 +1,254 @@
+package subrow
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	WebhookSignatureHeader string = "X-Subrow-Signature"
+	WebhookUniqueKeyHeader string = "X-Subrow-Unique-Key"
+
+	defaultWebhookMaxBodyBytes int64         = 1 << 20
+	defaultWebhookTolerance    time.Duration = 5 * time.Minute
+)
+
+var (
+	ErrWebhookSignature = errors.New("subrow: invalid webhook signature")
+	ErrWebhookReplayed  = errors.New("subrow: webhook signature is outside the tolerance window")
+)
+
+type WebhookHandlerOptions struct {
+	// MaxBodyBytes caps the size of a webhook body. Defaults to 1MiB.
+	MaxBodyBytes int64
+	// Tolerance is the maximum age of a JWT signature, based on its iat claim,
+	// which is required. Defaults to 5 minutes.
+	//
+	// HMAC signatures cover the body only, without a timestamp, so Tolerance
+	// does not apply to them: an HMAC endpoint has no replay protection but
+	// the deduplication of unique keys within DedupWindow.
+	Tolerance time.Duration
+	// DedupWindow is how long unique keys of delivered webhooks are
+	// remembered, so that redeliveries within the window are acknowledged
+	// without being dispatched again. Defaults to Tolerance.
+	DedupWindow time.Duration
+	// SignatureAlgo is the signature algorithm of the webhook endpoint the
+	// handler serves. Defaults to JWT.
+	SignatureAlgo SignatureAlgo
+	// OnError is called with every error that made the handler reject a
+	// webhook or answer with a 5xx.
+	OnError func(r *http.Request, err error)
+}
+
+type webhookHandler struct {
+	webhook    *WebhookRequest
+	dispatcher *WebhookDispatcher
+	options    WebhookHandlerOptions
+	now        func() time.Time
+
+	mu          sync.Mutex
+	delivered   map[string]time.Time
+	dispatching map[string]struct{}
+	lastPruned  time.Time
+}
+
+// Handler returns an http.Handler that verifies, decodes and dispatches
+// webhooks. It answers 2xx once a webhook is handled, or when no handler
+// matches its type, and 5xx when the handler fails so Subrow delivers it
+// again. Requests that fail verification are answered with 4xx, and
+// redeliveries of a webhook still being handled with 409.
+func (wr *WebhookRequest) Handler(dispatcher *WebhookDispatcher, opts *WebhookHandlerOptions) http.Handler {
+	options := WebhookHandlerOptions{}
+	if opts != nil {
+		options = *opts
+	}
+	if options.MaxBodyBytes <= 0 {
+		options.MaxBodyBytes = defaultWebhookMaxBodyBytes
+	}
+	if options.Tolerance <= 0 {
+		options.Tolerance = defaultWebhookTolerance
+	}
+	if options.DedupWindow <= 0 {
+		options.DedupWindow = options.Tolerance
+	}
+	if options.SignatureAlgo == "" {
+		options.SignatureAlgo = JWT
+	}
+
+	return &webhookHandler{
+		webhook:     wr,
+		dispatcher:  dispatcher,
+		options:     options,
+		now:         time.Now,
+		delivered:   map[string]time.Time{},
+		dispatching: map[string]struct{}{},
+	}
+}
+
+func (wh *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		wh.reject(w, r, http.StatusMethodNotAllowed, fmt.Errorf("subrow: unexpected webhook method %s", r.Method))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, wh.options.MaxBodyBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			wh.reject(w, r, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		wh.reject(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if status, err := wh.verify(r, string(body)); err != nil {
+		wh.reject(w, r, status, err)
+		return
+	}
+
+	uniqueKey := r.Header.Get(WebhookUniqueKeyHeader)
+	if uniqueKey != "" {
+		if delivered, ok := wh.reserve(uniqueKey); !ok {
+			if delivered {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				wh.reject(w, r, http.StatusConflict, fmt.Errorf("subrow: webhook %s is already being handled", uniqueKey))
+			}
+			return
+		}
+	}
+
+	status, err := wh.dispatch(r, body)
+	if uniqueKey != "" {
+		wh.release(uniqueKey, err == nil)
+	}
+	if err != nil {
+		wh.reject(w, r, status, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wh *webhookHandler) dispatch(r *http.Request, body []byte) (int, error) {
+	event, err := ParseWebhookEvent(body)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if err := wh.dispatcher.DispatchEvent(r.Context(), event); err != nil && !errors.Is(err, ErrUnhandledWebhook) {
+		return http.StatusInternalServerError, err
+	}
+
+	return 0, nil
+}
+
+func (wh *webhookHandler) verify(r *http.Request, body string) (int, error) {
+	signature := r.Header.Get(WebhookSignatureHeader)
+	if signature == "" {
+		return http.StatusUnauthorized, fmt.Errorf("%w: missing %s header", ErrWebhookSignature, WebhookSignatureHeader)
+	}
+
+	if algo := r.Header.Get(WebhookSignatureAlgorithmHeader); algo != "" && SignatureAlgo(algo) != wh.options.SignatureAlgo {
+		return http.StatusUnauthorized, fmt.Errorf("%w: unexpected %s algorithm", ErrWebhookSignature, algo)
+	}
+
+	if wh.options.SignatureAlgo != JWT {
+		valid, err := wh.webhook.ValidateBodyWithAlgo(r.Context(), wh.options.SignatureAlgo, signature, body)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if !valid {
+			return http.StatusUnauthorized, fmt.Errorf("%w: body does not match", ErrWebhookSignature)
+		}
+		return 0, nil
+	}
+
+	// Failing to fetch the key is on our side: ask for a redelivery.
+	publicKey, keyErr := wh.webhook.publicKey(r.Context())
+	if keyErr != nil {
+		return http.StatusServiceUnavailable, keyErr
+	}
+
+	token, parseErr := wh.webhook.parseSignatureWithRotation(r.Context(), signature, publicKey)
+	if parseErr != nil {
+		return http.StatusUnauthorized, fmt.Errorf("%w: %w", ErrWebhookSignature, parseErr)
+	}
+
+	if ok, _ := tokenMatchesBody(token, body); !ok {
+		return http.StatusUnauthorized, fmt.Errorf("%w: body does not match", ErrWebhookSignature)
+	}
+
+	issuedAt, err := token.Claims.GetIssuedAt()
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("%w: %w", ErrWebhookSignature, err)
+	}
+
+	if issuedAt == nil {
+		return http.StatusUnauthorized, fmt.Errorf("%w: missing iat claim", ErrWebhookSignature)
+	}
+
+	age := wh.now().Sub(issuedAt.Time)
+	if age > wh.options.Tolerance || age < -wh.options.Tolerance {
+		return http.StatusUnauthorized, ErrWebhookReplayed
+	}
+
+	return 0, nil
+}
+
+func (wh *webhookHandler) reject(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if wh.options.OnError != nil {
+		wh.options.OnError(r, err)
+	}
+
+	http.Error(w, http.StatusText(status), status)
+}
+
+// reserve marks the webhook with uniqueKey as being dispatched, unless it was
+// delivered within the dedup window or another request is dispatching it. It
+// reports which of the two when it fails.
+func (wh *webhookHandler) reserve(uniqueKey string) (delivered bool, ok bool) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	if _, dispatching := wh.dispatching[uniqueKey]; dispatching {
+		return false, false
+	}
+	if deliveredAt, seen := wh.delivered[uniqueKey]; seen && wh.now().Sub(deliveredAt) <= wh.options.DedupWindow {
+		return true, false
+	}
+	wh.dispatching[uniqueKey] = struct{}{}
+
+	return false, true
+}
+
+// release ends the dispatch of the webhook with uniqueKey, remembering it
+// when it was delivered so that redeliveries are not dispatched again.
+func (wh *webhookHandler) release(uniqueKey string, delivered bool) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	delete(wh.dispatching, uniqueKey)
+	if !delivered {
+		return
+	}
+
+	now := wh.now()
+	wh.delivered[uniqueKey] = now
+
+	if now.Sub(wh.lastPruned) < wh.options.DedupWindow {
+		return
+	}
+	for key, deliveredAt := range wh.delivered {
+		if now.Sub(deliveredAt) > wh.options.DedupWindow {
+			delete(wh.delivered, key)
+		}
+	}
+	wh.lastPruned = now
+}
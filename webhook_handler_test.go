@@ -0,0 +1,193 @@
+package subrow
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+type webhookSigner struct {
+	key     *rsa.PrivateKey
+	server  *httptest.Server
+	fetches atomic.Int32
+	mu      sync.Mutex
+}
+
+func newWebhookSigner(c *qt.C) *webhookSigner {
+	signer := &webhookSigner{}
+	signer.rotate(c)
+
+	signer.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, qt.Equals, "/api/v1/webhooks/public_key")
+		signer.fetches.Add(1)
+
+		signer.mu.Lock()
+		der, err := x509.MarshalPKIXPublicKey(&signer.key.PublicKey)
+		signer.mu.Unlock()
+		c.Check(err, qt.IsNil)
+
+		_, _ = w.Write([]byte(base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))))
+	}))
+	c.Cleanup(signer.server.Close)
+
+	return signer
+}
+
+func (ws *webhookSigner) rotate(c *qt.C) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, qt.IsNil)
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.key = key
+}
+
+func (ws *webhookSigner) sign(c *qt.C, body string, issuedAt time.Time) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"data": body,
+		"iat":  issuedAt.Unix(),
+	})
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	signature, err := token.SignedString(ws.key)
+	c.Assert(err, qt.IsNil)
+
+	return signature
+}
+
+func postWebhook(handler http.Handler, body, signature, uniqueKey string) int {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	req.Header.Set(WebhookSignatureHeader, signature)
+	if uniqueKey != "" {
+		req.Header.Set(WebhookUniqueKeyHeader, uniqueKey)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestWebhookHandler(t *testing.T) {
+	const body = `{"webhook_type":"invoice.created","object_type":"invoice","invoice":{"number":"INV-001"}}`
+
+	t.Run("When the webhook is valid", func(t *testing.T) {
+		c := qt.New(t)
+
+		signer := newWebhookSigner(c)
+		client := New().SetBaseURL(signer.server.URL).SetApiKey("test_api_key")
+
+		var calls atomic.Int32
+		dispatcher := NewWebhookDispatcher().OnInvoiceCreated(func(ctx context.Context, invoice *Invoice) error {
+			calls.Add(1)
+			c.Check(invoice.Number, qt.Equals, "INV-001")
+			return nil
+		})
+		handler := client.Webhook().Handler(dispatcher, nil)
+
+		signature := signer.sign(c, body, time.Now())
+		c.Assert(postWebhook(handler, body, signature, "key_1"), qt.Equals, http.StatusOK)
+		c.Assert(postWebhook(handler, body, signature, "key_1"), qt.Equals, http.StatusOK)
+		c.Assert(calls.Load(), qt.Equals, int32(1))
+	})
+
+	t.Run("When verification fails", func(t *testing.T) {
+		c := qt.New(t)
+
+		signer := newWebhookSigner(c)
+		client := New().SetBaseURL(signer.server.URL).SetApiKey("test_api_key")
+
+		var rejected []error
+		handler := client.Webhook().Handler(NewWebhookDispatcher(), &WebhookHandlerOptions{
+			MaxBodyBytes: 256,
+			OnError: func(r *http.Request, err error) {
+				rejected = append(rejected, err)
+			},
+		})
+
+		c.Assert(postWebhook(handler, body, "", ""), qt.Equals, http.StatusUnauthorized)
+		c.Assert(postWebhook(handler, body, "not_a_jwt", ""), qt.Equals, http.StatusUnauthorized)
+		c.Assert(postWebhook(handler, body, signer.sign(c, `{"other":"body"}`, time.Now()), ""), qt.Equals, http.StatusUnauthorized)
+		c.Assert(postWebhook(handler, body, signer.sign(c, body, time.Now().Add(-time.Hour)), ""), qt.Equals, http.StatusUnauthorized)
+		c.Assert(rejected[3], qt.ErrorIs, ErrWebhookReplayed)
+
+		withoutIssuedAt, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"data": body}).SignedString(signer.key)
+		c.Assert(err, qt.IsNil)
+		c.Assert(postWebhook(handler, body, withoutIssuedAt, ""), qt.Equals, http.StatusUnauthorized)
+		c.Assert(rejected[4], qt.ErrorMatches, `.*missing iat claim`)
+		c.Assert(rejected[4], qt.ErrorIs, ErrWebhookSignature)
+
+		large := strings.Repeat("a", 512)
+		c.Assert(postWebhook(handler, large, signer.sign(c, large, time.Now()), ""), qt.Equals, http.StatusRequestEntityTooLarge)
+
+		for _, err := range rejected[:3] {
+			c.Assert(err, qt.ErrorIs, ErrWebhookSignature)
+		}
+	})
+
+	t.Run("When the same webhook is delivered concurrently", func(t *testing.T) {
+		c := qt.New(t)
+
+		signer := newWebhookSigner(c)
+		client := New().SetBaseURL(signer.server.URL).SetApiKey("test_api_key")
+
+		var calls atomic.Int32
+		dispatching, release := make(chan struct{}), make(chan struct{})
+		dispatcher := NewWebhookDispatcher().OnInvoiceCreated(func(ctx context.Context, invoice *Invoice) error {
+			calls.Add(1)
+			close(dispatching)
+			<-release
+			return nil
+		})
+		handler := client.Webhook().Handler(dispatcher, nil)
+		signature := signer.sign(c, body, time.Now())
+
+		first := make(chan int)
+		go func() {
+			first <- postWebhook(handler, body, signature, "key_1")
+		}()
+		<-dispatching
+
+		// The redelivery is asked to come back rather than acknowledged, as
+		// the first delivery may still fail.
+		c.Assert(postWebhook(handler, body, signature, "key_1"), qt.Equals, http.StatusConflict)
+		close(release)
+		c.Assert(<-first, qt.Equals, http.StatusOK)
+
+		c.Assert(postWebhook(handler, body, signature, "key_1"), qt.Equals, http.StatusOK)
+		c.Assert(calls.Load(), qt.Equals, int32(1))
+	})
+
+	t.Run("When the handler fails or is missing", func(t *testing.T) {
+		c := qt.New(t)
+
+		signer := newWebhookSigner(c)
+		client := New().SetBaseURL(signer.server.URL).SetApiKey("test_api_key")
+
+		failing := NewWebhookDispatcher().OnInvoiceCreated(func(ctx context.Context, invoice *Invoice) error {
+			return errors.New("database is down")
+		})
+		signature := signer.sign(c, body, time.Now())
+
+		c.Assert(postWebhook(client.Webhook().Handler(failing, nil), body, signature, "key_1"), qt.Equals, http.StatusInternalServerError)
+		c.Assert(postWebhook(client.Webhook().Handler(NewWebhookDispatcher(), nil), body, signature, "key_1"), qt.Equals, http.StatusOK)
+
+		invalid := `{"webhook_type":"invoice.created"}`
+		c.Assert(postWebhook(client.Webhook().Handler(failing, nil), invalid, signer.sign(c, invalid, time.Now()), ""), qt.Equals, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,65 @@
+package subrow
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const WebhookSignatureAlgorithmHeader string = "X-Subrow-Signature-Algorithm"
+
+// SetWebhookHmacKey sets the organization's HMAC key, used to verify webhooks
+// sent to endpoints configured with the HMac signature algorithm.
+func (c *Client) SetWebhookHmacKey(hmacKey string) *Client {
+	c.webhookHmacKey = hmacKey
+
+	return c
+}
+
+// ValidateHmacBody checks that signature is the base64 encoded HMAC-SHA256 of
+// body under the key set with SetWebhookHmacKey.
+func (wr *WebhookRequest) ValidateHmacBody(ctx context.Context, signature string, body string) (bool, *Error) {
+	if wr.client.webhookHmacKey == "" {
+		return false, &Error{
+			Err:            errors.New("webhook hmac key is not set"),
+			HTTPStatusCode: http.StatusInternalServerError,
+			Message:        "webhook hmac key is not set",
+		}
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, nil
+	}
+
+	return hmac.Equal(decoded, webhookHmac(wr.client.webhookHmacKey, body)), nil
+}
+
+// ValidateBodyWithAlgo verifies a webhook with the signature algorithm of the
+// endpoint it was sent to, see WebhookEndpoint.SignatureAlgo. An empty
+// algorithm stands for JWT.
+func (wr *WebhookRequest) ValidateBodyWithAlgo(ctx context.Context, signatureAlgo SignatureAlgo, signature string, body string) (bool, *Error) {
+	switch signatureAlgo {
+	case JWT, "":
+		return wr.ValidateBody(ctx, signature, body)
+	case HMac:
+		return wr.ValidateHmacBody(ctx, signature, body)
+	default:
+		return false, &Error{
+			Err:            fmt.Errorf("unsupported signature algorithm %q", signatureAlgo),
+			HTTPStatusCode: http.StatusInternalServerError,
+			Message:        "unsupported signature algorithm",
+		}
+	}
+}
+
+func webhookHmac(hmacKey string, body string) []byte {
+	mac := hmac.New(sha256.New, []byte(hmacKey))
+	mac.Write([]byte(body))
+
+	return mac.Sum(nil)
+}
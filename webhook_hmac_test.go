@@ -0,0 +1,97 @@
+package subrow
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestWebhookHmac(t *testing.T) {
+	const body = `{"webhook_type":"customer.created","customer":{"external_id":"cus_1"}}`
+	signature := base64.StdEncoding.EncodeToString(webhookHmac("hmac_key", body))
+
+	t.Run("When the body is validated", func(t *testing.T) {
+		c := qt.New(t)
+
+		client := New().SetWebhookHmacKey("hmac_key")
+
+		valid, err := client.Webhook().ValidateHmacBody(context.Background(), signature, body)
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(valid, qt.IsTrue)
+
+		valid, err = client.Webhook().ValidateBodyWithAlgo(context.Background(), HMac, signature, body+" ")
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(valid, qt.IsFalse)
+
+		valid, _ = client.Webhook().ValidateHmacBody(context.Background(), "not base64!", body)
+		c.Assert(valid, qt.IsFalse)
+	})
+
+	t.Run("When the hmac key is not set", func(t *testing.T) {
+		c := qt.New(t)
+
+		_, err := New().Webhook().ValidateHmacBody(context.Background(), signature, body)
+		c.Assert(err.Message, qt.Equals, "webhook hmac key is not set")
+	})
+
+	t.Run("When the handler serves an hmac endpoint", func(t *testing.T) {
+		c := qt.New(t)
+
+		client := New().SetWebhookHmacKey("hmac_key")
+		handler := client.Webhook().Handler(NewWebhookDispatcher(), &WebhookHandlerOptions{SignatureAlgo: HMac})
+
+		post := func(signature, algo string) int {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+			req.Header.Set(WebhookSignatureHeader, signature)
+			req.Header.Set(WebhookSignatureAlgorithmHeader, algo)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		c.Assert(post(signature, "hmac"), qt.Equals, http.StatusOK)
+		c.Assert(post(signature, "jwt"), qt.Equals, http.StatusUnauthorized)
+		c.Assert(post(base64.StdEncoding.EncodeToString(webhookHmac("other_key", body)), "hmac"), qt.Equals, http.StatusUnauthorized)
+	})
+
+	t.Run("When an old hmac delivery is replayed", func(t *testing.T) {
+		c := qt.New(t)
+
+		var calls int
+		dispatcher := NewWebhookDispatcher().OnCustomerCreated(func(ctx context.Context, customer *Customer) error {
+			calls++
+			return nil
+		})
+		client := New().SetWebhookHmacKey("hmac_key")
+		handler := client.Webhook().Handler(dispatcher, &WebhookHandlerOptions{SignatureAlgo: HMac, DedupWindow: 24 * time.Hour}).(*webhookHandler)
+
+		now := time.Now()
+		handler.now = func() time.Time { return now }
+		post := func() int {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+			req.Header.Set(WebhookSignatureHeader, signature)
+			req.Header.Set(WebhookUniqueKeyHeader, "key_1")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		c.Assert(post(), qt.Equals, http.StatusOK)
+
+		// Past the tolerance, the replay is still caught by its unique key.
+		now = now.Add(time.Hour)
+		c.Assert(post(), qt.Equals, http.StatusOK)
+		c.Assert(calls, qt.Equals, 1)
+
+		// Without a signed timestamp, nothing rejects it past the window.
+		now = now.Add(24 * time.Hour)
+		c.Assert(post(), qt.Equals, http.StatusOK)
+		c.Assert(calls, qt.Equals, 2)
+	})
+}
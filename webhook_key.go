@@ -0,0 +1,164 @@
+package subrow
+
+import (
+	"context"
+	"crypto/rsa"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultWebhookPublicKeyTTL time.Duration = time.Hour
+	// webhookPublicKeyMinRefresh keeps invalid signatures from turning every
+	// webhook into a fetch of the public key.
+	webhookPublicKeyMinRefresh time.Duration = time.Minute
+)
+
+// webhookKeyCache holds the webhook public key shared by every
+// WebhookRequest of a Client. Concurrent fetches are collapsed into one.
+type webhookKeyCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	key       *rsa.PublicKey
+	fetchedAt time.Time
+	pinned    bool
+	fetching  chan struct{}
+	fetchErr  *Error
+}
+
+func newWebhookKeyCache() *webhookKeyCache {
+	return &webhookKeyCache{ttl: defaultWebhookPublicKeyTTL}
+}
+
+// SetWebhookPublicKeyTTL sets how long the webhook public key is cached
+// before it is fetched again.
+func (c *Client) SetWebhookPublicKeyTTL(ttl time.Duration) *Client {
+	c.webhookKeys.setTTL(ttl)
+
+	return c
+}
+
+// SetWebhookPublicKey pins the key webhook signatures are verified with, so
+// verification never calls the API. See ParseWebhookPublicKey to load a PEM
+// encoded key.
+func (c *Client) SetWebhookPublicKey(publicKey *rsa.PublicKey) *Client {
+	c.webhookKeys.pin(publicKey)
+
+	return c
+}
+
+func (kc *webhookKeyCache) setTTL(ttl time.Duration) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+
+	kc.ttl = ttl
+}
+
+func (kc *webhookKeyCache) pin(publicKey *rsa.PublicKey) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+
+	kc.key = publicKey
+	kc.fetchedAt = time.Now()
+	kc.pinned = publicKey != nil
+}
+
+// clone returns a cache holding the key and TTL of kc.
+func (kc *webhookKeyCache) clone() *webhookKeyCache {
+	if kc == nil {
+		return newWebhookKeyCache()
+	}
+
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+
+	return &webhookKeyCache{ttl: kc.ttl, key: kc.key, fetchedAt: kc.fetchedAt, pinned: kc.pinned}
+}
+
+// get returns the cached key, fetching it when it is missing or expired.
+// With refresh set, a key older than webhookPublicKeyMinRefresh is fetched
+// again even if it has not expired.
+func (kc *webhookKeyCache) get(ctx context.Context, fetch func(context.Context) (*rsa.PublicKey, *Error), refresh bool) (*rsa.PublicKey, *Error) {
+	kc.mu.Lock()
+
+	for {
+		if kc.key != nil && kc.pinned {
+			defer kc.mu.Unlock()
+			return kc.key, nil
+		}
+
+		age := time.Since(kc.fetchedAt)
+		fresh := age < kc.ttl && (!refresh || age < webhookPublicKeyMinRefresh)
+		if kc.key != nil && fresh {
+			defer kc.mu.Unlock()
+			return kc.key, nil
+		}
+
+		if kc.fetching == nil {
+			break
+		}
+
+		fetching := kc.fetching
+		kc.mu.Unlock()
+
+		select {
+		case <-fetching:
+		case <-ctx.Done():
+			return nil, &Error{Err: ctx.Err()}
+		}
+
+		kc.mu.Lock()
+		if kc.fetchErr != nil {
+			defer kc.mu.Unlock()
+			return nil, kc.fetchErr
+		}
+		// Another goroutine just fetched the key: it counts as refreshed.
+		refresh = false
+	}
+
+	fetching := make(chan struct{})
+	kc.fetching = fetching
+	kc.mu.Unlock()
+
+	key, err := fetch(ctx)
+
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+
+	kc.fetching = nil
+	kc.fetchErr = err
+	if err == nil {
+		kc.key = key
+		kc.fetchedAt = time.Now()
+	}
+	close(fetching)
+
+	return key, err
+}
+
+func (wr *WebhookRequest) publicKey(ctx context.Context) (*rsa.PublicKey, *Error) {
+	if wr.client.webhookKeys == nil {
+		return wr.GetPublicKey(ctx)
+	}
+
+	return wr.client.webhookKeys.get(ctx, wr.GetPublicKey, false)
+}
+
+// parseSignatureWithRotation verifies signature with publicKey. When that
+// fails, the key is fetched again in case Subrow rotated it, and the
+// signature is verified once more if the key changed.
+func (wr *WebhookRequest) parseSignatureWithRotation(ctx context.Context, signature string, publicKey *rsa.PublicKey) (*jwt.Token, *Error) {
+	token, err := parseSignatureWithKey(signature, publicKey)
+	if err == nil || wr.client.webhookKeys == nil {
+		return token, err
+	}
+
+	refreshed, fetchErr := wr.client.webhookKeys.get(ctx, wr.GetPublicKey, true)
+	if fetchErr != nil || refreshed.Equal(publicKey) {
+		return token, err
+	}
+
+	return parseSignatureWithKey(signature, refreshed)
+}
@@ -0,0 +1,80 @@
+package subrow
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"sync"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestWebhookPublicKeyCache(t *testing.T) {
+	const body = `{"webhook_type":"customer.created"}`
+
+	t.Run("When many webhooks are verified concurrently", func(t *testing.T) {
+		c := qt.New(t)
+
+		signer := newWebhookSigner(c)
+		client := New().SetBaseURL(signer.server.URL).SetApiKey("test_api_key")
+		signature := signer.sign(c, body, time.Now())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				valid, err := client.Webhook().ValidateBody(context.Background(), signature, body)
+				c.Check(err == nil, qt.IsTrue)
+				c.Check(valid, qt.IsTrue)
+			}()
+		}
+		wg.Wait()
+
+		c.Assert(signer.fetches.Load(), qt.Equals, int32(1))
+	})
+
+	t.Run("When the key is rotated", func(t *testing.T) {
+		c := qt.New(t)
+
+		signer := newWebhookSigner(c)
+		client := New().SetBaseURL(signer.server.URL).SetApiKey("test_api_key")
+
+		valid, err := client.Webhook().ValidateBody(context.Background(), signer.sign(c, body, time.Now()), body)
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(valid, qt.IsTrue)
+
+		// Pretend the cached key is old enough to be refreshed.
+		client.webhookKeys.mu.Lock()
+		client.webhookKeys.fetchedAt = time.Now().Add(-2 * webhookPublicKeyMinRefresh)
+		client.webhookKeys.mu.Unlock()
+
+		signer.rotate(c)
+		valid, err = client.Webhook().ValidateBody(context.Background(), signer.sign(c, body, time.Now()), body)
+		c.Assert(err == nil, qt.IsTrue)
+		c.Assert(valid, qt.IsTrue)
+		c.Assert(signer.fetches.Load(), qt.Equals, int32(2))
+	})
+
+	t.Run("When the key is preloaded", func(t *testing.T) {
+		c := qt.New(t)
+
+		signer := newWebhookSigner(c)
+		der, err := x509.MarshalPKIXPublicKey(&signer.key.PublicKey)
+		c.Assert(err, qt.IsNil)
+
+		publicKey, keyErr := ParseWebhookPublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		c.Assert(keyErr == nil, qt.IsTrue)
+
+		client := New().SetBaseURL("http://127.0.0.1:0").SetWebhookPublicKey(publicKey)
+		valid, validateErr := client.Webhook().ValidateBody(context.Background(), signer.sign(c, body, time.Now()), body)
+		c.Assert(validateErr == nil, qt.IsTrue)
+		c.Assert(valid, qt.IsTrue)
+
+		_, validateErr = client.Webhook().ValidateBody(context.Background(), "not_a_jwt", body)
+		c.Assert(validateErr != nil, qt.IsTrue)
+		c.Assert(signer.fetches.Load(), qt.Equals, int32(0))
+	})
+}
@@ -0,0 +1,143 @@
+// Package webhooktest signs webhooks the way Subrow does, so webhook
+// consumers can be tested without a Subrow instance.
+package webhooktest
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	subrow "github.com/subrowio/subrow-go-client"
+)
+
+const PublicKeyPath string = "/api/v1/webhooks/public_key"
+
+// Signer holds the RSA key pair and the HMAC key webhooks are signed with.
+type Signer struct {
+	PrivateKey *rsa.PrivateKey
+	HmacKey    string
+}
+
+// NewSigner generates a new RSA key pair and a random HMAC key.
+func NewSigner() (*Signer, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	hmacKey := make([]byte, 32)
+	if _, err := rand.Read(hmacKey); err != nil {
+		return nil, err
+	}
+
+	return &Signer{
+		PrivateKey: privateKey,
+		HmacKey:    hex.EncodeToString(hmacKey),
+	}, nil
+}
+
+// Sign returns the JWT signature of body, issued now.
+func (s *Signer) Sign(body string) (string, error) {
+	return s.SignAt(body, time.Now())
+}
+
+// SignAt returns the JWT signature of body with the given issue time, which
+// allows testing replay protection.
+func (s *Signer) SignAt(body string, issuedAt time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"data": body,
+		"iat":  issuedAt.Unix(),
+	})
+
+	return token.SignedString(s.PrivateKey)
+}
+
+// SignHmac returns the HMAC signature of body under HmacKey.
+func (s *Signer) SignHmac(body string) string {
+	return SignHmac(s.HmacKey, body)
+}
+
+// SignHmac returns the base64 encoded HMAC-SHA256 of body under hmacKey.
+func SignHmac(hmacKey string, body string) string {
+	mac := hmac.New(sha256.New, []byte(hmacKey))
+	mac.Write([]byte(body))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// PublicKeyPEM returns the PEM encoded public key, as accepted by
+// subrow.ParseWebhookPublicKey.
+func (s *Signer) PublicKeyPEM() []byte {
+	der, err := x509.MarshalPKIXPublicKey(&s.PrivateKey.PublicKey)
+	if err != nil {
+		panic(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// NewRequest builds a webhook delivery of body to url, signed with JWT.
+func (s *Signer) NewRequest(url string, body string) (*http.Request, error) {
+	signature, err := s.Sign(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRequest(url, body, subrow.JWT, signature)
+}
+
+// NewHmacRequest builds a webhook delivery of body to url, signed with HMAC.
+func (s *Signer) NewHmacRequest(url string, body string) (*http.Request, error) {
+	return newRequest(url, body, subrow.HMac, s.SignHmac(body))
+}
+
+// PublicKeyHandler serves the public key the way the webhooks/public_key
+// endpoint does.
+func (s *Signer) PublicKeyHandler() http.Handler {
+	encoded := base64.StdEncoding.EncodeToString(s.PublicKeyPEM())
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(encoded))
+	})
+}
+
+// NewServer starts a server exposing the signer's public key at
+// PublicKeyPath. Point a client at it with SetBaseURL(server.URL).
+func NewServer(signer *Signer) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle(PublicKeyPath, signer.PublicKeyHandler())
+
+	return httptest.NewServer(mux)
+}
+
+func newRequest(url string, body string, signatureAlgo subrow.SignatureAlgo, signature string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(subrow.WebhookSignatureHeader, signature)
+	req.Header.Set(subrow.WebhookSignatureAlgorithmHeader, string(signatureAlgo))
+	req.Header.Set(subrow.WebhookUniqueKeyHeader, uuid.New().String())
+
+	return req, nil
+}
@@ -0,0 +1,81 @@
+package webhooktest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	subrow "github.com/subrowio/subrow-go-client"
+	"github.com/subrowio/subrow-go-client/webhooktest"
+)
+
+const body = `{"webhook_type":"invoice.created","object_type":"invoice","invoice":{"number":"INV-001"}}`
+
+func TestSigner(t *testing.T) {
+	t.Run("When a JWT signed webhook is delivered", func(t *testing.T) {
+		c := qt.New(t)
+
+		signer, err := webhooktest.NewSigner()
+		c.Assert(err, qt.IsNil)
+		server := webhooktest.NewServer(signer)
+		defer server.Close()
+
+		client := subrow.New().SetBaseURL(server.URL)
+
+		var number string
+		dispatcher := subrow.NewWebhookDispatcher().OnInvoiceCreated(func(ctx context.Context, invoice *subrow.Invoice) error {
+			number = invoice.Number
+			return nil
+		})
+
+		req, err := signer.NewRequest("/webhooks", body)
+		c.Assert(err, qt.IsNil)
+		rec := httptest.NewRecorder()
+		client.Webhook().Handler(dispatcher, nil).ServeHTTP(rec, req)
+
+		c.Assert(rec.Code, qt.Equals, http.StatusOK)
+		c.Assert(number, qt.Equals, "INV-001")
+	})
+
+	t.Run("When the signature is validated directly", func(t *testing.T) {
+		c := qt.New(t)
+
+		signer, err := webhooktest.NewSigner()
+		c.Assert(err, qt.IsNil)
+		publicKey, keyErr := subrow.ParseWebhookPublicKey(signer.PublicKeyPEM())
+		c.Assert(keyErr == nil, qt.IsTrue)
+
+		client := subrow.New().SetWebhookPublicKey(publicKey).SetWebhookHmacKey(signer.HmacKey)
+
+		signature, err := signer.SignAt(body, time.Now().Add(-time.Hour))
+		c.Assert(err, qt.IsNil)
+		valid, validateErr := client.Webhook().ValidateBody(context.Background(), signature, body)
+		c.Assert(validateErr == nil, qt.IsTrue)
+		c.Assert(valid, qt.IsTrue)
+
+		valid, validateErr = client.Webhook().ValidateHmacBody(context.Background(), signer.SignHmac(body), body)
+		c.Assert(validateErr == nil, qt.IsTrue)
+		c.Assert(valid, qt.IsTrue)
+	})
+
+	t.Run("When an HMAC signed webhook is delivered", func(t *testing.T) {
+		c := qt.New(t)
+
+		signer, err := webhooktest.NewSigner()
+		c.Assert(err, qt.IsNil)
+
+		client := subrow.New().SetWebhookHmacKey(signer.HmacKey)
+		handler := client.Webhook().Handler(subrow.NewWebhookDispatcher(), &subrow.WebhookHandlerOptions{SignatureAlgo: subrow.HMac})
+
+		req, err := signer.NewHmacRequest("/webhooks", body)
+		c.Assert(err, qt.IsNil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		c.Assert(rec.Code, qt.Equals, http.StatusOK)
+	})
+}